@@ -0,0 +1,221 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"GoTower/internal/savefile"
+	"GoTower/internal/storage"
+
+	"github.com/pkg/errors"
+)
+
+// archiveVersion is the version of the profile archives' layout, an archive from a newer game is
+// refused rather than half imported
+const archiveVersion = 1
+
+// archiveManifest is the file of a profile archive that says it is one, and which version
+const archiveManifest = "manifest.json"
+
+// maxArchived is the biggest file an archive can bring in, so a broken one can't fill the disk
+const maxArchived = 64 << 20
+
+type archiveInfo struct {
+	Version  int       `json:"version"`
+	Exported time.Time `json:"exported"`
+}
+
+// archivedSaves are the saves that go in a profile archive, the stats have the high scores and
+// what's unlocked. The install key and the outbox stay behind, they're this install's: the
+// scores are signed with the key, and a copy of the outbox would submit them twice.
+var archivedSaves = []struct {
+	name string
+	kind *savefile.Kind
+}{
+	{storage.Settings, settingsFile},
+	{storage.Stats, statsFile},
+	{storage.Profile, profileFile},
+	{storage.ChallengeLog, challengeLogFile},
+}
+
+// archivedDirs are the directories that go in whole, the files in them are checked against their
+// kind on the way in when they have one
+var archivedDirs = []struct {
+	name string
+	kind *savefile.Kind
+}{
+	{storage.Runs, nil},
+}
+
+// exportProfile writes the player's saves and runs to a zip archive at path, to import
+// on another computer
+func exportProfile(path string) error {
+	dir, err := storage.Dir()
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "error exporting the profile")
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+
+	put := func(name string, data []byte) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	info, err := json.MarshalIndent(archiveInfo{Version: archiveVersion, Exported: time.Now()}, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error exporting the profile")
+	}
+	if err := put(archiveManifest, info); err != nil {
+		return errors.Wrap(err, "error exporting the profile")
+	}
+	n := 0
+	for _, s := range archivedSaves {
+		data, err := storage.ReadFile(s.name)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error exporting %s", s.name)
+		}
+		if err := put(s.name, data); err != nil {
+			return errors.Wrapf(err, "error exporting %s", s.name)
+		}
+		n++
+	}
+	for _, d := range archivedDirs {
+		files, err := ioutil.ReadDir(filepath.Join(dir, d.name))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error exporting the %s", d.name)
+		}
+		for _, fi := range files {
+			if !fi.Mode().IsRegular() {
+				continue
+			}
+			data, err := storage.ReadFile(d.name, fi.Name())
+			if err != nil {
+				return errors.Wrapf(err, "error exporting the %s", d.name)
+			}
+			// zip paths always use slashes
+			if err := put(d.name+"/"+fi.Name(), data); err != nil {
+				return errors.Wrapf(err, "error exporting the %s", d.name)
+			}
+			n++
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return errors.Wrap(err, "error exporting the profile")
+	}
+	fmt.Printf("exported %d files to %s\n", n, path)
+	return errors.Wrap(f.Close(), "error exporting the profile")
+}
+
+// importProfile brings in an archive made by exportProfile. Every file is checked before any is
+// written, so an archive from a newer game, or a broken one, leaves the saves as they were. The
+// saves it replaces are kept next to them as a .bak, like an upgrade does.
+func importProfile(path string) error {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return errors.Wrap(err, "error importing the profile")
+	}
+	defer zr.Close()
+
+	files := map[string][]byte{}
+	var names []string
+	for _, zf := range zr.File {
+		rc, err := zf.Open()
+		if err != nil {
+			return errors.Wrapf(err, "error importing %s", zf.Name)
+		}
+		data, err := ioutil.ReadAll(io.LimitReader(rc, maxArchived+1))
+		rc.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error importing %s", zf.Name)
+		}
+		if len(data) > maxArchived {
+			return errors.Errorf("error importing %s: it's bigger than %d MB", zf.Name, maxArchived>>20)
+		}
+		files[zf.Name] = data
+		if zf.Name != archiveManifest {
+			names = append(names, zf.Name)
+		}
+	}
+
+	var info archiveInfo
+	data, ok := files[archiveManifest]
+	if !ok {
+		return errors.Errorf("error importing the profile: %s isn't a profile archive", path)
+	}
+	if err := json.Unmarshal(data, &info); err != nil || info.Version < 1 {
+		return errors.Errorf("error importing the profile: %s has a broken manifest", path)
+	}
+	if info.Version > archiveVersion {
+		return errors.Errorf("error importing the profile: the archive is from a newer version of the game (version %d, this one knows up to %d)", info.Version, archiveVersion)
+	}
+
+	for _, name := range names {
+		kind, ok := archivedKind(name)
+		if !ok {
+			return errors.Errorf("error importing the profile: %s doesn't go in a profile", name)
+		}
+		// an old save is fine, it's upgraded when it's loaded, a newer one isn't
+		if kind != nil {
+			if _, _, err := kind.Upgrade(files[name]); err != nil {
+				return errors.Wrapf(err, "error importing %s", name)
+			}
+		}
+	}
+
+	for _, name := range names {
+		parts := strings.Split(name, "/")
+		if len(parts) == 1 {
+			if old, err := storage.ReadFile(name); err == nil {
+				if err := storage.WriteFile(old, 0644, name+".bak"); err != nil {
+					return errors.Wrapf(err, "error keeping the old %s", name)
+				}
+			}
+		}
+		if err := storage.WriteFile(files[name], 0644, parts...); err != nil {
+			return errors.Wrapf(err, "error importing %s", name)
+		}
+	}
+	fmt.Printf("imported %d files from %s, exported %s\n", len(names), path, info.Exported.Format("2006-01-02 15:04"))
+	return nil
+}
+
+// archivedKind is the kind of a file of an archive, ok is whether it goes in a profile at all.
+// Only the names the export writes are taken, so a file can't land outside the data directory.
+func archivedKind(name string) (kind *savefile.Kind, ok bool) {
+	for _, s := range archivedSaves {
+		if name == s.name {
+			return s.kind, true
+		}
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) != 2 || parts[1] == "" || parts[1] == "." || parts[1] == ".." || strings.ContainsAny(parts[1], `\:`) {
+		return nil, false
+	}
+	for _, d := range archivedDirs {
+		if parts[0] == d.name {
+			return d.kind, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"GoTower/internal/sprite"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+	"golang.org/x/image/font"
+)
+
+type assetEntry struct {
+	refs  int
+	ready chan struct{}
+	value interface{}
+	err   error
+}
+
+// assetManager shares decoded assets by key and counts who is using them, an asset is dropped as
+// soon as the last user releases it, so long sessions don't keep every texture around
+type assetManager struct {
+	mu      sync.Mutex
+	entries map[string]*assetEntry
+
+	// layers are directories looked in for every asset before the game's own files, the first one
+	// that has it wins, that's how mods replace textures and sounds
+	layers []string
+}
+
+func newAssetManager(layers ...string) *assetManager {
+	return &assetManager{entries: make(map[string]*assetEntry), layers: layers}
+}
+
+// resolve is where the asset at path (relative, with slashes) is loaded from, the first layer
+// that has the file, or the game's own
+func (am *assetManager) resolve(path string) string {
+	for _, dir := range am.layers {
+		p := filepath.Join(dir, filepath.FromSlash(path))
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return path
+}
+
+// acquire returns the asset under key, loading it first if nobody holds it yet, every successful
+// acquire must be paired with a release
+func (am *assetManager) acquire(key string, load func() (interface{}, error)) (interface{}, error) {
+	am.mu.Lock()
+	e, ok := am.entries[key]
+	if ok {
+		e.refs++
+		am.mu.Unlock()
+		<-e.ready
+	} else {
+		// load outside of the lock, so different assets can decode in parallel
+		e = &assetEntry{refs: 1, ready: make(chan struct{})}
+		am.entries[key] = e
+		am.mu.Unlock()
+		e.value, e.err = load()
+		close(e.ready)
+	}
+
+	if e.err != nil {
+		am.release(key)
+		return nil, e.err
+	}
+	return e.value, nil
+}
+
+func (am *assetManager) release(key string) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	e, ok := am.entries[key]
+	if !ok {
+		return
+	}
+	e.refs--
+	if e.refs <= 0 {
+		delete(am.entries, key)
+	}
+}
+
+// assetScope remembers everything acquired through it, so a screen (or a biome) can let go of all
+// of its assets at once when it's done
+type assetScope struct {
+	am *assetManager
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (am *assetManager) scope() *assetScope {
+	return &assetScope{am: am}
+}
+
+func (as *assetScope) acquire(key string, load func() (interface{}, error)) (interface{}, error) {
+	v, err := as.am.acquire(key, load)
+	if err != nil {
+		return nil, err
+	}
+	as.mu.Lock()
+	as.keys = append(as.keys, key)
+	as.mu.Unlock()
+	return v, nil
+}
+
+func (as *assetScope) release() {
+	as.mu.Lock()
+	keys := as.keys
+	as.keys = nil
+	as.mu.Unlock()
+	for _, k := range keys {
+		as.am.release(k)
+	}
+}
+
+func (as *assetScope) picture(path string) (pixel.Picture, error) {
+	path = as.am.resolve(path)
+	v, err := as.acquire("picture:"+path, func() (interface{}, error) {
+		pic, err := sprite.LoadPicture(path)
+		return pic, errors.Wrapf(err, "error loading picture %s", path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(pixel.Picture), nil
+}
+
+type animationSheet struct {
+	sheets map[string]pixel.Picture
+	anims  map[string][]sprite.Frame
+}
+
+func (as *assetScope) animationSheet(sheetPath, descPath string, frameWidth float64, required ...string) (*animationSheet, error) {
+	sheetPath, descPath = as.am.resolve(sheetPath), as.am.resolve(descPath)
+	key := fmt.Sprintf("anims:%s:%s:%v", sheetPath, descPath, frameWidth)
+	v, err := as.acquire(key, func() (interface{}, error) {
+		sheets, anims, err := sprite.LoadSheet(sheetPath, descPath, frameWidth, required...)
+		if err != nil {
+			return nil, err
+		}
+		return &animationSheet{sheets: sheets, anims: anims}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*animationSheet), nil
+}
+
+func (as *assetScope) font(path string, size float64) (font.Face, error) {
+	path = as.am.resolve(path)
+	v, err := as.acquire(fmt.Sprintf("font:%s:%v", path, size), func() (interface{}, error) {
+		return loadTTF(path, size)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(font.Face), nil
+}
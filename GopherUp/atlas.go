@@ -0,0 +1,137 @@
+package main
+
+import (
+	"image"
+	"image/draw"
+	"sort"
+
+	"GoTower/internal/sprite"
+
+	"github.com/faiface/pixel"
+)
+
+// atlasPadding keeps neighbouring images from bleeding into each other when sampled
+const atlasPadding = 1
+
+// atlasRegion is where a packed image ended up
+type atlasRegion struct {
+	pic  pixel.Picture
+	rect pixel.Rect
+}
+
+// remap moves a frame given in the original picture's coordinates into the atlas
+func (r atlasRegion) remap(orig pixel.Picture, frame pixel.Rect) pixel.Rect {
+	return frame.Moved(r.rect.Min.Sub(orig.Bounds().Min))
+}
+
+// packAtlases combines the pictures into as few shared atlases as possible (shelf packing, tallest
+// first), so everything drawn through one IMDraw needs just one texture
+func packAtlases(pics map[string]*pixel.PictureData, maxSize int) map[string]atlasRegion {
+	type item struct {
+		key string
+		pic *pixel.PictureData
+	}
+	var items []item
+	for k, p := range pics {
+		items = append(items, item{k, p})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		hi, hj := items[i].pic.Bounds().H(), items[j].pic.Bounds().H()
+		if hi != hj {
+			return hi > hj
+		}
+		return items[i].key < items[j].key
+	})
+
+	type placement struct {
+		key  string
+		page int
+		x, y int
+	}
+	var (
+		places      []placement
+		pageSizes   []image.Point
+		x, y, shelf int
+	)
+	newPage := func() {
+		pageSizes = append(pageSizes, image.Point{})
+		x, y, shelf = 0, 0, 0
+	}
+	newPage()
+	for _, it := range items {
+		w := int(it.pic.Bounds().W()) + atlasPadding
+		h := int(it.pic.Bounds().H()) + atlasPadding
+		if x+w > maxSize {
+			x, y, shelf = 0, y+shelf, 0
+		}
+		if y+h > maxSize && (x > 0 || y > 0) {
+			newPage()
+		}
+		page := len(pageSizes) - 1
+		places = append(places, placement{it.key, page, x, y})
+		if x+w > pageSizes[page].X {
+			pageSizes[page].X = x + w
+		}
+		if y+h > pageSizes[page].Y {
+			pageSizes[page].Y = y + h
+		}
+		x += w
+		if h > shelf {
+			shelf = h
+		}
+	}
+
+	// copy the pixels over, image coordinates have Y pointing down, pixel's point up
+	pages := make([]*image.RGBA, len(pageSizes))
+	for i, size := range pageSizes {
+		pages[i] = image.NewRGBA(image.Rect(0, 0, size.X, size.Y))
+	}
+	for _, pl := range places {
+		src := pics[pl.key].Image()
+		dst := image.Rect(pl.x, pl.y, pl.x+src.Bounds().Dx(), pl.y+src.Bounds().Dy())
+		draw.Draw(pages[pl.page], dst, src, src.Bounds().Min, draw.Src)
+	}
+	pagePics := make([]*pixel.PictureData, len(pages))
+	for i, p := range pages {
+		pagePics[i] = pixel.PictureDataFromImage(p)
+	}
+
+	regions := make(map[string]atlasRegion)
+	for _, pl := range places {
+		b := pics[pl.key].Bounds()
+		pageH := float64(pageSizes[pl.page].Y)
+		min := pixel.V(float64(pl.x), pageH-float64(pl.y)-b.H())
+		regions[pl.key] = atlasRegion{
+			pic:  pagePics[pl.page],
+			rect: pixel.Rect{Min: min, Max: min.Add(b.Size())},
+		}
+	}
+	return regions
+}
+
+// pictures lists the sheets to pack, keyed by prefix and sheet id
+func (a *animationSheet) pictures(prefix string) map[string]*pixel.PictureData {
+	pics := make(map[string]*pixel.PictureData, len(a.sheets))
+	for id, pic := range a.sheets {
+		pics[prefix+"/"+id] = pixel.PictureDataFromPicture(pic)
+	}
+	return pics
+}
+
+// packed returns a copy of the animation sheet with its frames pointing into the atlases
+func (a *animationSheet) packed(prefix string, regions map[string]atlasRegion) *animationSheet {
+	sheets := make(map[string]pixel.Picture, len(a.sheets))
+	for id := range a.sheets {
+		sheets[id] = regions[prefix+"/"+id].pic
+	}
+	anims := make(map[string][]sprite.Frame, len(a.anims))
+	for name, frames := range a.anims {
+		moved := make([]sprite.Frame, len(frames))
+		for i, f := range frames {
+			r := regions[prefix+"/"+f.Sheet]
+			moved[i] = sprite.Frame{Sheet: f.Sheet, Pic: r.pic, Rect: r.remap(a.sheets[f.Sheet], f.Rect)}
+		}
+		anims[name] = moved
+	}
+	return &animationSheet{sheets: sheets, anims: anims}
+}
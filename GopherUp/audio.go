@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/beep"
+	"github.com/faiface/beep/effects"
+	"github.com/faiface/beep/speaker"
+	"github.com/faiface/beep/wav"
+	"github.com/pkg/errors"
+)
+
+const (
+	audioSampleRate = beep.SampleRate(44100)
+	// tempoBoost is how much faster the music plays at maxSpeed, tempoRate how fast it gets there
+	tempoBoost = 0.12
+	tempoRate  = 0.02
+	// duckVolume is the music volume while ducked (in doublings), duckRate how fast it fades there
+	duckVolume = -1.5
+	duckRate   = 6
+)
+
+// audioState is what the mixer is doing, the game sets it and the mixer follows
+type audioState int
+
+const (
+	// audioPlaying is the music and the gameplay sounds
+	audioPlaying audioState = iota
+	// audioDucked is quieter music and no gameplay sounds, while paused or out of focus
+	audioDucked
+)
+
+// loopStreamer plays samples over and over
+type loopStreamer struct {
+	samples [][2]float64
+	pos     int
+}
+
+func (ls *loopStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) {
+		c := copy(samples[n:], ls.samples[ls.pos:])
+		n += c
+		ls.pos = (ls.pos + c) % len(ls.samples)
+	}
+	return n, true
+}
+
+func (ls *loopStreamer) Err() error {
+	return nil
+}
+
+// soundStreamer plays samples once
+type soundStreamer struct {
+	samples [][2]float64
+}
+
+func (ss *soundStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if len(ss.samples) == 0 {
+		return 0, false
+	}
+	n = copy(samples, ss.samples)
+	ss.samples = ss.samples[n:]
+	return n, true
+}
+
+func (ss *soundStreamer) Err() error {
+	return nil
+}
+
+// audio is the sound of the game: the music goes through a resampler, which speeds it up along
+// with the tower, then through its volume into the mixer the speaker plays. The gameplay sounds
+// have a mixer of their own, which can be paused and emptied.
+type audio struct {
+	set   *settings
+	state audioState
+
+	music    *beep.Resampler
+	musicVol *effects.Volume
+	sfx      *beep.Mixer
+	sfxCtrl  *beep.Ctrl
+	mixer    *beep.Mixer
+
+	// loop plays the tune, the day's or the night's, see ambientAt
+	loop       *loopStreamer
+	day, night [][2]float64
+
+	tempo float64
+	duck  float64
+
+	jump, goal, death, screech, fanfare [][2]float64
+
+	heartbeat [][2]float64
+}
+
+// startAudio opens the speaker and starts the music, a mod can replace any of the sounds with a
+// sounds/<name>.wav in its assets
+func startAudio(set *settings, am *assetManager) (*audio, error) {
+	if err := speaker.Init(audioSampleRate, audioSampleRate.N(time.Second/20)); err != nil {
+		return nil, errors.Wrap(err, "error starting audio")
+	}
+	sr := int(audioSampleRate)
+	a := &audio{
+		set:     set,
+		mixer:   &beep.Mixer{},
+		sfx:     &beep.Mixer{},
+		tempo:   1,
+		jump:    renderSweep(sr, square(0.5), 300, 600, 0.12, 0.15),
+		goal:    append(renderSweep(sr, triangle, pitch("E6"), pitch("E6"), 0.08, 0.3), renderSweep(sr, triangle, pitch("B6"), pitch("B6"), 0.16, 0.3)...),
+		death:   renderSweep(sr, square(0.5), 400, 60, 0.4, 0.2),
+		screech: renderSweep(sr, square(0.25), 900, 500, 0.5, 0.15),
+		fanfare: renderTune(sr, []voice{{square(0.5), 0.15, "C5:.25 E5:.25 G5:.25 C6:1"}}),
+	}
+	// lub, a short rest, dub
+	a.heartbeat = append(renderSweep(sr, triangle, 80, 50, 0.09, 0.5), make([][2]float64, sr*8/100)...)
+	a.heartbeat = append(a.heartbeat, renderSweep(sr, triangle, 70, 45, 0.1, 0.35)...)
+	a.day, a.night = renderTune(sr, gameTune), renderTune(sr, nightTune)
+	for name, sound := range map[string]*[][2]float64{
+		"music": &a.day, "night": &a.night, "jump": &a.jump, "goal": &a.goal, "death": &a.death, "screech": &a.screech, "fanfare": &a.fanfare,
+		"heartbeat": &a.heartbeat,
+	} {
+		path := "sounds/" + name + ".wav"
+		if p := am.resolve(path); p != path {
+			samples, err := loadSound(p)
+			if err != nil {
+				// a broken sound keeps the built-in one
+				fmt.Println(err)
+				continue
+			}
+			*sound = samples
+		}
+	}
+	a.loop = &loopStreamer{samples: a.day}
+	a.music = beep.ResampleRatio(4, 1, a.loop)
+	a.musicVol = &effects.Volume{Streamer: a.music, Base: 2}
+	a.sfxCtrl = &beep.Ctrl{Streamer: a.sfx}
+	a.mixer.Add(a.musicVol, a.sfxCtrl)
+	speaker.Play(a.mixer)
+	return a, nil
+}
+
+// loadSound decodes a wav file into samples at audioSampleRate
+func loadSound(path string) ([][2]float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading sound")
+	}
+	s, format, err := wav.Decode(f)
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrapf(err, "error loading sound %s", path)
+	}
+	defer s.Close()
+	var r beep.Streamer = s
+	if format.SampleRate != audioSampleRate {
+		r = beep.Resample(4, format.SampleRate, audioSampleRate, s)
+	}
+	var samples [][2]float64
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := r.Stream(buf)
+		samples = append(samples, buf[:n]...)
+		if !ok {
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrapf(err, "error loading sound %s", path)
+	}
+	if len(samples) == 0 {
+		return nil, errors.Errorf("error loading sound %s: it's empty", path)
+	}
+	return samples, nil
+}
+
+// setState switches the mixer to the state, ducking stops the gameplay sounds that are playing
+func (a *audio) setState(state audioState) {
+	if a == nil || state == a.state {
+		return
+	}
+	a.state = state
+	speaker.Lock()
+	defer speaker.Unlock()
+	if state == audioDucked {
+		a.sfx = &beep.Mixer{}
+		a.sfxCtrl.Streamer = a.sfx
+	}
+	a.sfxCtrl.Paused = state == audioDucked
+}
+
+// play starts a gameplay sound
+func (a *audio) play(sound [][2]float64) {
+	speaker.Lock()
+	defer speaker.Unlock()
+	if a.state == audioPlaying {
+		a.sfx.Add(&soundStreamer{samples: sound})
+	}
+}
+
+// targetTempo is the tempo for the current scroll speed, up to tempoBoost faster at maxSpeed
+func targetTempo() float64 {
+	t := math.Max(0, math.Min((tower.Scroll-tower.StartSpeed)/(tower.MaxSpeed-tower.StartSpeed), 1))
+	return 1 + tempoBoost*t
+}
+
+// update follows the settings and the scroll speed, a nil audio is a game without sound
+func (a *audio) update(dt float64) {
+	if a == nil {
+		return
+	}
+	target := 1.0
+	if a.set.MusicTempo {
+		target = targetTempo()
+	}
+	a.tempo = approach(a.tempo, target, tempoRate*dt)
+	duck := 0.0
+	if a.state == audioDucked {
+		duck = duckVolume
+	}
+	a.duck = approach(a.duck, duck, duckRate*dt)
+	tune := a.day
+	if d, ok := ambientAt(a.set.Ambient, time.Now()); ok && d >= dusk {
+		tune = a.night
+	}
+
+	speaker.Lock()
+	if &tune[0] != &a.loop.samples[0] {
+		a.loop.samples, a.loop.pos = tune, 0
+	}
+	a.music.SetRatio(a.tempo)
+	a.musicVol.Volume = a.duck
+	a.musicVol.Silent = !a.set.Music
+	speaker.Unlock()
+}
+
+func (a *audio) onEvent(e tower.Event) {
+	switch e := e.(type) {
+	case physics.Jumped:
+		a.play(a.jump)
+	case tower.GoalCollected:
+		a.play(a.goal)
+	case tower.BossWarned:
+		a.play(a.screech)
+	case dangerBeat:
+		a.play(a.heartbeat)
+	case physics.FloorReached:
+		if milestone(e.Floor) {
+			a.play(a.fanfare)
+		}
+	case tower.PrestigeReached:
+		a.play(a.fanfare)
+	case tower.CountdownTick:
+		if e.Left == 0 {
+			a.play(a.goal)
+		} else {
+			a.play(a.heartbeat)
+		}
+	case physics.Died:
+		a.play(a.death)
+		// the tempo snaps back on death and builds up again
+		a.tempo = 1
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/render"
+	"GoTower/internal/sprite"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+)
+
+// benchSim plays the first half of the canned replay, for a tower with as many platforms, hazards
+// and goals as a run has, and returns the sim with the gopher's state at every step of the way
+func benchSim(b *testing.B) (*tower.Sim, []physics.Body) {
+	chunks, err := tower.LoadChunks("chunks.json")
+	if err != nil {
+		b.Fatal(err)
+	}
+	r, err := loadReplay(filepath.Join("testdata", "canned.replay"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	// the sim subscribes to the bus, the tests after the benchmark get theirs back
+	old := tower.Bus
+	b.Cleanup(func() { tower.Bus = old })
+	tower.ResetWorld(r.Seed)
+	s := tower.NewSim(chunks)
+	var states []physics.Body
+	for i := 0; i < len(r.Inputs)/2; i++ {
+		s.Update(r.Step, r.command(i))
+		states = append(states, *s.Phys)
+	}
+	// nobody listens to what the benchmarks do
+	tower.Bus = &tower.EventBus{}
+	return s, states
+}
+
+// BenchmarkCollision is a step of the gopher's physics against all the platforms
+func BenchmarkCollision(b *testing.B) {
+	s, _ := benchSim(b)
+	platforms := s.Platforms.All()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gp := *s.Phys
+		gp.Update(tower.SimStep, pixel.V(1, 1), platforms, tower.PhysicsWorld())
+	}
+	b.ReportMetric(float64(len(platforms)), "platforms")
+}
+
+// BenchmarkGeneration throws the tower away and generates it again
+func BenchmarkGeneration(b *testing.B) {
+	s, _ := benchSim(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Reroll(-120)
+	}
+	b.ReportMetric(float64(len(s.Platforms.All())), "platforms")
+}
+
+// BenchmarkAnimation picks the gopher's frame for every step of the replay
+func BenchmarkAnimation(b *testing.B) {
+	_, anims, err := sprite.LoadSheet("sheet.png", "sheet.csv", 12, render.GopherAnimations...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	_, states := benchSim(b)
+	ga := render.NewGopherAnim(anims)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ga.Update(tower.SimStep, &states[i%len(states)])
+	}
+}
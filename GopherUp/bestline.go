@@ -0,0 +1,78 @@
+package main
+
+import (
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// bestLine marks the all-time best height inside the tower, so passing it is something you see
+type bestLine struct {
+	height float64
+	beaten bool
+
+	txt *text.Text
+}
+
+func newBestLine(height float64) *bestLine {
+	return &bestLine{
+		height: height,
+		txt:    text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+// worldY converts the record height into the current world coordinates
+func (bl *bestLine) worldY() float64 {
+	return bl.height - tower.Climbed - 120
+}
+
+func (bl *bestLine) onEvent(e tower.Event) {
+	if e, ok := e.(physics.FloorReached); ok && bl.height > 0 && e.Height > bl.height {
+		bl.beaten = true
+	}
+}
+
+func (bl *bestLine) visible() bool {
+	// nothing to mark on the first run, or when the line is off screen
+	y := bl.worldY()
+	return bl.height > 0 && y > -130 && y < 130
+}
+
+func (bl *bestLine) color() pixel.RGBA {
+	if bl.beaten {
+		return pixel.ToRGBA(colornames.Limegreen)
+	}
+	return pixel.ToRGBA(colornames.Gold)
+}
+
+func (bl *bestLine) draw(imd *imdraw.IMDraw) {
+	if !bl.visible() {
+		return
+	}
+	y := bl.worldY()
+
+	imd.Color = bl.color()
+	imd.Push(pixel.V(-160, y), pixel.V(160, y))
+	imd.Line(1)
+
+	// a small flag on the right edge
+	imd.Push(pixel.V(150, y), pixel.V(150, y+12))
+	imd.Line(1)
+	imd.Push(pixel.V(150, y+12), pixel.V(142, y+9), pixel.V(150, y+6))
+	imd.Polygon(0)
+}
+
+// drawLabel goes on top of the IMDraw batch so platforms don't hide it
+func (bl *bestLine) drawLabel(t pixel.Target) {
+	if !bl.visible() {
+		return
+	}
+	bl.txt.Clear()
+	bl.txt.Color = bl.color()
+	bl.txt.WriteString("BEST")
+	bl.txt.Draw(t, pixel.IM.Moved(pixel.V(140-bl.txt.Bounds().W(), bl.worldY()+2)))
+}
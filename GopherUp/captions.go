@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	// captionTime is how long a caption stays up, maxCaptions how many fit in the strip
+	captionTime = 2.5
+	maxCaptions = 3
+)
+
+// milestone is whether reaching the floor plays the fanfare, at the start of every tier
+func milestone(floor int) bool {
+	return floor > 0 && floor%tower.TierFloors == 0
+}
+
+type caption struct {
+	text string
+	ttl  float64
+}
+
+// captions shows the important sounds as text at the bottom of the screen, for players who can't
+// hear them. They come from the same events as the sounds.
+type captions struct {
+	shown []caption
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newCaptions() *captions {
+	return &captions{
+		imd: imdraw.New(nil),
+		txt: text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (c *captions) add(s string) {
+	c.shown = append(c.shown, caption{s, captionTime})
+	if len(c.shown) > maxCaptions {
+		c.shown = c.shown[1:]
+	}
+}
+
+func (c *captions) onEvent(e tower.Event) {
+	switch e := e.(type) {
+	case tower.GoalCollected:
+		c.add("[chime] goal")
+	case tower.BossWarned:
+		if e.Dir > 0 {
+			c.add("<< [screech] bird from the left")
+		} else {
+			c.add("[screech] bird from the right >>")
+		}
+	case physics.FloorReached:
+		if milestone(e.Floor) {
+			c.add(fmt.Sprintf("[fanfare] floor %d", e.Floor))
+		}
+	case tower.PrestigeReached:
+		c.add(fmt.Sprintf("[fanfare] prestige %d", e.Tier))
+	case dangerBeat:
+		if e.first {
+			c.add("[heartbeat] close to the bottom")
+		}
+	case physics.Died:
+		c.add("[crash] " + e.Cause)
+	}
+}
+
+func (c *captions) update(dt float64) {
+	kept := c.shown[:0]
+	for _, cp := range c.shown {
+		if cp.ttl -= dt; cp.ttl > 0 {
+			kept = append(kept, cp)
+		}
+	}
+	c.shown = kept
+}
+
+func (c *captions) draw(canvas *pixelgl.Canvas) {
+	if len(c.shown) == 0 {
+		return
+	}
+	c.txt.Clear()
+	c.txt.Color = colornames.White
+	for _, cp := range c.shown {
+		c.txt.Dot.X -= c.txt.BoundsOf(cp.text).W() / 2
+		c.txt.WriteString(cp.text + "\n")
+	}
+
+	// the newest at the bottom, on a dark strip so it reads over anything, scaled up from there
+	bounds := c.txt.Bounds()
+	zoom := interfaceZoom(bounds.Moved(bounds.Center().Scaled(-1)))
+	canvas.SetMatrix(pixel.IM.
+		Moved(pixel.V(0, 6-bounds.Min.Y)).
+		Scaled(pixel.ZV, zoom).
+		Moved(pixel.V(0, canvasBounds.Min.Y)).
+		Chained(screenMatrix()))
+	c.imd.Clear()
+	c.imd.Color = pixel.Alpha(0.6)
+	c.imd.Push(bounds.Min.Sub(pixel.V(3, 2)), bounds.Max.Add(pixel.V(3, 2)))
+	c.imd.Rectangle(0)
+	c.imd.Draw(canvas)
+	c.txt.Draw(canvas, pixel.IM)
+}
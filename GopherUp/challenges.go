@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"GoTower/internal/challenge"
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+
+	"github.com/pkg/errors"
+)
+
+// challengeTimeout is how long the loading screen waits for the server's challenges
+const challengeTimeout = 5 * time.Second
+
+// netClock is the server's time, the local clock plus the difference measured when the
+// challenges were fetched. Without the server it's just the local clock and synced is false.
+type netClock struct {
+	offset time.Duration
+	synced bool
+}
+
+func (c *netClock) now() time.Time {
+	return time.Now().Add(c.offset)
+}
+
+// loadChallenges is what the challenges are: from the server if there's one and it answers, else
+// its last answer while that's still for today, else made up from the date. The clock is the
+// server's when it answered.
+func loadChallenges(url string) (challenge.Current, *netClock) {
+	clock := &netClock{}
+	now := time.Now()
+	if url != "" {
+		cur, offset, err := fetchChallenges(url)
+		if err == nil {
+			if data, err := json.Marshal(cur); err == nil {
+				if err := storage.WriteFile(data, 0644, storage.Challenges); err != nil {
+					fmt.Println(err)
+				}
+			}
+			clock.offset, clock.synced = offset, true
+			return cur, clock
+		}
+		fmt.Println(err)
+	}
+
+	var cached challenge.Current
+	data, err := storage.ReadFile(storage.Challenges)
+	if err == nil && json.Unmarshal(data, &cached) == nil && cached.Daily == challenge.DailyAt(now) {
+		// the cached event may be over by now
+		if cached.Event != nil && !now.Before(cached.Event.Until) {
+			cached.Event = nil
+		}
+		return cached, clock
+	}
+	return challenge.At(now), clock
+}
+
+// fetchChallenges gets the challenges from the server, offset is how far ahead its clock is,
+// taking it was read halfway through the request
+func fetchChallenges(url string) (cur challenge.Current, offset time.Duration, err error) {
+	client := &http.Client{Timeout: challengeTimeout}
+	sent := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		return cur, 0, errors.Wrap(err, "error getting the challenges")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return cur, 0, errors.Errorf("error getting the challenges: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&cur); err != nil {
+		return cur, 0, errors.Wrap(err, "error getting the challenges")
+	}
+	if cur.Now.IsZero() {
+		return cur, 0, errors.New("error getting the challenges: the server didn't say the time")
+	}
+	received := time.Now()
+	return cur, cur.Now.Sub(sent.Add(received.Sub(sent) / 2)), nil
+}
+
+// challengeResult is how the first go at a challenge went, the ones after don't count
+type challengeResult struct {
+	Score  int       `json:"score"`
+	Height float64   `json:"height"`
+	At     time.Time `json:"at"`
+	// Synced is whether At is the server's time, runs played offline go by the local clock
+	Synced bool `json:"synced"`
+}
+
+// challengeLog is the challenges played on this install
+type challengeLog struct {
+	Version int `json:"version"`
+	// Done has the first run of every challenge played, by its name like "daily 2026-10-15"
+	Done map[string]challengeResult `json:"done"`
+}
+
+func loadChallengeLog() (*challengeLog, error) {
+	cl := &challengeLog{Done: map[string]challengeResult{}}
+	data, err := readSave(challengeLogFile, storage.ChallengeLog)
+	if os.IsNotExist(err) {
+		return cl, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the challenge log")
+	}
+	if err := json.Unmarshal(data, cl); err != nil {
+		return nil, errors.Wrap(err, "error loading the challenge log")
+	}
+	if cl.Done == nil {
+		cl.Done = map[string]challengeResult{}
+	}
+	return cl, nil
+}
+
+// complete records the run if it's the first one of its challenge, at the time on the clock
+func (cl *challengeLog) complete(rs runSummary, clock *netClock) {
+	if _, ok := cl.Done[rs.Challenge]; ok {
+		return
+	}
+	cl.Done[rs.Challenge] = challengeResult{Score: rs.Score, Height: rs.Height, At: clock.now().UTC(), Synced: clock.synced}
+	if err := cl.save(); err != nil {
+		fmt.Println(err)
+	}
+}
+
+func (cl *challengeLog) save() error {
+	cl.Version = challengeLogFile.Version()
+	data, err := json.MarshalIndent(cl, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving the challenge log")
+	}
+	return errors.Wrap(storage.WriteFile(data, 0644, storage.ChallengeLog), "error saving the challenge log")
+}
+
+// eventMutators are the mutators of the event
+func eventMutators(all []*tower.Mutator, ev *challenge.Event) []*tower.Mutator {
+	if ev == nil {
+		return nil
+	}
+	return tower.MutatorsByID(all, ev.Mutators)
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/pkg/errors"
+)
+
+// device is what the player plays with, the prompts show its buttons
+type device int
+
+const (
+	keyboard device = iota
+	// xboxPad is any gamepad that isn't a PlayStation one, they mostly use the Xbox names
+	xboxPad
+	playstationPad
+	devices
+)
+
+// bind is something the player does, on whichever device they use
+type bind int
+
+const (
+	bindUp bind = iota
+	bindDown
+	bindLeft
+	bindRight
+	bindJump
+	bindSkip
+	bindSlowmo
+	bindRestart
+	bindPause
+	bindConfirm
+	bindBack
+	binds
+)
+
+// stickDeadzone is how far the left stick has to be pushed to count as a direction
+const stickDeadzone = 0.5
+
+// binding is what does a bind: the keys, the gamepad buttons and a direction of the left stick,
+// with the name of its button on every device for the prompts. The gamepad buttons go by their
+// place, ButtonA is the cross on a PlayStation pad.
+type binding struct {
+	name    string
+	keys    []pixelgl.Button
+	buttons []pixelgl.GamepadButton
+	axis    pixelgl.GamepadAxis
+	dir     float64
+	glyphs  [devices]string
+}
+
+var bindings = [binds]binding{
+	bindUp:      {"up", []pixelgl.Button{pixelgl.KeyUp}, []pixelgl.GamepadButton{pixelgl.ButtonDpadUp}, pixelgl.AxisLeftY, -1, [devices]string{"UP", "UP", "UP"}},
+	bindDown:    {"down", []pixelgl.Button{pixelgl.KeyDown}, []pixelgl.GamepadButton{pixelgl.ButtonDpadDown}, pixelgl.AxisLeftY, +1, [devices]string{"DOWN", "DOWN", "DOWN"}},
+	bindLeft:    {"left", []pixelgl.Button{pixelgl.KeyLeft}, []pixelgl.GamepadButton{pixelgl.ButtonDpadLeft}, pixelgl.AxisLeftX, -1, [devices]string{"LEFT", "LEFT", "LEFT"}},
+	bindRight:   {"right", []pixelgl.Button{pixelgl.KeyRight}, []pixelgl.GamepadButton{pixelgl.ButtonDpadRight}, pixelgl.AxisLeftX, +1, [devices]string{"RIGHT", "RIGHT", "RIGHT"}},
+	bindJump:    {"jump", []pixelgl.Button{pixelgl.KeyUp}, []pixelgl.GamepadButton{pixelgl.ButtonA}, 0, 0, [devices]string{"UP", "A", "CROSS"}},
+	bindSkip:    {"skip", []pixelgl.Button{pixelgl.KeySpace}, []pixelgl.GamepadButton{pixelgl.ButtonX}, 0, 0, [devices]string{"SPACE", "X", "SQUARE"}},
+	bindSlowmo:  {"slowmo", []pixelgl.Button{pixelgl.KeyTab}, []pixelgl.GamepadButton{pixelgl.ButtonRightBumper}, 0, 0, [devices]string{"TAB", "RB", "R1"}},
+	bindRestart: {"restart", []pixelgl.Button{pixelgl.KeyR}, []pixelgl.GamepadButton{pixelgl.ButtonBack}, 0, 0, [devices]string{"R", "BACK", "SHARE"}},
+	bindPause:   {"pause", []pixelgl.Button{pixelgl.KeyEscape}, []pixelgl.GamepadButton{pixelgl.ButtonStart}, 0, 0, [devices]string{"ESC", "START", "OPTIONS"}},
+	bindConfirm: {"confirm", []pixelgl.Button{pixelgl.KeyEnter}, []pixelgl.GamepadButton{pixelgl.ButtonA}, 0, 0, [devices]string{"ENTER", "A", "CROSS"}},
+	bindBack:    {"back", []pixelgl.Button{pixelgl.KeyEscape}, []pixelgl.GamepadButton{pixelgl.ButtonB}, 0, 0, [devices]string{"ESC", "B", "CIRCLE"}},
+}
+
+// padButtons are the gamepad buttons by their names in the presets, the Xbox ones, and their
+// names on the pads
+var padButtons = []struct {
+	name   string
+	button pixelgl.GamepadButton
+	glyphs [devices]string
+}{
+	{"A", pixelgl.ButtonA, [devices]string{"", "A", "CROSS"}},
+	{"B", pixelgl.ButtonB, [devices]string{"", "B", "CIRCLE"}},
+	{"X", pixelgl.ButtonX, [devices]string{"", "X", "SQUARE"}},
+	{"Y", pixelgl.ButtonY, [devices]string{"", "Y", "TRIANGLE"}},
+	{"LB", pixelgl.ButtonLeftBumper, [devices]string{"", "LB", "L1"}},
+	{"RB", pixelgl.ButtonRightBumper, [devices]string{"", "RB", "R1"}},
+	{"BACK", pixelgl.ButtonBack, [devices]string{"", "BACK", "SHARE"}},
+	{"START", pixelgl.ButtonStart, [devices]string{"", "START", "OPTIONS"}},
+	{"LS", pixelgl.ButtonLeftThumb, [devices]string{"", "LS", "L3"}},
+	{"RS", pixelgl.ButtonRightThumb, [devices]string{"", "RS", "R3"}},
+	{"UP", pixelgl.ButtonDpadUp, [devices]string{"", "UP", "UP"}},
+	{"RIGHT", pixelgl.ButtonDpadRight, [devices]string{"", "RIGHT", "RIGHT"}},
+	{"DOWN", pixelgl.ButtonDpadDown, [devices]string{"", "DOWN", "DOWN"}},
+	{"LEFT", pixelgl.ButtonDpadLeft, [devices]string{"", "LEFT", "LEFT"}},
+}
+
+// keyByName is the key with the name pixelgl gives it, like "W" or "LeftShift"
+func keyByName(name string) (pixelgl.Button, bool) {
+	for k := pixelgl.KeySpace; k <= pixelgl.KeyLast; k++ {
+		if k.String() == name {
+			return k, true
+		}
+	}
+	return 0, false
+}
+
+// apply is the bindings with the preset on top, an error for the names it doesn't know
+func (cp controlPreset) apply() ([binds]binding, error) {
+	bs := bindings
+	byName := func(name string) (bind, bool) {
+		for b, bd := range bindings {
+			if bd.name == name {
+				return bind(b), true
+			}
+		}
+		return 0, false
+	}
+	for name, keys := range cp.Keys {
+		b, ok := byName(name)
+		if !ok {
+			return bs, errors.Errorf("preset %q: no such control %q", cp.Name, name)
+		}
+		bs[b].keys = nil
+		for _, k := range keys {
+			key, ok := keyByName(k)
+			if !ok {
+				return bs, errors.Errorf("preset %q: no such key %q", cp.Name, k)
+			}
+			bs[b].keys = append(bs[b].keys, key)
+		}
+		if len(keys) > 0 {
+			bs[b].glyphs[keyboard] = strings.ToUpper(keys[0])
+		}
+	}
+	for name, buttons := range cp.Buttons {
+		b, ok := byName(name)
+		if !ok {
+			return bs, errors.Errorf("preset %q: no such control %q", cp.Name, name)
+		}
+		bs[b].buttons = nil
+		for i, n := range buttons {
+			found := false
+			for _, pb := range padButtons {
+				if pb.name == n {
+					bs[b].buttons = append(bs[b].buttons, pb.button)
+					if i == 0 {
+						bs[b].glyphs[xboxPad], bs[b].glyphs[playstationPad] = pb.glyphs[xboxPad], pb.glyphs[playstationPad]
+					}
+					found = true
+				}
+			}
+			if !found {
+				return bs, errors.Errorf("preset %q: no such button %q", cp.Name, n)
+			}
+		}
+	}
+	return bs, nil
+}
+
+// controls is the input of the game, the keyboard and a gamepad alike. The last one touched is
+// the active one, the prompts follow it.
+type controls struct {
+	win    *pixelgl.Window
+	active device
+	// binds are the bindings of the preset in use, presets the ones to pick from and preset the
+	// one in use, saved is called with its name when the player picks another
+	binds   [binds]binding
+	presets []controlPreset
+	preset  int
+	saved   func(name string)
+	// pad is the gamepad last touched, held and was what its binds are this frame and the last
+	pad       pixelgl.Joystick
+	held, was [binds]bool
+	// sticks are whether the left stick of each gamepad is pushed, on each axis
+	sticks map[pixelgl.Joystick]*[2]bool
+}
+
+// ctl is the game's controls, set up with the window
+var ctl *controls
+
+func newControls(win *pixelgl.Window) *controls {
+	return &controls{win: win, binds: bindings, presets: builtinPresets, sticks: map[pixelgl.Joystick]*[2]bool{}}
+}
+
+// setPresets gives the presets to pick from and uses the one with the name, the first one when
+// there's none by that name. The presets are typed in by hand, the broken ones are left out.
+func (c *controls) setPresets(presets []controlPreset, name string, saved func(name string)) {
+	c.presets, c.saved = nil, saved
+	for _, p := range presets {
+		if _, err := p.apply(); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		c.presets = append(c.presets, p)
+	}
+	c.preset = 0
+	for i, p := range c.presets {
+		if p.Name == name {
+			c.preset = i
+		}
+	}
+	c.binds, _ = c.presets[c.preset].apply()
+}
+
+// presetName is the name of the preset in use
+func (c *controls) presetName() string {
+	return c.presets[c.preset].Name
+}
+
+// nextPreset switches to the next preset, and saves the choice
+func (c *controls) nextPreset() {
+	c.preset = (c.preset + 1) % len(c.presets)
+	c.binds, _ = c.presets[c.preset].apply()
+	// whatever was held was on the old keys
+	c.held, c.was = [binds]bool{}, [binds]bool{}
+	if c.saved != nil {
+		c.saved(c.presetName())
+	}
+}
+
+// padDevice tells a PlayStation pad from the others by its name
+func padDevice(name string) device {
+	name = strings.ToLower(name)
+	for _, s := range []string{"playstation", "dualshock", "dualsense", "ps3", "ps4", "ps5", "sony"} {
+		if strings.Contains(name, s) {
+			return playstationPad
+		}
+	}
+	return xboxPad
+}
+
+// update reads the gamepads and switches the active device to whatever was touched, once a frame
+func (c *controls) update() {
+	win := c.win
+	for b := pixelgl.KeySpace; b <= pixelgl.KeyLast; b++ {
+		if win.JustPressed(b) {
+			c.active = keyboard
+			break
+		}
+	}
+	for js := pixelgl.Joystick1; js <= pixelgl.JoystickLast; js++ {
+		if !win.JoystickPresent(js) {
+			continue
+		}
+		touched := false
+		for b := pixelgl.ButtonA; b <= pixelgl.ButtonDpadLeft; b++ {
+			touched = touched || win.JoystickJustPressed(js, b)
+		}
+		// a stick counts when it's pushed, not while it's held over
+		if c.sticks[js] == nil {
+			c.sticks[js] = &[2]bool{}
+		}
+		for i, a := range []pixelgl.GamepadAxis{pixelgl.AxisLeftX, pixelgl.AxisLeftY} {
+			pushed := math.Abs(win.JoystickAxis(js, a)) > stickDeadzone
+			touched = touched || pushed && !c.sticks[js][i]
+			c.sticks[js][i] = pushed
+		}
+		if touched {
+			c.pad, c.active = js, padDevice(win.JoystickName(js))
+		}
+	}
+
+	c.was = c.held
+	for b, bd := range c.binds {
+		c.held[b] = false
+		if !win.JoystickPresent(c.pad) {
+			continue
+		}
+		for _, btn := range bd.buttons {
+			c.held[b] = c.held[b] || win.JoystickPressed(c.pad, btn)
+		}
+		if bd.dir != 0 && win.JoystickAxis(c.pad, bd.axis)*bd.dir > stickDeadzone {
+			c.held[b] = true
+		}
+	}
+}
+
+// pressed is whether the bind is held down, on the keyboard or the gamepad
+func (c *controls) pressed(b bind) bool {
+	for _, k := range c.binds[b].keys {
+		if c.win.Pressed(k) {
+			return true
+		}
+	}
+	return c.held[b]
+}
+
+// justPressed is whether the bind was pressed this frame
+func (c *controls) justPressed(b bind) bool {
+	for _, k := range c.binds[b].keys {
+		if c.win.JustPressed(k) {
+			return true
+		}
+	}
+	return c.held[b] && !c.was[b]
+}
+
+// typingJustPressed is justPressed for the screens where something is typed in, the keys that
+// type, like the W of the WASD preset, are left out there
+func (c *controls) typingJustPressed(b bind) bool {
+	for _, k := range c.binds[b].keys {
+		// the printable keys all come before ESC
+		if k >= pixelgl.KeyEscape && c.win.JustPressed(k) {
+			return true
+		}
+	}
+	return c.held[b] && !c.was[b]
+}
+
+// glyph is the name of the bind's button on the active device
+func (c *controls) glyph(b bind) string {
+	return c.binds[b].glyphs[c.active]
+}
+
+// prompt puts the buttons of the active device in s, in place of the binds' names in braces, like
+// "{confirm} to climb"
+func (c *controls) prompt(s string) string {
+	for b, bd := range c.binds {
+		s = strings.Replace(s, "{"+bd.name+"}", c.glyph(bind(b)), -1)
+	}
+	return s
+}
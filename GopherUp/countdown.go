@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// at go, the game plays at goSlowmo of the speed for goTime while the camera, zoomed in on the
+// gopher during the countdown, pulls back out
+const (
+	goTime   = 0.6
+	goSlowmo = 0.35
+)
+
+// countdownShow is the countdown on the screen: the numbers, go, and the close-up of the gopher
+// that goes with them
+type countdownShow struct {
+	// shown is the number on the screen, -1 for none, and since how long it's been there
+	shown int
+	since float64
+	// slow is what's left of the slow motion after go
+	slow float64
+
+	txt *text.Text
+}
+
+func newCountdownShow() *countdownShow {
+	return &countdownShow{shown: -1, txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+func (cs *countdownShow) onEvent(e tower.Event) {
+	if e, ok := e.(tower.CountdownTick); ok {
+		cs.shown, cs.since = e.Left, 0
+		if e.Left == 0 {
+			cs.slow = goTime
+		}
+	}
+}
+
+// update is how much of dt the game plays, slower right after go, and how far the camera's zoomed
+// in on the gopher
+func (cs *countdownShow) update(dt float64, counting bool) (float64, float64) {
+	cs.since += dt
+	if cs.shown == 0 && cs.since > goTime*2 {
+		cs.shown = -1
+	}
+	switch {
+	case counting:
+		return dt, 1
+	case cs.slow > 0:
+		cs.slow -= dt
+		return dt * goSlowmo, math.Max(0, cs.slow/goTime)
+	}
+	return dt, 0
+}
+
+// draw shows the number, or go, big in the middle of bounds, each one shrinking as it goes, with
+// the hint how to skip them under the numbers
+func (cs *countdownShow) draw(t pixel.Target, bounds pixel.Rect, hint string) {
+	switch {
+	case cs.shown < 0:
+		return
+	case cs.shown == 0:
+		drawBig(cs.txt, t, bounds.Center(), 5, "GO!")
+	default:
+		drawBig(cs.txt, t, bounds.Center(), 6-2*math.Min(cs.since, 1), string(rune('0'+cs.shown)))
+		drawBig(cs.txt, t, bounds.Center().Sub(pixel.V(0, 40)), 1, hint)
+	}
+}
+
+// drawBig draws s scaled up size times, centered on at
+func drawBig(txt *text.Text, t pixel.Target, at pixel.Vec, size float64, s string) {
+	txt.Clear()
+	txt.Color = colornames.White
+	txt.WriteString(s)
+	b := txt.Bounds()
+	txt.Draw(t, pixel.IM.Moved(b.Center().Scaled(-1)).Scaled(pixel.ZV, size).Moved(at))
+}
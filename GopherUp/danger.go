@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// the warning starts physics.DangerRange above the kill zone and builds up to the gopher standing
+// on it. The camera is pulled down up to dangerPull to show the drop, the
+// heart beats every dangerSlowBeat at first and every dangerFastBeat at the edge.
+const (
+	dangerRate     = 4
+	dangerPull     = 12
+	dangerSlowBeat = 0.9
+	dangerFastBeat = 0.35
+	vignetteWidth  = 40
+)
+
+// dangerBeat is published on every heartbeat while the gopher is close to the kill zone, first
+// is the first one since it got close
+type dangerBeat struct {
+	level float64
+	first bool
+}
+
+// dangerWarning telegraphs a fall: the closer the gopher is to the kill zone, the redder the edges
+// of the screen, the faster the heartbeat and the further the camera pulls down. It's only the
+// presentation, the sim doesn't know about it.
+type dangerWarning struct {
+	// level goes from 0, safe, to 1, on the kill zone, eased so it doesn't flicker
+	level float64
+	beat  float64
+	near  bool
+
+	imd *imdraw.IMDraw
+}
+
+func newDangerWarning() *dangerWarning {
+	return &dangerWarning{imd: imdraw.New(nil)}
+}
+
+func (dw *dangerWarning) update(dt float64, phys *physics.Body) {
+	target := math.Max(0, math.Min(1, 1-(phys.Rect.Min.Y-physics.KillZone)/physics.DangerRange))
+	dw.level = approach(dw.level, target, dangerRate*dt)
+	if target == 0 {
+		dw.near, dw.beat = false, 0
+		return
+	}
+	if dw.beat -= dt; dw.beat <= 0 {
+		tower.Bus.Publish(dangerBeat{level: target, first: !dw.near})
+		dw.near = true
+		dw.beat = dangerSlowBeat + (dangerFastBeat-dangerSlowBeat)*target
+	}
+}
+
+// pull is how far down the camera goes
+func (dw *dangerWarning) pull() float64 {
+	return -dangerPull * dw.level
+}
+
+// draw darkens the edges of the canvas to red, the bottom most
+func (dw *dangerWarning) draw(t pixel.Target, bounds pixel.Rect) {
+	if dw.level == 0 {
+		return
+	}
+	imd := dw.imd
+	imd.Clear()
+	red := pixel.RGB(0.8, 0, 0)
+	edge := func(outer0, outer1, inner1, inner0 pixel.Vec, strength float64) {
+		imd.Color = red.Mul(pixel.Alpha(dw.level * strength))
+		imd.Push(outer0, outer1)
+		imd.Color = pixel.Alpha(0)
+		imd.Push(inner1, inner0)
+		imd.Polygon(0)
+	}
+	in := bounds.Resized(bounds.Center(), bounds.Size().Sub(pixel.V(2*vignetteWidth, 2*vignetteWidth)))
+	edge(bounds.Min, pixel.V(bounds.Max.X, bounds.Min.Y), pixel.V(in.Max.X, in.Min.Y), in.Min, 0.8)
+	edge(pixel.V(bounds.Min.X, bounds.Max.Y), bounds.Max, in.Max, pixel.V(in.Min.X, in.Max.Y), 0.3)
+	edge(bounds.Min, pixel.V(bounds.Min.X, bounds.Max.Y), pixel.V(in.Min.X, in.Max.Y), in.Min, 0.5)
+	edge(pixel.V(bounds.Max.X, bounds.Min.Y), bounds.Max, in.Max, pixel.V(in.Max.X, in.Min.Y), 0.5)
+	imd.Draw(t)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"time"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// daytime is the time of day the ambience shows, from the computer's clock or picked for good in
+// the settings
+type daytime int
+
+const (
+	dawn daytime = iota
+	day
+	dusk
+	night
+	daytimes
+)
+
+var daytimeNames = [daytimes]string{"dawn", "day", "dusk", "night"}
+
+// ambients are the choices for the time of day in the settings: none, the clock's, or one of
+// daytimeNames all the time. The first one is the default.
+var ambients = []string{"off", "clock", "dawn", "day", "dusk", "night"}
+
+func knownAmbient(name string) bool {
+	for _, a := range ambients {
+		if a == name {
+			return true
+		}
+	}
+	return false
+}
+
+// daytimeAt is the time of day at the hour, dawn from 5, day from 8, dusk from 18 and night from
+// 21
+func daytimeAt(hour int) daytime {
+	switch {
+	case hour >= 21 || hour < 5:
+		return night
+	case hour >= 18:
+		return dusk
+	case hour >= 8:
+		return day
+	}
+	return dawn
+}
+
+// ambientAt is the time of day the ambient setting shows at now, and whether it shows one at all
+func ambientAt(ambient string, now time.Time) (daytime, bool) {
+	if ambient == "clock" {
+		return daytimeAt(now.Hour()), true
+	}
+	for d, name := range daytimeNames {
+		if name == ambient {
+			return daytime(d), true
+		}
+	}
+	return 0, false
+}
+
+// sky is a time of day in a theme: the gradient behind the tower, from top to bottom, and the
+// light over it, premultiplied, the zero light leaves the colors alone
+type sky struct {
+	top, bottom pixel.RGBA
+	light       pixel.RGBA
+}
+
+// defaultSkies are the skies of the themes that don't have their own
+var defaultSkies = [daytimes]sky{
+	dawn: {
+		top:    pixel.RGB(0.2, 0.16, 0.3),
+		bottom: pixel.RGB(0.5, 0.32, 0.32),
+		light:  pixel.RGB(1, 0.7, 0.6).Mul(pixel.Alpha(0.08)),
+	},
+	day: {
+		top:    pixel.RGB(0.12, 0.22, 0.38),
+		bottom: pixel.RGB(0.3, 0.42, 0.55),
+	},
+	dusk: {
+		top:    pixel.RGB(0.15, 0.1, 0.3),
+		bottom: pixel.RGB(0.5, 0.22, 0.15),
+		light:  pixel.RGB(1, 0.5, 0.2).Mul(pixel.Alpha(0.12)),
+	},
+	night: {
+		top:    pixel.RGB(0.01, 0.01, 0.06),
+		bottom: pixel.RGB(0.05, 0.06, 0.15),
+		light:  pixel.RGB(0.05, 0.08, 0.25).Mul(pixel.Alpha(0.3)),
+	},
+}
+
+// drawSky fills bounds with the gradient, to go under everything else
+func (s sky) drawSky(imd *imdraw.IMDraw, bounds pixel.Rect) {
+	imd.Color = s.bottom
+	imd.Push(bounds.Min, pixel.V(bounds.Max.X, bounds.Min.Y))
+	imd.Color = s.top
+	imd.Push(bounds.Max, pixel.V(bounds.Min.X, bounds.Max.Y))
+	imd.Polygon(0)
+}
+
+// drawLight tints everything drawn so far in bounds with the light
+func (s sky) drawLight(imd *imdraw.IMDraw, bounds pixel.Rect) {
+	if s.light == (pixel.RGBA{}) {
+		return
+	}
+	imd.Color = s.light
+	imd.Push(bounds.Min, bounds.Max)
+	imd.Rectangle(0)
+}
+
+// nightTune is the music from dusk on, slower and lower than gameTune
+var nightTune = []voice{
+	{triangle, 0.14, "A4:1 E4:1 C5:1 E4:1 F4:1 C4:1 A4:1 C4:1 " +
+		"G4:1 D4:1 B4:1 D4:1 E4:2 -:2"},
+	{triangle, 0.25, "A2:2 E2:2 F2:2 C2:2 G2:2 D2:2 E2:4"},
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"GoTower/events"
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+)
+
+// the gameplay moments the simulation publishes, besides the gopher's own from physics.
+// Peripheral systems (score, stats, effects...) subscribe to the ones they care about instead of
+// being called from run()
+type (
+	// featureUsed is for the things outside the gameplay: pausing, slow motion...
+	featureUsed struct {
+		name string
+	}
+)
+
+// exported is the event as the events package has it, for the subscribers outside the game, nil
+// for the ones that stay inside
+func exported(e tower.Event) events.Event {
+	switch e := e.(type) {
+	case physics.Jumped:
+		return events.Jumped{X: e.Pos.X, Y: e.Pos.Y}
+	case physics.Landed:
+		return events.Landed{X: e.Pos.X, Y: e.Pos.Y, Speed: e.Speed}
+	case tower.GoalCollected:
+		return events.GoalCollected{X: e.Pos.X, Y: e.Pos.Y, Value: e.Value, Score: tower.Score}
+	case physics.FloorReached:
+		return events.FloorReached{Floor: e.Floor, Height: e.Height}
+	case physics.LifeLost:
+		return events.LifeLost{Cause: e.Cause, Left: e.Left}
+	case physics.Died:
+		return events.Died{Cause: e.Cause, Height: e.Height, Score: tower.Score}
+	case tower.BossSurvived:
+		return events.BossSurvived{Bonus: e.Bonus, Score: tower.Score}
+	case tower.PrestigeReached:
+		return events.PrestigeReached{Tier: e.Tier}
+	case featureUsed:
+		return events.FeatureUsed{Name: e.name}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/render"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// the arrow is arrowSize big and arrowMargin in from the edge of the screen
+const (
+	arrowSize   = 6
+	arrowMargin = 4
+)
+
+// goalArrow points at the goal from the edge of the screen while it's above or below it, with how
+// many floors away it is
+type goalArrow struct {
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newGoalArrow() *goalArrow {
+	return &goalArrow{imd: imdraw.New(nil), txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// draw draws the arrow in screen space, for the goal at pos with the camera showing view
+func (ga *goalArrow) draw(t pixel.Target, pos pixel.Vec, cam *render.Camera, view pixel.Rect) {
+	pos = pos.Sub(cam.Pos).Scaled(cam.Zoom)
+	var dir, dist float64
+	switch {
+	case pos.Y > view.Max.Y:
+		dir, dist = 1, pos.Y-view.Max.Y
+	case pos.Y < view.Min.Y:
+		dir, dist = -1, view.Min.Y-pos.Y
+	default:
+		return
+	}
+	edge := view.Max.Y
+	if dir < 0 {
+		edge = view.Min.Y
+	}
+	x := math.Max(view.Min.X+arrowSize+arrowMargin, math.Min(pos.X, view.Max.X-arrowSize-arrowMargin))
+	tip := pixel.V(x, edge-dir*arrowMargin)
+	base := tip.Sub(pixel.V(0, dir*arrowSize))
+
+	ga.imd.Clear()
+	ga.imd.Color = colornames.Gold
+	ga.imd.Push(tip, base.Add(pixel.V(-arrowSize/2, 0)), base.Add(pixel.V(arrowSize/2, 0)))
+	ga.imd.Polygon(0)
+	ga.imd.Draw(t)
+
+	ga.txt.Clear()
+	ga.txt.Color = colornames.Gold
+	fmt.Fprintf(ga.txt, "%d", int(math.Ceil(dist/physics.FloorHeight)))
+	b := ga.txt.Bounds()
+	// next to the arrow, on the side towards the middle of the screen
+	at := pixel.V(x+arrowSize, base.Y-b.H()/2+2)
+	if x > 0 {
+		at.X = x - arrowSize - b.W()*0.75
+	}
+	ga.txt.Draw(t, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(at))
+}
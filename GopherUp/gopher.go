@@ -0,0 +1,21 @@
+package main
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+)
+
+// approach moves v towards target by at most step
+func approach(v, target, step float64) float64 {
+	if v < target {
+		return math.Min(v+step, target)
+	}
+	return math.Max(v-step, target)
+}
+
+// playerHeight is the gopher's height above the bottom of the tower
+func playerHeight(phys *physics.Body) float64 {
+	return phys.Height(tower.Climbed)
+}
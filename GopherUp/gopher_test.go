@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+)
+
+// TestTunnelingAndSideHits moves the gopher at a thin platform in steps much longer than the
+// platform is thick or the gopher wide, it has to land on the platform instead of tunneling
+// through it, or stop at its side
+func TestTunnelingAndSideHits(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   pixel.Vec
+		vel    pixel.Vec
+		slope  float64
+		ground bool
+		want   pixel.Vec
+	}{
+		{"straight down", pixel.V(0, 100), pixel.V(0, -3000), 0, true, pixel.V(0, 2)},
+		// the feet come down on the slope where the gopher was then, not where the step ends
+		{"onto a slope", pixel.V(-20, 100), pixel.V(200, -3000), 40, true, pixel.V(0, 2+20-20*(1-98.0/320))},
+		// the feet pass under the end of the platform and run into its side
+		{"into the side", pixel.V(-40, 1.5), pixel.V(400, 0), 0, false, pixel.V(-26, 1.5)},
+	}
+	oldBus, oldSpe := tower.Bus, tower.Scroll
+	t.Cleanup(func() { tower.Bus, tower.Scroll = oldBus, oldSpe })
+	for _, tt := range tests {
+		tower.Bus, tower.Scroll = &tower.EventBus{}, 0
+		p := &entity.Platform{ID: 1, Rect: pixel.R(-20, 0, 20, 2), Slope: tt.slope}
+		gp := &physics.Body{Rect: pixel.R(-6, 0, 6, 14).Moved(tt.from), Vel: tt.vel, Normal: pixel.V(0, 1)}
+		gp.Update(0.1, pixel.ZV, []*entity.Platform{p}, tower.PhysicsWorld())
+		at := pixel.V(gp.Rect.Center().X, gp.Rect.Min.Y)
+		if gp.Ground != tt.ground || at.To(tt.want).Len() > 1e-9 {
+			t.Errorf("%s: gopher at %v, on the ground %v, want %v, %v", tt.name, at, gp.Ground, tt.want, tt.ground)
+		}
+	}
+}
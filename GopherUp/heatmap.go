@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/profile"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// deaths are counted in sections of deathSection floors
+const deathSection = 10
+
+func deathSectionAt(height float64) int {
+	return int(math.Max(height, 0)/physics.FloorHeight) / deathSection
+}
+
+// heat goes from dark blue for no deaths, through red, to yellow for the most deaths
+func heat(f float64) color.Color {
+	cold := pixel.ToRGBA(colornames.Midnightblue)
+	if f <= 0 {
+		return cold
+	}
+	if f < 0.5 {
+		return cold.Mul(pixel.Alpha(1 - 2*f)).Add(pixel.ToRGBA(colornames.Red).Mul(pixel.Alpha(2 * f)))
+	}
+	return pixel.ToRGBA(colornames.Red).Mul(pixel.Alpha(2 - 2*f)).Add(pixel.ToRGBA(colornames.Yellow).Mul(pixel.Alpha(2*f - 1)))
+}
+
+// statsScreen shows the records and a strip of the tower colored by where the gopher died the
+// most, so the player knows which sections to practice
+type statsScreen struct {
+	win     *pixelgl.Window
+	screens *screenStack
+	st      *stats
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newStatsScreen(win *pixelgl.Window, screens *screenStack, st *stats) *statsScreen {
+	return &statsScreen{
+		win:     win,
+		screens: screens,
+		st:      st,
+		imd:     imdraw.New(nil),
+		txt:     text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (ss *statsScreen) update(dt float64) {
+	if ctl.justPressed(bindBack) || ctl.justPressed(bindConfirm) || ss.win.JustPressed(pixelgl.MouseButtonLeft) {
+		ss.screens.pop()
+	}
+}
+
+func (ss *statsScreen) draw(canvas *pixelgl.Canvas) {
+	canvas.SetMatrix(screenMatrix())
+	imd := ss.imd
+	imd.Clear()
+	imd.Color = pixel.Alpha(0.8)
+	imd.Push(canvasBounds.Min, canvasBounds.Max)
+	imd.Rectangle(0)
+	imd.Draw(canvas)
+	imd.Clear()
+	canvas.SetMatrix(interfaceMatrix(canvasBounds))
+
+	// the strip covers the tower up to the best height, or the highest death if the record was
+	// beaten in a run that's still going
+	sections := deathSectionAt(ss.st.BestHeight) + 1
+	most, worst := 0, 0
+	for s, n := range ss.st.Deaths {
+		if s+1 > sections {
+			sections = s + 1
+		}
+		if n > most || n == most && s < worst {
+			most, worst = n, s
+		}
+	}
+	strip := pixel.R(80, -90, 100, 90)
+	h := strip.H() / float64(sections)
+	for s := 0; s < sections; s++ {
+		f := 0.0
+		if most > 0 {
+			f = float64(ss.st.Deaths[s]) / float64(most)
+		}
+		imd.Color = heat(f)
+		imd.Push(pixel.V(strip.Min.X, strip.Min.Y+float64(s)*h), pixel.V(strip.Max.X, strip.Min.Y+float64(s+1)*h))
+		imd.Rectangle(0)
+	}
+	imd.Color = colornames.Gold
+	best := strip.Min.Y + strip.H()*ss.st.BestHeight/physics.FloorHeight/deathSection/float64(sections)
+	imd.Push(pixel.V(strip.Min.X-4, best), pixel.V(strip.Max.X+4, best))
+	imd.Line(1)
+	imd.Draw(canvas)
+
+	ss.txt.Clear()
+	ss.txt.Color = colornames.White
+	ss.txt.WriteString("STATS\n\n")
+	ss.txt.Color = colornames.Lightgrey
+	fmt.Fprintf(ss.txt, "Runs:        %d\n", ss.st.Runs)
+	fmt.Fprintf(ss.txt, "Best floor:  %d\n", int(ss.st.BestHeight/physics.FloorHeight))
+	fmt.Fprintf(ss.txt, "Best score:  %d\n\n", ss.st.BestScore)
+	if most > 0 {
+		ss.txt.WriteString("You die most on\n")
+		ss.txt.Color = colornames.Gold
+		fmt.Fprintf(ss.txt, "floors %d-%d", worst*deathSection, (worst+1)*deathSection-1)
+	}
+	// the records move up to make room for the high scores
+	top := 40.0
+	if len(ss.st.Top) > 0 {
+		top = 100
+	}
+	ss.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-140, top)))
+
+	// the high scores, in the colors of the avatars they were played with
+	if len(ss.st.Top) > 0 {
+		ss.txt.Clear()
+		ss.txt.Color = colornames.White
+		ss.txt.WriteString("HIGH SCORES\n")
+		for i, r := range ss.st.Top {
+			ss.txt.Color = profile.AvatarAt(r.Avatar).Color
+			fmt.Fprintf(ss.txt, "%d. %-12s %5d  F%d", i+1, r.Name, r.Score, r.Floor)
+			if r.Style != "" && r.Style != tower.MoveStyles[0].Name {
+				ss.txt.WriteString("  " + r.Style)
+			}
+			ss.txt.WriteString("\n")
+		}
+		ss.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-140, -20)))
+	}
+
+	ss.txt.Clear()
+	ss.txt.Color = colornames.Lightgrey
+	fmt.Fprintf(ss.txt, "%d", sections*deathSection)
+	ss.txt.Draw(canvas, pixel.IM.Moved(pixel.V(strip.Max.X+6, strip.Max.Y-8)))
+	ss.txt.Clear()
+	ss.txt.WriteString("0")
+	ss.txt.Draw(canvas, pixel.IM.Moved(pixel.V(strip.Max.X+6, strip.Min.Y)))
+}
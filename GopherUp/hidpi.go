@@ -0,0 +1,33 @@
+package main
+
+import (
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// contentScale is how many pixels the monitor has for every pixel of a 96dpi one, in quarter
+// steps, worked out from its resolution and physical size. Windows and most Linux desktops
+// don't scale for the game, so the window and the text are scaled by it to keep their size on
+// HiDPI screens. Monitors that don't report a physical size are 1.
+func contentScale(m *pixelgl.Monitor) float64 {
+	w, _ := m.Size()
+	mm, _ := m.PhysicalSize()
+	if w <= 0 || mm <= 0 {
+		return 1
+	}
+	dpi := w / (mm / 25.4)
+	scale := math.Round(dpi/96*4) / 4
+	return math.Max(1, math.Min(scale, 4))
+}
+
+// canvasScale is how much the canvas is blown up to fill the window, whole pixels when it fits
+// at least once, so every canvas pixel becomes the same number of screen pixels and stays crisp
+func canvasScale(win, canvas pixel.Rect) float64 {
+	scale := math.Min(win.W()/canvas.W(), win.H()/canvas.H())
+	if scale >= 1 {
+		return math.Floor(scale)
+	}
+	return scale
+}
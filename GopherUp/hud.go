@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+	"golang.org/x/image/font"
+)
+
+// hudSize is the size of the HUD's font, before the UI scale, and hudMargin how far it is from
+// the window's corner, in the window's pixels
+const (
+	hudSize   = 18
+	hudMargin = 12
+)
+
+// headsUp is the readouts over the game: the score, the best one and the height, and whatever else
+// sets one. It's drawn on the window after the canvas is stretched over it, in the window's
+// pixels, so the text stays sharp whatever the canvas' resolution. The readouts are for a frame,
+// what isn't set again the next frame isn't shown.
+type headsUp struct {
+	txt *text.Text
+	// size is the size of the font txt is in, load loads it in another one when the scale changes
+	size float64
+	load func(size float64) (font.Face, error)
+	// keys are the readouts in the order they were first set, values the ones set this frame
+	keys   []string
+	values map[string]string
+}
+
+// hud is the game's HUD, set up once the font is loaded, the readouts set before are dropped
+var hud *headsUp
+
+func newHeadsUp(face font.Face, size float64, load func(size float64) (font.Face, error)) *headsUp {
+	return &headsUp{txt: hudText(face), size: size, load: load, values: map[string]string{}}
+}
+
+func hudText(face font.Face) *text.Text {
+	txt := text.New(pixel.ZV, text.NewAtlas(face, text.ASCII))
+	txt.Color = colornames.White
+	return txt
+}
+
+// set shows the readout for this frame, like set("score", "120"), the readouts keep the order
+// they were first set in
+func (h *headsUp) set(key, value string) {
+	if h == nil {
+		return
+	}
+	if _, ok := h.values[key]; !ok {
+		known := false
+		for _, k := range h.keys {
+			known = known || k == key
+		}
+		if !known {
+			h.keys = append(h.keys, key)
+		}
+	}
+	h.values[key] = value
+}
+
+// draw puts the frame's readouts in the top right corner of the window, one a line and lined up
+// on the right, out of the way of the movement stats, and clears them for the next frame. scale is
+// the content scale times the UI scale, the font is loaded again at its size rather than
+// stretched, so it stays sharp; a font that doesn't load keeps the old size.
+func (h *headsUp) draw(win *pixelgl.Window, scale float64) {
+	if h == nil || len(h.values) == 0 {
+		return
+	}
+	if size := hudSize * scale; size != h.size {
+		h.size = size
+		if face, err := h.load(size); err == nil {
+			h.txt = hudText(face)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	h.txt.Clear()
+	for _, k := range h.keys {
+		if v, ok := h.values[k]; ok {
+			line := k + "  " + v
+			h.txt.Dot.X -= h.txt.BoundsOf(line).W()
+			h.txt.WriteString(line + "\n")
+		}
+	}
+	h.values = map[string]string{}
+
+	b := win.Bounds()
+	at := pixel.V(b.Max.X-hudMargin*scale, b.Max.Y-hudMargin*scale-h.txt.LineHeight)
+	win.SetMatrix(pixel.IM)
+	// a shadow, the tower behind is any color
+	h.txt.DrawColorMask(win, pixel.IM.Moved(at.Add(pixel.V(scale, -scale))), pixel.RGBA{A: 0.8})
+	h.txt.Draw(win, pixel.IM.Moved(at))
+}
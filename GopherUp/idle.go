@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/text"
+)
+
+// idleTimes are the choices for how many seconds without input pause a run, zero never does
+var idleTimes = []int{30, 60, 15, 0}
+
+// resumeTime is how long the countdown after an idle pause takes, the tower waits for it
+const resumeTime = 3
+
+// idleWatch pauses the run when the player's been away from the keys for a while, rather than
+// letting the tower scroll the gopher out, and counts down before the tower starts again
+type idleWatch struct {
+	idle float64
+	// resume is what's left of the countdown, zero when there's none
+	resume float64
+
+	txt *text.Text
+}
+
+func newIdleWatch() *idleWatch {
+	return &idleWatch{txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// update counts the time without input and is whether it's been after, seconds long; seconds
+// of zero never is
+func (iw *idleWatch) update(dt float64, actions byte, after int) bool {
+	if actions != 0 || after == 0 {
+		iw.idle = 0
+		return false
+	}
+	iw.idle += dt
+	if iw.idle < float64(after) {
+		return false
+	}
+	iw.idle, iw.resume = 0, resumeTime
+	return true
+}
+
+// counting is whether the countdown is on, it runs it down by dt
+func (iw *idleWatch) counting(dt float64) bool {
+	if iw.resume <= 0 {
+		return false
+	}
+	iw.resume -= dt
+	return true
+}
+
+// draw shows the countdown big in the middle of bounds
+func (iw *idleWatch) draw(t pixel.Target, bounds pixel.Rect) {
+	if iw.resume <= 0 {
+		return
+	}
+	drawBig(iw.txt, t, bounds.Center(), 4, fmt.Sprint(math.Ceil(iw.resume)))
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// inputKeys is the layout of the input display, the input bits with where their key goes (in key
+// sizes from the bottom left) and its label
+var inputKeys = []struct {
+	bit   byte
+	at    pixel.Vec
+	label string
+}{
+	{tower.InputLeft, pixel.V(0, 0), "<"},
+	{tower.InputDown, pixel.V(1, 0), "v"},
+	{tower.InputRight, pixel.V(2, 0), ">"},
+	{tower.InputJump, pixel.V(1, 1), "^"},
+}
+
+const (
+	inputKeySize = 12
+	inputKeyGap  = 2
+	// inputJumpFlash is how long a jump stays lit after the frame it happened on
+	inputJumpFlash = 0.15
+)
+
+// inputDisplay shows the keys the player is holding in the corner of the screen, for whoever is
+// watching a stream or a video
+type inputDisplay struct {
+	held  byte
+	flash float64
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newInputDisplay() *inputDisplay {
+	return &inputDisplay{
+		imd: imdraw.New(nil),
+		txt: text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+// update takes the input the gopher got this frame, a jump is a single frame, so it's kept lit
+// for a moment to be seen
+func (id *inputDisplay) update(dt float64, input byte) {
+	id.flash -= dt
+	if input&tower.InputJump != 0 {
+		id.flash = inputJumpFlash
+	}
+	id.held = input
+	if id.flash > 0 {
+		id.held |= tower.InputJump
+	}
+}
+
+func (id *inputDisplay) draw(canvas *pixelgl.Canvas) {
+	canvas.SetMatrix(screenMatrix())
+	corner := canvasBounds.Min.Add(pixel.V(6, 6))
+
+	id.imd.Clear()
+	id.txt.Clear()
+	for _, k := range inputKeys {
+		min := corner.Add(k.at.Scaled(inputKeySize + inputKeyGap))
+		key := pixel.R(min.X, min.Y, min.X+inputKeySize, min.Y+inputKeySize)
+		id.imd.Color, id.txt.Color = pixel.Alpha(0.5), colornames.White
+		if id.held&k.bit != 0 {
+			id.imd.Color, id.txt.Color = colornames.Gold, colornames.Black
+		}
+		id.imd.Push(key.Min, key.Max)
+		id.imd.Rectangle(0)
+
+		id.txt.Dot = key.Center().Sub(id.txt.BoundsOf(k.label).Center().Sub(id.txt.Dot))
+		id.txt.WriteString(k.label)
+	}
+	id.imd.Draw(canvas)
+	id.txt.Draw(canvas, pixel.IM)
+}
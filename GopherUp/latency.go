@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"GoTower/internal/storage"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// latencyFrames is how many frames the latency stages are summed up over
+const latencyFrames = 240
+
+// latencyProbe times the stages between the input and the screen that the game can see, every
+// frame: from polling the input to the screens updating with it, from there to the frame handed
+// to the driver, and the swap itself, which waits for the monitor with vsync on. What happens
+// before the poll (the OS, the keyboard) and after the swap (the driver, the monitor) can't be
+// seen from here.
+type latencyProbe struct {
+	polled, updating, updated time.Time
+	// samples are the stages of the last frames, oldest first
+	samples [][latencyStages]time.Duration
+}
+
+type latencyStage int
+
+const (
+	// pollToSim is from polling the input to the screens updating with it, the frame cap's wait
+	// is in it
+	pollToSim latencyStage = iota
+	// simulate is updating the screens
+	simulate
+	// simToSwap is drawing and handing the frame over
+	simToSwap
+	// swap is the buffer swap, with vsync it waits for the monitor
+	swap
+	latencyStages
+)
+
+var latencyStageNames = [latencyStages]string{"poll to sim", "simulate", "sim to swap", "swap"}
+
+// markPolled, markUpdating, markUpdated and markSwapped are called at those points of every frame,
+// the swap ends the frame's sample
+func (lp *latencyProbe) markPolled()   { lp.polled = time.Now() }
+func (lp *latencyProbe) markUpdating() { lp.updating = time.Now() }
+func (lp *latencyProbe) markUpdated()  { lp.updated = time.Now() }
+
+func (lp *latencyProbe) markSwapped(swapping time.Time) {
+	if lp.polled.IsZero() {
+		return
+	}
+	lp.samples = append(lp.samples, [latencyStages]time.Duration{
+		pollToSim: lp.updating.Sub(lp.polled),
+		simulate:  lp.updated.Sub(lp.updating),
+		simToSwap: swapping.Sub(lp.updated),
+		swap:      time.Since(swapping),
+	})
+	if len(lp.samples) > latencyFrames {
+		lp.samples = lp.samples[len(lp.samples)-latencyFrames:]
+	}
+}
+
+// reset forgets the samples, after a change like the vsync that makes the old ones meaningless
+func (lp *latencyProbe) reset() {
+	lp.samples = nil
+}
+
+// report is the mean and the 95th percentile of each stage and of all of them, in milliseconds
+func (lp *latencyProbe) report() []string {
+	if len(lp.samples) == 0 {
+		return []string{"measuring..."}
+	}
+	ms := func(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
+	line := func(name string, ds []time.Duration) string {
+		var sum time.Duration
+		for _, d := range ds {
+			sum += d
+		}
+		sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+		p95 := ds[len(ds)*95/100]
+		return fmt.Sprintf("%-12s %5.1f  p95 %5.1f", name, ms(sum)/float64(len(ds)), ms(p95))
+	}
+	var lines []string
+	totals := make([]time.Duration, len(lp.samples))
+	for st := latencyStage(0); st < latencyStages; st++ {
+		ds := make([]time.Duration, len(lp.samples))
+		for i, s := range lp.samples {
+			ds[i] = s[st]
+			totals[i] += s[st]
+		}
+		lines = append(lines, line(latencyStageNames[st], ds))
+	}
+	return append(lines, line("total", totals))
+}
+
+// latencyOverlay shows the latency stages live over whatever is playing, for players with laggy
+// controls to send in. It's hidden: ctrl+shift+L shows and hides it, ctrl+shift+S saves the
+// numbers.
+type latencyOverlay struct {
+	win   *pixelgl.Window
+	lp    *latencyProbe
+	set   *settings
+	shown bool
+	// vsync is what the samples were taken with, they start over when it changes
+	vsync bool
+	// saved is the file the numbers were saved to last, to show the player where to find it
+	saved string
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newLatencyOverlay(win *pixelgl.Window, lp *latencyProbe, set *settings) *latencyOverlay {
+	return &latencyOverlay{win: win, lp: lp, set: set, imd: imdraw.New(nil), txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// text is the report with what it was measured with, for a bug report
+func (lo *latencyOverlay) text() string {
+	fps := fullFPS
+	if lo.set.LowPower {
+		fps = lowPowerFPS
+	}
+	return fmt.Sprintf("INPUT LATENCY (ms, %d frames)\nvsync %v, cap %d fps\n\n%s",
+		len(lo.lp.samples), lo.win.VSync(), fps, strings.Join(lo.lp.report(), "\n"))
+}
+
+// save writes the report to the logs directory, and prints it for whoever runs the game from a
+// terminal
+func (lo *latencyOverlay) save() {
+	report := lo.text()
+	fmt.Println(report)
+	name := "latency-" + time.Now().Format("2006-01-02T15-04-05") + ".txt"
+	if err := storage.WriteFile([]byte(report+"\n"), 0644, storage.Logs, name); err != nil {
+		fmt.Println(err)
+		return
+	}
+	lo.saved = name
+}
+
+func (lo *latencyOverlay) update() {
+	win := lo.win
+	ctrl := win.Pressed(pixelgl.KeyLeftControl) || win.Pressed(pixelgl.KeyRightControl)
+	shift := win.Pressed(pixelgl.KeyLeftShift) || win.Pressed(pixelgl.KeyRightShift)
+	if ctrl && shift && win.JustPressed(pixelgl.KeyL) {
+		lo.shown, lo.saved = !lo.shown, ""
+		lo.lp.reset()
+	}
+	if lo.shown && ctrl && shift && win.JustPressed(pixelgl.KeyS) {
+		lo.save()
+	}
+	if v := win.VSync(); v != lo.vsync {
+		lo.vsync = v
+		lo.lp.reset()
+	}
+}
+
+// draw puts the numbers in the top left corner of the canvas, over everything
+func (lo *latencyOverlay) draw(canvas *pixelgl.Canvas) {
+	if !lo.shown {
+		return
+	}
+	canvas.SetMatrix(screenMatrix())
+	lo.txt.Clear()
+	lo.txt.Color = colornames.White
+	lo.txt.WriteString(lo.text())
+	if lo.saved != "" {
+		lo.txt.WriteString("\n\nsaved to " + storage.Logs + "/\n" + lo.saved)
+	}
+	b := lo.txt.Bounds()
+	at := pixel.V(canvasBounds.Min.X+4-b.Min.X, canvasBounds.Max.Y-4-b.Max.Y)
+
+	lo.imd.Clear()
+	lo.imd.Color = pixel.RGBA{A: 0.7}
+	lo.imd.Push(b.Min.Add(at).Sub(pixel.V(2, 2)), b.Max.Add(at).Add(pixel.V(2, 2)))
+	lo.imd.Rectangle(0)
+	lo.imd.Draw(canvas)
+	lo.txt.Draw(canvas, pixel.IM.Moved(at))
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"GoTower/internal/levels"
+
+	"github.com/faiface/pixel/pixelgl"
+)
+
+// maxListed is how many levels fit in the browser
+const maxListed = 10
+
+// levelBrowser lists the community levels on the level server, ENTER downloads and plays the
+// selected one, 1 to 5 rate it. The server is only talked to on goroutines, the screen shows
+// how it's going.
+type levelBrowser struct {
+	win     *pixelgl.Window
+	screens *screenStack
+	lc      *levelClient
+	play    func(lvl *customLevel)
+	menu    *menu
+
+	mu     sync.Mutex
+	list   []levels.Listing
+	status string
+	// loaded is the downloaded level, it's played on the next update
+	loaded *customLevel
+	busy   bool
+}
+
+func newLevelBrowser(win *pixelgl.Window, screens *screenStack, lc *levelClient, play func(lvl *customLevel)) *levelBrowser {
+	lb := &levelBrowser{win: win, screens: screens, lc: lc, play: play, menu: newMenu(""), status: "loading..."}
+	lb.refresh()
+	lb.rebuild()
+	return lb
+}
+
+// refresh gets the list again in the background
+func (lb *levelBrowser) refresh() {
+	go func() {
+		list, err := lb.lc.list()
+		lb.mu.Lock()
+		defer lb.mu.Unlock()
+		if err != nil {
+			lb.status = err.Error()
+			return
+		}
+		if len(list) > maxListed {
+			list = list[:maxListed]
+		}
+		lb.list, lb.status = list, ""
+		if len(list) == 0 {
+			lb.status = "no levels yet, upload one with -upload-level"
+		}
+	}()
+}
+
+// rebuild updates the menu to the list and the status, the lock has to be held
+func (lb *levelBrowser) rebuild() {
+	var items []menuItem
+	for _, l := range lb.list {
+		l := l
+		items = append(items, menuItem{static(l.Name + " by " + l.Author), func() { lb.download(l.ID) }})
+	}
+	items = append(items, menuItem{static("Back"), lb.screens.pop})
+	lb.menu.items = items
+	if lb.menu.sel >= len(items) {
+		lb.menu.sel = len(items) - 1
+	}
+
+	// the selected level's details go under the title
+	lb.menu.title = "COMMUNITY LEVELS\nENTER plays, 1-5 rates"
+	if sel := lb.menu.sel; sel < len(lb.list) {
+		l := lb.list[sel]
+		lb.menu.title += fmt.Sprintf("\ndifficulty %d/5, ", l.Difficulty)
+		if l.Ratings > 0 {
+			lb.menu.title += fmt.Sprintf("rated %.1f/5 by %d", l.Rating, l.Ratings)
+		} else {
+			lb.menu.title += "not rated yet"
+		}
+	}
+	if lb.status != "" {
+		lb.menu.title += "\n" + lb.status
+	}
+}
+
+func (lb *levelBrowser) download(id string) {
+	if lb.busy {
+		return
+	}
+	lb.busy, lb.status = true, "downloading..."
+	go func() {
+		lvl, err := lb.lc.download(id)
+		lb.mu.Lock()
+		defer lb.mu.Unlock()
+		lb.busy = false
+		if err != nil {
+			lb.status = err.Error()
+			return
+		}
+		lb.loaded = lvl
+	}()
+}
+
+func (lb *levelBrowser) rate(l levels.Listing, stars int) {
+	lb.status = fmt.Sprintf("rating %s %d/5...", l.Name, stars)
+	go func() {
+		err := lb.lc.rate(l.ID, stars)
+		lb.mu.Lock()
+		if err != nil {
+			lb.status = err.Error()
+		} else {
+			lb.status = fmt.Sprintf("rated %s %d/5", l.Name, stars)
+		}
+		lb.mu.Unlock()
+		if err == nil {
+			lb.refresh()
+		}
+	}()
+}
+
+func (lb *levelBrowser) update(dt float64) {
+	lb.mu.Lock()
+	defer lb.mu.Unlock()
+	if lb.loaded != nil {
+		lvl := lb.loaded
+		lb.loaded = nil
+		lb.screens.pop()
+		lb.play(lvl)
+		return
+	}
+	if ctl.justPressed(bindBack) {
+		lb.screens.pop()
+		return
+	}
+	if lb.menu.sel < len(lb.list) {
+		for stars := 1; stars <= levels.MaxStars; stars++ {
+			if lb.win.JustPressed(pixelgl.Key0 + pixelgl.Button(stars)) {
+				lb.rate(lb.list[lb.menu.sel], stars)
+			}
+		}
+	}
+	lb.rebuild()
+	lb.menu.update(lb.win)
+}
+
+func (lb *levelBrowser) draw(canvas *pixelgl.Canvas) {
+	lb.menu.draw(canvas)
+}
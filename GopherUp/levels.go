@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"GoTower/internal/levels"
+	"GoTower/internal/tower"
+
+	"github.com/pkg/errors"
+)
+
+// customLevel is a community level, downloaded to be played instead of the game's own chunks
+type customLevel struct {
+	id, name string
+	chunks   []*tower.Chunk
+}
+
+// makeLevel makes a custom level of the chunk library at path, the chunks are checked like the
+// game's own
+func makeLevel(path, name, author string, difficulty int) (levels.Level, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return levels.Level{}, errors.Wrap(err, "error making level")
+	}
+	if _, err := tower.ParseChunks(data, path); err != nil {
+		return levels.Level{}, err
+	}
+	l := levels.Level{Name: name, Author: author, Difficulty: difficulty, Chunks: data}
+	return l, l.Check()
+}
+
+// loadLevel reads a level made by makeLevel
+func loadLevel(path string) (levels.Level, error) {
+	var l levels.Level
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return l, errors.Wrap(err, "error loading level")
+	}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return l, errors.Wrapf(err, "error loading level from %s", path)
+	}
+	if err := l.Check(); err != nil {
+		return l, err
+	}
+	_, err = tower.ParseChunks(l.Chunks, path)
+	return l, err
+}
+
+func saveLevel(l levels.Level, path string) error {
+	data, err := json.MarshalIndent(l, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving level")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0644), "error saving level")
+}
+
+// levelClient lists, downloads, uploads and rates the levels on the level server
+type levelClient struct {
+	url    string
+	key    ed25519.PrivateKey
+	client *http.Client
+}
+
+func newLevelClient(url string) (*levelClient, error) {
+	key, err := loadInstallKey()
+	if err != nil {
+		return nil, err
+	}
+	return &levelClient{url: strings.TrimSuffix(url, "/"), key: key, client: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+func (lc *levelClient) do(method, path string, v, out interface{}) error {
+	return doJSON(lc.client, method, lc.url+path, v, out)
+}
+
+// doJSON sends the request, with v as JSON if it isn't nil, and decodes the answer into out if
+// it isn't nil
+func doJSON(client *http.Client, method, url string, v, out interface{}) error {
+	var body []byte
+	if v != nil {
+		var err error
+		if body, err = json.Marshal(v); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// list is the levels on the server, the best rated first
+func (lc *levelClient) list() ([]levels.Listing, error) {
+	var list []levels.Listing
+	return list, errors.Wrap(lc.do(http.MethodGet, "", nil, &list), "error listing levels")
+}
+
+func (lc *levelClient) download(id string) (*customLevel, error) {
+	var l levels.Level
+	if err := lc.do(http.MethodGet, "/"+url.PathEscape(id), nil, &l); err != nil {
+		return nil, errors.Wrap(err, "error downloading level")
+	}
+	chunks, err := tower.ParseChunks(l.Chunks, l.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &customLevel{id: id, name: l.Name, chunks: chunks}, nil
+}
+
+func (lc *levelClient) upload(l levels.Level) (levels.Listing, error) {
+	var up levels.Listing
+	return up, errors.Wrap(lc.do(http.MethodPost, "", l, &up), "error uploading level")
+}
+
+// rate gives the level stars from 1 to 5, rating again changes them
+func (lc *levelClient) rate(id string, stars int) error {
+	r := levels.Rate(id, stars, lc.key)
+	return errors.Wrap(lc.do(http.MethodPost, "/"+url.PathEscape(id)+"/rating", r, nil), "error rating level")
+}
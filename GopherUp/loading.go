@@ -0,0 +1,141 @@
+package main
+
+import (
+	"sync"
+
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+type loadTask struct {
+	name string
+	load func() error
+}
+
+// loader decodes assets on goroutines, so the window stays responsive while they stream in
+type loader struct {
+	tasks []loadTask
+
+	mu      sync.Mutex
+	done    int
+	err     error
+	started bool
+}
+
+func (l *loader) add(name string, load func() error) {
+	l.tasks = append(l.tasks, loadTask{name: name, load: load})
+}
+
+func (l *loader) start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.started {
+		return
+	}
+	l.started = true
+	for _, t := range l.tasks {
+		go func(t loadTask) {
+			err := t.load()
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			l.done++
+			if err != nil && l.err == nil {
+				l.err = err
+			}
+		}(t)
+	}
+}
+
+// progress is the fraction of finished tasks, between 0 and 1
+func (l *loader) progress() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.tasks) == 0 {
+		return 1
+	}
+	return float64(l.done) / float64(len(l.tasks))
+}
+
+func (l *loader) finished() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.done == len(l.tasks)
+}
+
+func (l *loader) error() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.err
+}
+
+var loadingTips = []string{
+	"Hold {slowmo} for slow motion",
+	"Collect the goals to score",
+	"Don't fall off the bottom!",
+	"The gold line is your best height",
+	"Press {pause} to pause",
+}
+
+// loadingScreen shows a progress bar until the loader is done, then hands over to onDone, or to
+// onFail with the first error when something couldn't be loaded
+type loadingScreen struct {
+	ld     *loader
+	onDone func()
+	onFail func(err error)
+	tip    string
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newLoadingScreen(ld *loader, onDone func(), onFail func(err error)) *loadingScreen {
+	ld.start()
+	return &loadingScreen{
+		ld:     ld,
+		onDone: onDone,
+		onFail: onFail,
+		tip:    loadingTips[rng.Cosmetic.Intn(len(loadingTips))],
+		imd:    imdraw.New(nil),
+		txt:    text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (ls *loadingScreen) update(dt float64) {
+	if err := ls.ld.error(); err != nil {
+		ls.onFail(err)
+		return
+	}
+	if ls.ld.finished() {
+		ls.onDone()
+	}
+}
+
+func (ls *loadingScreen) draw(canvas *pixelgl.Canvas) {
+	canvas.SetMatrix(screenMatrix())
+
+	// progress bar in the middle of the screen
+	bar := pixel.R(-100, -4, 100, 4)
+	ls.imd.Clear()
+	ls.imd.Color = colornames.Dimgray
+	ls.imd.Push(bar.Min, bar.Max)
+	ls.imd.Rectangle(1)
+	ls.imd.Color = colornames.Gold
+	ls.imd.Push(bar.Min, pixel.V(bar.Min.X+bar.W()*ls.ld.progress(), bar.Max.Y))
+	ls.imd.Rectangle(0)
+	ls.imd.Draw(canvas)
+
+	ls.txt.Clear()
+	ls.txt.Color = colornames.Lightgrey
+	ls.txt.WriteString("LOADING")
+	ls.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ls.txt.Bounds().W()/2, 16)))
+
+	ls.txt.Clear()
+	ls.txt.Color = colornames.Gray
+	ls.txt.WriteString(ctl.prompt(ls.tip))
+	ls.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ls.txt.Bounds().W()/2, -30)))
+}
@@ -1,19 +1,24 @@
 package main
 
 import (
-	"encoding/csv"
+	"flag"
 	"fmt"
-	"image"
-	"image/color"
-	"io"
 	"io/ioutil"
-	"math"
-	"math/rand"
 	"os"
 	"strconv"
 	"time"
 
-	_ "image/png"
+	"GoTower/events"
+	"GoTower/internal/challenge"
+	"GoTower/internal/entity"
+	"GoTower/internal/lobby"
+	"GoTower/internal/physics"
+	"GoTower/internal/profile"
+	"GoTower/internal/render"
+	"GoTower/internal/rng"
+	"GoTower/internal/sprite"
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
 
 	"github.com/faiface/pixel"
 	"github.com/faiface/pixel/imdraw"
@@ -25,7 +30,25 @@ import (
 	"golang.org/x/image/font"
 )
 
-var spe float64 = 20
+// canvasBounds is the part of the world on screen, the canvas is stretched over the window
+var canvasBounds = pixel.R(-320/2, -240/2, 320/2, 240/2)
+
+// slowmo is how far into slow motion the game looks, from 0 to 1, eased in and out so the
+// treatment doesn't pop: the canvas is tinted blue and the particles stretch
+var slowmo float64
+
+// slow motion is slowmoScale of the speed, it fades in and out at slowmoFade a second
+const (
+	slowmoScale = 1.0 / 8
+	slowmoFade  = 6
+)
+
+// slowmoTint is the color the canvas is tinted in full slow motion
+var slowmoTint = pixel.RGB(0.6, 0.75, 1)
+
+// practicing is set once the player turns on practice mode from the pause menu, until they quit
+// to the title the runs don't count for the records, the run log or the leaderboard
+var practicing bool
 
 func loadTTF(path string, size float64) (font.Face, error) {
 	file, err := os.Open(path)
@@ -50,461 +73,867 @@ func loadTTF(path string, size float64) (font.Face, error) {
 	}), nil
 }
 
-func loadAnimationSheet(sheetPath, descPath string, frameWidth float64) (sheet pixel.Picture, anims map[string][]pixel.Rect, err error) {
-	// total hack, nicely format the error at the end, so I don't have to type it every time
-	defer func() {
-		if err != nil {
-			err = errors.Wrap(err, "error loading animation sheet")
+func run() {
+	// the settings pick the display mode, so they can't wait for the loading screen
+	set := loadSettings()
+	for _, p := range set.problems {
+		fmt.Println(p)
+	}
+	speech = newNarrator(func() bool { return set.Narration })
+	if *monitor != "" {
+		if err := set.pickMonitor(*monitor); err != nil {
+			panic(err)
+		}
+		if err := set.save(); err != nil {
+			fmt.Println(err)
 		}
-	}()
-
-	// open and load the spritesheet
-	sheetFile, err := os.Open(sheetPath)
-	if err != nil {
-		return nil, nil, err
 	}
-	defer sheetFile.Close()
-	sheetImg, _, err := image.Decode(sheetFile)
+	set.scale = *uiScale
+	if set.scale <= 0 {
+		set.scale = contentScale(set.monitor())
+	}
+	win, err := pixelgl.NewWindow(set.windowConfig("Platformer"))
 	if err != nil {
-		return nil, nil, err
+		panic(err)
 	}
-	sheet = pixel.PictureDataFromImage(sheetImg)
+	ctl = newControls(win)
+
+	// mods' assets directories go over the game's own
+	assets := newAssetManager(modAssets()...)
 
-	// create a slice of frames inside the spritesheet
-	var frames []pixel.Rect
-	for x := 0.0; x+frameWidth <= sheet.Bounds().Max.X; x += frameWidth {
-		frames = append(frames, pixel.R(
-			x,
-			0,
-			x+frameWidth,
-			sheet.Bounds().H(),
-		))
+	// the game goes on without sound when there's no audio device
+	sound, err := startAudio(set, assets)
+	if err != nil {
+		fmt.Println(err)
 	}
 
-	descFile, err := os.Open(descPath)
+	// a broken mod doesn't take the game down, it runs without them
+	mods, err := loadMods()
 	if err != nil {
-		return nil, nil, err
+		fmt.Println(err)
+		mods = noMods{}
 	}
-	defer descFile.Close()
+	defer mods.close()
 
-	anims = make(map[string][]pixel.Rect)
+	stopTelemetry := startTelemetry(*telemetryURL)
+	defer stopTelemetry()
+	overlay := startOverlay(*overlayPort)
+	defer overlay.stop()
 
-	// load the animation information, name and interval inside the spritesheet
-	desc := csv.NewReader(descFile)
-	for {
-		anim, err := desc.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return nil, nil, err
+	// a broken profile is set up again, like a missing one
+	prof, err := loadProfile()
+	if err != nil {
+		fmt.Println(err)
+		prof = &playerProfile{}
+	}
+	ctl.setPresets(prof.presets(), prof.Controls, func(name string) {
+		prof.Controls = name
+		if err := prof.save(); err != nil {
+			fmt.Println(err)
 		}
+	})
 
-		name := anim[0]
-		start, _ := strconv.Atoi(anim[1])
-		end, _ := strconv.Atoi(anim[2])
-
-		anims[name] = frames[start : end+1]
+	var sub *submitter
+	if *leaderboardURL != "" {
+		if sub, err = newSubmitter(*leaderboardURL, prof); err != nil {
+			panic(err)
+		}
+		defer sub.close()
+	}
+	var lc *levelClient
+	if *levelsURL != "" {
+		if lc, err = newLevelClient(*levelsURL); err != nil {
+			panic(err)
+		}
 	}
 
-	return sheet, anims, nil
-}
-
-type platform struct {
-	rect  pixel.Rect
-	color color.Color
-}
-
-func (p *platform) draw(imd *imdraw.IMDraw) {
-	imd.Color = p.color
-	imd.Push(p.rect.Min, p.rect.Max)
-	imd.Rectangle(0)
-}
+	// decode everything in the background while the loading screen is up, the game owns the
+	// assets through its scope until it quits. The HUD face has a scope of its own, it's swapped
+	// for a new size when the UI scale changes.
+	scope, hudFonts := assets.scope(), assets.scope()
+	defer scope.release()
+	defer func() { hudFonts.release() }()
+	var (
+		gopher *animationSheet
+		chunks []*tower.Chunk
+		muts   []*tower.Mutator
+		picks  []*tower.Mutator
+		trails []*trail
+		chals  challenge.Current
+		clock  *netClock
+		clog   *challengeLog
+		face   font.Face
+		st     *stats
+		// battery is whether the game started on battery, for suggesting the low-power mode
+		battery bool
+	)
+	ld := &loader{}
+	ld.add("sprites", func() (err error) {
+		gopher, err = scope.animationSheet("sheet.png", "sheet.csv", 12, render.GopherAnimations...)
+		return err
+	})
+	ld.add("power", func() error {
+		battery = onBattery()
+		return nil
+	})
+	ld.add("chunks", func() (err error) {
+		chunks, err = tower.LoadChunks(assets.resolve("chunks.json"))
+		return err
+	})
+	ld.add("mutators", func() (err error) {
+		muts, err = tower.LoadMutators(assets.resolve("mutators.json"), tower.MaxMutators)
+		return err
+	})
+	ld.add("picks", func() (err error) {
+		picks, err = tower.LoadMutators(assets.resolve("picks.json"), tower.MaxPicks)
+		return err
+	})
+	ld.add("trails", func() (err error) {
+		trails, err = loadTrails(assets.resolve("trails.json"))
+		return err
+	})
+	ld.add("challenges", func() (err error) {
+		chals, clock = loadChallenges(*challengesURL)
+		clog, err = loadChallengeLog()
+		return err
+	})
+	ld.add("font", func() (err error) {
+		face, err = hudFonts.font("intuitive.ttf", hudSize*set.scale)
+		return err
+	})
+	ld.add("stats", func() (err error) {
+		st, err = loadStats()
+		return err
+	})
+
+	fps, slowFPS := time.Tick(time.Second/fullFPS), time.Tick(time.Second/lowPowerFPS)
+
+	canvas := pixelgl.NewCanvas(canvasBounds)
+	sess := newSession()
+	// summed is set once the session summary was shown for closing the game
+	summed := false
+
+	screens := &screenStack{}
+	screens.push(newLoadingScreen(ld, func() {
+		tower.Bus.Subscribe(func(e tower.Event) {
+			switch e := e.(type) {
+			case tower.GoalCollected:
+				tower.Score += e.Value
+			case tower.BossSurvived:
+				tower.Score += e.Bonus
+			}
+		})
+		// after the score, so the events have it with the goal in it
+		tower.Bus.Subscribe(func(e tower.Event) {
+			if ee := exported(e); ee != nil {
+				events.Publish(ee)
+			}
+		})
+		st.track(tower.Bus)
+		tower.Bus.Subscribe(sess.onEvent)
+		if sound != nil {
+			tower.Bus.Subscribe(sound.onEvent)
+		}
+		if speech != nil {
+			tower.Bus.Subscribe(speech.onEvent)
+		}
 
-type gopherPhys struct {
-	gravity   float64
-	runSpeed  float64
-	jumpSpeed float64
+		hud = newHeadsUp(face, hudSize*set.scale, func(size float64) (font.Face, error) {
+			// the new size is held before the old one is let go, so the same size isn't decoded again
+			next := assets.scope()
+			f, err := next.font("intuitive.ttf", size)
+			if err != nil {
+				next.release()
+				return nil, err
+			}
+			hudFonts.release()
+			hudFonts = next
+			return f, nil
+		})
+
+		// pack the sheets into shared atlases, the frames are rewritten to match
+		regions := packAtlases(gopher.pictures("gopher"), 2048)
+		gopher = gopher.packed("gopher", regions)
+
+		// a tower code or link can come from the command line, when the game is opened from one
+		screens.pop()
+		var browse func(play func(lvl *customLevel))
+		if lc != nil {
+			browse = func(play func(lvl *customLevel)) {
+				screens.push(newLevelBrowser(win, screens, lc, play))
+			}
+		}
+		var showTitle func()
+		var startRun func(rc runCode, picked []*tower.Mutator, lvl *customLevel, rr *race)
+		startRun = func(rc runCode, picked []*tower.Mutator, lvl *customLevel, rr *race) {
+			rng.Seed(rc.seed)
+			tower.Climbed, tower.Score, tower.Scroll = 0, 0, tower.StartSpeed
+			sess.start()
+			// everything subscribed from here on is for this run only
+			mark := tower.Bus.Mark()
+			runChunks, level := chunks, ""
+			if lvl != nil {
+				runChunks, level = lvl.chunks, lvl.id
+			}
+			style := tower.StyleByName(prof.Style)
+			rl := newRunLog(*logRuns, rc, tower.MutatorIDs(picked), level, style.Name, set.Assist, set.Adaptive)
+			// the leaderboard is for the game's own tower
+			if sub != nil && lvl == nil {
+				rl.finished = append(rl.finished, sub.submit)
+			}
+			if rc.challenge != "" {
+				rl.finished = append(rl.finished, func(rs runSummary) { clog.complete(rs, clock) })
+			}
+			rl.finished = append(rl.finished, func(rs runSummary) { st.record(rs, prof) })
+			tower.Bus.Subscribe(rl.onEvent)
+			events.Publish(events.RunStarted{Code: rc.String()})
+			tower.Bus.Subscribe(func(e tower.Event) {
+				// in practice and races the gopher starts again right away, that's a new run
+				if _, ok := e.(physics.Died); ok && (rr != nil || practicing) {
+					events.Publish(events.RunStarted{Code: rc.String()})
+				}
+			})
+			screens.pop()
+			gs := newGameScreen(win, screens, gopher, runChunks, st, set, rl)
+			gs.Goals.SetPalette(entity.GoalPalette(rc.seed))
+			gs.Restyle(style.Name)
+			style.Animate(gs.anim)
+			gs.SetAssist(set.Assist)
+			gs.SetAdaptive(set.Adaptive)
+			gs.SetCountdown(true)
+			gs.Mutate(picked)
+			gs.Pool = picks
+			gs.anim.Tint = profile.AvatarAt(prof.Avatar).Color
+			if t := trailByID(trails, prof.Trail); t != nil && t.Unlock.met(st) {
+				gs.trail = newParticleSystem(t.Emitter)
+			}
+			gs.race = rr
+			// the run's looks are only held while it's up, the tile sheet in a biome scope of its
+			// own that's let go of when the platforms are switched to the classic look
+			run := assets.scope()
+			gs.themes = loadThemes(run)
+			gs.biome = assets.scope()
+			gs.tileSet()
+			gs.quit = func() {
+				// the pause menu and the tower
+				screens.pop()
+				screens.pop()
+				gs.biome.release()
+				run.release()
+				if rr != nil {
+					rr.leave()
+				}
+				tower.Bus.Drop(mark)
+				sess.finish()
+				// the runs from the title count again
+				practicing = false
+				showTitle()
+				if sess.runs > 0 {
+					screens.push(newSessionScreen(win, sess, screens.pop))
+				}
+			}
+			if rr == nil {
+				// the run so far is dropped like quitting drops it, startRun takes the tower
+				// off the stack for the new one
+				gs.restart = func() {
+					tower.Bus.Drop(mark)
+					sess.finish()
+					tower.Bus.Publish(featureUsed{"restart"})
+					startRun(rc, picked, lvl, nil)
+					// after the new run holds them, so they aren't decoded again
+					gs.biome.release()
+					run.release()
+				}
+			}
+			if *startFloor > 0 {
+				practicing = true
+				gs.StartAt(*startFloor)
+			}
+			tower.Bus.Subscribe(mods.onEvent)
+			mods.start(gs.Sim)
+			screens.push(gs)
+		}
+		var openLobby func(picked []*tower.Mutator)
+		if *raceURL != "" {
+			rc := newRaceClient(*raceURL, prof)
+			openLobby = func(picked []*tower.Mutator) {
+				screens.push(newRaceScreen(win, screens, rc, picked, func(r *race) {
+					room, _, _ := r.state()
+					startRun(runCode{seed: room.Seed}, tower.MutatorsByID(muts, room.Mutators), nil, r)
+				}))
+			}
+		}
+		editProfile := func() { screens.push(newProfileScreen(win, screens, prof, gopher, trails, st)) }
+		showTitle = func() {
+			screens.push(newTitleScreen(win, flag.Arg(0), muts, chals, clock, clog, prof, editProfile, browse, openLobby, func(rc runCode, picked []*tower.Mutator, lvl *customLevel) {
+				startRun(rc, picked, lvl, nil)
+			}))
+		}
+		showTitle()
+		if !prof.made {
+			editProfile()
+		}
+		if battery && !set.LowPower && !set.PowerAsked {
+			screens.push(newPowerScreen(win, screens, set))
+		}
+		if len(set.problems) > 0 {
+			screens.push(newNoticeScreen(win, screens, "SOME SETTINGS WERE IGNORED\nand left at their defaults", set.problems))
+		}
+	}, func(err error) {
+		// the game can't go on without it, so the notice closes the window
+		fmt.Println(err)
+		screens.pop()
+		screens.push(newFailureScreen(win, "THE GAME COULDN'T BE LOADED", err))
+	}))
+
+	probe := &latencyProbe{}
+	diag := newLatencyOverlay(win, probe, set)
+	last := time.Now()
+	for !win.Closed() {
+		dt := time.Since(last).Seconds()
+		last = time.Now()
+		sound.update(dt)
+		ctl.update()
 
-	rect   pixel.Rect
-	vel    pixel.Vec
-	ground bool
-}
+		// slow motion with tab
+		if ctl.justPressed(bindSlowmo) {
+			tower.Bus.Publish(featureUsed{"slowmo"})
+		}
+		slow := 0.0
+		if ctl.pressed(bindSlowmo) {
+			slow = 1
+		}
+		slowmo = approach(slowmo, slow, slowmoFade*dt)
+		if slow > 0 {
+			dt *= slowmoScale
+		}
 
-func (gp *gopherPhys) update(dt float64, ctrl pixel.Vec, platforms []platform) {
-	// apply controls
-	switch {
-	case ctrl.X < 0:
-		if gp.rect.Max.X > -160 {
-			gp.vel.X = -gp.runSpeed
+		// the music ducks under the menus and when the window isn't looked at
+		if len(screens.screens) > 1 || !win.Focused() {
+			sound.setState(audioDucked)
 		} else {
-			gp.vel.X = -0.000001
+			sound.setState(audioPlaying)
 		}
-	case ctrl.X > 0:
-		if gp.rect.Max.X < 160 {
-			gp.vel.X = +gp.runSpeed
-		} else {
-			gp.vel.X = +0.000001
+
+		// the canvas follows the resolution setting
+		if z := resolutionZoom(set.Resolution); z != canvasZoom {
+			canvasZoom = z
+			canvas.SetBounds(pixel.R(canvasBounds.Min.X*z, canvasBounds.Min.Y*z, canvasBounds.Max.X*z, canvasBounds.Max.Y*z))
 		}
-	default:
-		gp.vel.X = 0
-	}
+		interfaceScale = float64(set.UIScale) / 100
 
-	// apply gravity and velocity
-	gp.vel.Y += gp.gravity * dt
-	gp.rect = gp.rect.Moved(gp.vel.Scaled(dt))
+		diag.update()
 
-	// check collisions against each platform
-	gp.ground = false
-	if gp.vel.Y <= 0 {
-		for _, p := range platforms {
-			if gp.rect.Max.X <= p.rect.Min.X || gp.rect.Min.X >= p.rect.Max.X {
-				continue
-			}
-			if gp.rect.Min.Y > p.rect.Max.Y || gp.rect.Min.Y < p.rect.Max.Y+gp.vel.Y*dt {
-				continue
+		// only the top screen updates, the tower is still drawn underneath menus
+		probe.markUpdating()
+		screens.update(dt)
+		probe.markUpdated()
+		overlay.set(overlayStateOf(screens))
+		canvas.Clear(colornames.Black)
+		screens.draw(canvas)
+		diag.draw(canvas)
+
+		// stretch the canvas to the window
+		win.Clear(colornames.White)
+		canvasView = pixel.IM.Scaled(pixel.ZV, canvasScale(win.Bounds(), canvas.Bounds())).Moved(win.Bounds().Center())
+		win.SetMatrix(canvasView)
+		tint := pixel.RGB(1, 1, 1).Mul(pixel.Alpha(1 - slowmo)).Add(slowmoTint.Mul(pixel.Alpha(slowmo)))
+		if set.LowPower {
+			tint = pixel.RGB(1, 1, 1)
+		}
+		canvas.DrawColorMask(win, pixel.IM.Moved(canvas.Bounds().Center()), tint)
+		hud.draw(win, set.scale*interfaceScale)
+		// Update, split to time the swap and the poll
+		swapping := time.Now()
+		win.SwapBuffers()
+		probe.markSwapped(swapping)
+		win.UpdateInput()
+		probe.markPolled()
+
+		// closing after some runs shows what the session came to first
+		if win.Closed() && !summed {
+			summed = true
+			sess.finish()
+			if sess.runs > 0 {
+				win.SetClosed(false)
+				screens.push(newSessionScreen(win, sess, func() { win.SetClosed(true) }))
 			}
-			gp.vel.Y = 0
-			gp.rect = gp.rect.Moved(pixel.V(0, p.rect.Max.Y-gp.rect.Min.Y))
-			gp.ground = true
 		}
-	}
 
-	// jump if on the ground and the player wants to jump
-	if gp.ground && ctrl.Y > 0 {
-		gp.vel.Y = gp.jumpSpeed
+		if set.LowPower {
+			<-slowFPS
+		} else {
+			<-fps
+		}
+	}
+	// closed before the stats even finished loading, nothing to save
+	if !ld.finished() || st == nil {
+		return
+	}
+	if err := st.save(); err != nil {
+		fmt.Println(err)
 	}
-	gp.rect.Min.Y -= dt * spe
-	gp.rect.Max.Y -= dt * spe
 }
 
-type animState int
-
-const (
-	idle animState = iota
-	running
-	jumping
-)
+// gameScreen is the tower itself
+type gameScreen struct {
+	win     *pixelgl.Window
+	screens *screenStack
+
+	*tower.Sim
+	anim *render.GopherAnim
+	best *bestLine
+	runs *runLog
+	st   *stats
+	set  *settings
+
+	inputs   *inputDisplay
+	captions *captions
+	imd      *imdraw.IMDraw
+	cam      *render.Camera
+
+	// saved is the practice save state, died is set when the gopher died this frame, death is how it
+	// died
+	saved *tower.SimSnapshot
+	died  bool
+	death physics.Died
+
+	// race is the ghost race the run is in, nil when it isn't
+	race   *race
+	deaths int
+	ghosts *pixel.Sprite
+	names  *text.Text
+	bubble *imdraw.IMDraw
+
+	// trail is the particle trail from the profile, nil for none
+	trail *particleSystem
+	// danger warns of the kill zone below
+	danger *dangerWarning
+	// arrow points at the goal when it's off the screen
+	arrow *goalArrow
+	// quit leaves the run for the title screen
+	quit func()
+	// badge shows the prestige tier
+	badge *text.Text
+	// moves is the movement overlay, F3 shows and hides it
+	moves     *moveStats
+	showMoves bool
+	// tiles draws the platforms, nil for the classic ones or when the sheet couldn't be loaded,
+	// biome holds the sheet while they're drawn from it
+	tiles   *tower.TileSet
+	biome   *assetScope
+	noTiles bool
+	// themes are the looks to pick from in the settings
+	themes []*theme
+	// idle pauses the run when nobody's playing
+	idle *idleWatch
+	// show is the countdown at the start of the runs
+	show *countdownShow
+	// ambience draws the sky and the light of the time of day
+	ambience *imdraw.IMDraw
+	// gauge draws the stamina bar
+	gauge *imdraw.IMDraw
+	// clock steps the tower at simStep whatever the frame rate
+	clock fixedClock
+	// restart starts the run over on a fresh tower, nil when it can't be, restarting is the key
+	// held for it
+	restart    func()
+	restarting *restartKey
+
+	// offered is whether the picks on offer have been shown, chose is the one taken plus one, for
+	// the next command, zero for none
+	offered bool
+	chose   int
+}
 
-type gopherAnim struct {
-	sheet pixel.Picture
-	anims map[string][]pixel.Rect
-	rate  float64
+func newGameScreen(win *pixelgl.Window, screens *screenStack, gopher *animationSheet, chunks []*tower.Chunk, st *stats, set *settings, runs *runLog) *gameScreen {
+	gs := &gameScreen{
+		win:     win,
+		screens: screens,
+		runs:    runs,
+		st:      st,
+		set:     set,
+		cam:     render.NewCamera(render.CameraNames[*gameCamera], canvasBounds),
+	}
 
-	state   animState
-	counter float64
-	dir     float64
+	gs.Sim = tower.NewSim(chunks)
 
-	frame pixel.Rect
+	gs.anim = render.NewGopherAnim(gopher.anims)
+	tower.Bus.Subscribe(gs.anim.OnEvent)
 
-	sprite *pixel.Sprite
+	gs.best = newBestLine(st.BestHeight)
+	tower.Bus.Subscribe(gs.best.onEvent)
+	tower.Bus.Subscribe(func(e tower.Event) {
+		if e, ok := e.(physics.Died); ok {
+			gs.died, gs.death = true, e
+		}
+	})
+
+	gs.inputs = newInputDisplay()
+	gs.danger = newDangerWarning()
+	gs.arrow = newGoalArrow()
+	gs.badge = text.New(pixel.ZV, text.Atlas7x13)
+	gs.moves = newMoveStats()
+	tower.Bus.Subscribe(gs.moves.onEvent)
+	gs.idle = newIdleWatch()
+	gs.restarting = newRestartKey()
+	gs.show = newCountdownShow()
+	tower.Bus.Subscribe(gs.show.onEvent)
+	gs.captions = newCaptions()
+	tower.Bus.Subscribe(gs.captions.onEvent)
+	gs.imd = imdraw.New(nil)
+	gs.imd.Precision = 32
+	gs.ambience = imdraw.New(nil)
+	gs.gauge = imdraw.New(nil)
+
+	return gs
 }
 
-func (ga *gopherAnim) update(dt float64, phys *gopherPhys) {
-	ga.counter += dt
+func (gs *gameScreen) update(dt float64) {
+	win := gs.win
 
-	// determine the new animation state
-	var newState animState
-	switch {
-	case !phys.ground:
-		newState = jumping
-	case phys.vel.Len() == 0:
-		newState = idle
-	case phys.vel.Len() > 0:
-		newState = running
-	}
-
-	// reset the time counter if the state changed
-	if ga.state != newState {
-		ga.state = newState
-		ga.counter = 0
-	}
-
-	// determine the correct animation frame
-	switch ga.state {
-	case idle:
-		i := int(math.Floor(ga.counter/ga.rate)) % 40
-		if i > 38 {
-			ga.frame = ga.anims["FrontBlink"][0]
-		} else if i == 0 {
-			ga.frame = ga.anims["Front"][0]
-		}
-	case running:
-		i := int(math.Floor(ga.counter / ga.rate))
-		ga.frame = ga.anims["Run"][i%len(ga.anims["Run"])]
-	case jumping:
-		speed := phys.vel.Y
-		i := int((-speed/phys.jumpSpeed + 1) / 2 * float64(len(ga.anims["Jump"])))
-		if i < 0 {
-			i = 0
-		}
-		if i >= len(ga.anims["Jump"]) {
-			i = len(ga.anims["Jump"]) - 1
-		}
-		ga.frame = ga.anims["Jump"][i]
-	}
-
-	// set the facing direction of the gopher
-	if phys.vel.X != 0 {
-		if phys.vel.X > 0 {
-			ga.dir = +1
-		} else {
-			ga.dir = -1
-		}
+	// pause on escape, the tower stays on screen underneath the menu
+	if ctl.justPressed(bindPause) {
+		tower.Bus.Publish(featureUsed{"pause"})
+		gs.screens.push(newPauseScreen(win, gs.screens, gs.st, gs.set, gs.runs.code, gs.quit))
+		return
 	}
-}
 
-func (ga *gopherAnim) draw(t pixel.Target, phys *gopherPhys) {
-	if ga.sprite == nil {
-		ga.sprite = pixel.NewSprite(nil, pixel.Rect{})
-	}
-	// draw the correct frame with the correct position and direction
-	ga.sprite.Set(ga.sheet, ga.frame)
-	ga.sprite.Draw(t, pixel.IM.
-		ScaledXY(pixel.ZV, pixel.V(
-			phys.rect.W()/ga.sprite.Frame().W(),
-			phys.rect.H()/ga.sprite.Frame().H(),
-		)).
-		ScaledXY(pixel.ZV, pixel.V(-ga.dir, 1)).
-		Moved(phys.rect.Center()),
-	)
-}
+	if win.JustPressed(pixelgl.KeyF3) {
+		gs.showMoves = !gs.showMoves
+		if gs.showMoves {
+			tower.Bus.Publish(featureUsed{"movestats"})
+		}
+	}
 
-type goal struct {
-	pos    pixel.Vec
-	radius float64
-	step   float64
+	// restart the run on holding R, there's no restarting a race
+	if gs.restart != nil && gs.restarting.update(dt, ctl.pressed(bindRestart)) {
+		gs.restart()
+		return
+	}
 
-	counter float64
-	cols    [5]pixel.RGBA
-}
+	// control the gopher with the keys or a gamepad
+	var actions byte
+	if ctl.pressed(bindLeft) {
+		actions |= tower.InputLeft
+	}
+	if ctl.pressed(bindRight) {
+		actions |= tower.InputRight
+	}
+	if ctl.pressed(bindDown) {
+		actions |= tower.InputDown
+	}
+	if ctl.justPressed(bindJump) {
+		actions |= tower.InputJump
+	}
+	if ctl.justPressed(bindSkip) {
+		actions |= tower.InputSkip
+	}
 
-func (g *goal) update(dt float64) {
-	g.counter += dt
-	g.pos.Y -= dt * spe
-	for g.counter > g.step {
-		g.counter -= g.step
-		for i := len(g.cols) - 2; i >= 0; i-- {
-			g.cols[i+1] = g.cols[i]
+	// save states in practice mode, F5 saves, F8 goes back, and so does dying, F6 re-rolls the
+	// platforms above the gopher
+	if practicing {
+		if win.JustPressed(pixelgl.KeyF5) {
+			gs.saved = gs.Save()
+		}
+		if win.JustPressed(pixelgl.KeyF8) && gs.saved != nil {
+			gs.Load(gs.saved)
+		}
+		if win.JustPressed(pixelgl.KeyF6) {
+			gs.Reroll(gs.Phys.Rect.Max.Y)
 		}
-		g.cols[0] = randomNiceColor()
 	}
-}
 
-func (g *goal) draw(imd *imdraw.IMDraw) {
-	for i := len(g.cols) - 1; i >= 0; i-- {
-		imd.Color = g.cols[i]
-		imd.Push(g.pos)
-		imd.Circle(float64(i+1)*g.radius/float64(len(g.cols)), 0)
+	// the run pauses by itself when nobody's playing, and counts down before it goes on
+	if gs.idle.counting(dt) {
+		return
 	}
-}
-
-func randomNiceColor() pixel.RGBA {
-again:
-	r := rand.Float64()
-	g := rand.Float64()
-	b := rand.Float64()
-	len := math.Sqrt(r*r + g*g + b*b)
-	if len == 0 {
-		goto again
+	if gs.race == nil && gs.idle.update(dt, actions, gs.set.IdlePause) {
+		gs.screens.push(newPauseScreen(win, gs.screens, gs.st, gs.set, gs.runs.code, gs.quit))
+		return
 	}
-	return pixel.RGB(r/len, g/len, b/len)
-}
 
-func rebuildPlatform(idx int, platforms []platform) []platform {
-	platforms = append(platforms[:idx], platforms[idx+1:]...)
-	r := float64(rand.Int63n(240))
-	pf := platform{rect: pixel.R(-160+r, 120, -80+r, 122), color: randomNiceColor()}
-	platforms = append(platforms, pf)
-	return platforms
-}
+	if gs.chose > 0 {
+		actions = tower.WithPick(actions, gs.chose-1)
+		gs.chose = 0
+	}
 
-func updatePlatforms(dt float64, platforms []platform) []platform {
-	for idx, plat := range platforms {
-		platforms[idx].rect.Max.Y -= dt * spe
-		platforms[idx].rect.Min.Y -= dt * spe
-		if plat.rect.Max.Y < -128 {
-			platforms = rebuildPlatform(idx, platforms)
-			// fmt.Println(idx)
+	// the countdown zooms in on the gopher, and the start goes in slow motion
+	dt, gs.cam.Focus = gs.show.update(dt, gs.Hold > 0)
+
+	// update the tower in fixed steps, as many as fit in the frame
+	gs.died = false
+	for n := gs.clock.advance(dt, actions); n > 0; n-- {
+		cmd := tower.Command{Tick: gs.Tick, Actions: gs.clock.next()}
+		before := gs.Phys.Rect
+		gs.runs.update(tower.SimStep, cmd)
+		gs.Sim.Update(tower.SimStep, cmd)
+		gs.clock.stepped(before, tower.SimStep*tower.Scroll)
+		if practicing && gs.died && gs.saved != nil {
+			gs.Load(gs.saved)
 		}
+		// the run is over, practice and races go on after a death
+		if gs.died && gs.restart != nil && !practicing {
+			gs.screens.push(newGameOverScreen(win, gs.screens, gs.death, gs.st.BestScore, gs.restart, gs.quit))
+			break
+		}
+		// a milestone stops the tower for the picks
+		if gs.Offer != nil && !gs.offered {
+			gs.screens.push(newPickScreen(win, gs.screens, gs.Phys.Floor, gs.Offer, func(i int) { gs.chose = i + 1 }))
+			gs.offered = true
+			break
+		}
+		gs.offered = gs.Offer != nil
 	}
-	return platforms
+	if gs.race != nil {
+		// the number keys say the quick chat messages
+		for i := range lobby.QuickChat {
+			if win.JustPressed(pixelgl.Key1 + pixelgl.Button(i)) {
+				gs.race.say(i)
+			}
+		}
+		if gs.died {
+			gs.deaths++
+		}
+		gs.race.setGhost(lobby.Ghost{
+			Tick:   gs.Tick,
+			X:      gs.Phys.Rect.Center().X,
+			Height: playerHeight(gs.Phys),
+			Floor:  gs.Phys.Floor,
+			Deaths: gs.deaths,
+		})
+	}
+	gs.anim.Update(dt, gs.Phys)
+	if gs.trail != nil {
+		gs.trail.lowPower = gs.set.LowPower
+		gs.trail.update(dt, pixel.V(gs.Phys.Rect.Center().X, gs.Phys.Rect.Min.Y+2), gs.Phys.Vel.Len() > 0)
+	}
+	gs.inputs.update(dt, actions)
+	gs.moves.update(dt, gs.Phys, gs.Platforms.All())
+	gs.danger.update(dt, gs.Phys)
+	gs.cam.Update(dt, gs.Phys, gs.danger.pull())
+	themeByName(gs.themes, gs.set.Theme).goals.Update(dt)
+	gs.captions.update(dt)
 }
 
-var score int = 0
-
-func updategoal(gol *goal, platforms []platform, gp *gopherPhys) goal {
-	if gol.pos.Y+gol.radius < -120 {
-		pf := platforms[len(platforms)-1]
-		x := (pf.rect.Max.X + pf.rect.Min.X) / 2
-		y := pf.rect.Max.Y + 10
-		return goal{
-			pos:    pixel.V(x, y),
-			radius: 5,
-			step:   1.0 / 7,
-		}
-	} else if gol.pos.X < gp.rect.Max.X+gol.radius && gol.pos.X > gp.rect.Min.X-gol.radius && gol.pos.Y < gp.rect.Max.Y+gol.radius && gol.pos.Y > gp.rect.Min.Y-gol.radius {
-		score += 1
-		pf := platforms[len(platforms)-1]
-		x := (pf.rect.Max.X + pf.rect.Min.X) / 2
-		y := pf.rect.Max.Y + 10
-		return goal{
-			pos:    pixel.V(x, y),
-			radius: 5,
-			step:   1.0 / 7,
-		}
-	}
-	return *gol
+// tileSet is what the platforms are drawn with, nil for the classic ones. The sheet is loaded
+// when they're drawn from it and let go of when they're switched to the classic look.
+func (gs *gameScreen) tileSet() *tower.TileSet {
+	if gs.set.Platforms == "classic" {
+		if gs.tiles != nil {
+			gs.biome.release()
+			gs.tiles = nil
+		}
+		return nil
+	}
+	if gs.tiles == nil && !gs.noTiles {
+		sheet, err := gs.biome.picture("tiles.png")
+		if err != nil {
+			// the classic flat platforms do without
+			fmt.Println(err)
+			gs.noTiles = true
+			return nil
+		}
+		gs.tiles = tower.NewTileSet(sheet)
+	}
+	return gs.tiles
 }
 
-func run() {
-	rand.Seed(time.Now().UnixNano())
-
-	sheet, anims, err := loadAnimationSheet("sheet.png", "sheet.csv", 12)
-	if err != nil {
-		panic(err)
+func (gs *gameScreen) draw(canvas *pixelgl.Canvas) {
+	th := themeByName(gs.themes, gs.set.Theme)
+	sky, ambient := th.skies[0], false
+	if d, ok := ambientAt(gs.set.Ambient, time.Now()); ok {
+		sky, ambient = th.skies[d], true
+		canvas.SetMatrix(screenMatrix())
+		gs.ambience.Clear()
+		sky.drawSky(gs.ambience, canvasBounds)
+		gs.ambience.Draw(canvas)
 	}
 
-	cfg := pixelgl.WindowConfig{
-		Title:  "Platformer",
-		Bounds: pixel.R(0, 0, 1024, 768),
-		VSync:  true,
+	canvas.SetMatrix(gs.cam.Matrix().Chained(screenMatrix()))
+
+	// draw the scene to the canvas using IMDraw, the decorations in their own batch behind it
+	gs.Decor.Draw(canvas)
+	imd := gs.imd
+	imd.Clear()
+	gs.best.draw(imd)
+	tiles := gs.tileSet()
+	gs.DrawTower(imd, tiles, th.goals)
+	if gs.trail != nil {
+		gs.trail.draw(imd)
 	}
-	win, err := pixelgl.NewWindow(cfg)
-	if err != nil {
-		panic(err)
+	if tiles != nil {
+		tiles.Draw(canvas)
 	}
-
-	phys := &gopherPhys{
-		gravity:   -512,
-		runSpeed:  64,
-		jumpSpeed: 240,
-		rect:      pixel.R(-6, 40, 6, 54),
+	imd.Draw(canvas)
+	th.goals.Flush(canvas)
+	if gs.race != nil {
+		gs.drawGhosts(canvas)
 	}
-
-	anim := &gopherAnim{
-		sheet: sheet,
-		anims: anims,
-		rate:  1.0 / 10,
-		dir:   +1,
+	drawn := gs.clock.drawn(gs.Phys)
+	gs.anim.Draw(canvas, drawn)
+	if shift, ok := drawn.Seam(); ok {
+		// the half that's gone out one side comes in the other
+		across := *drawn
+		across.Rect = across.Rect.Moved(shift)
+		gs.anim.Draw(canvas, &across)
 	}
+	gs.best.drawLabel(canvas)
 
-	// hardcoded level
-	platforms := []platform{
-		{rect: pixel.R(-170, -120, -120, -118)},
-		{rect: pixel.R(-170, -100, -120, -98)},
-		{rect: pixel.R(50, -80, 140, -78)},
-		{rect: pixel.R(-80, -60, -30, -58)},
-		{rect: pixel.R(-30, -40, 60, -38)},
-		{rect: pixel.R(-130, -20, -40, -18)},
-		{rect: pixel.R(10, 0, 100, 2)},
-		{rect: pixel.R(-120, 20, -20, 22)},
-		{rect: pixel.R(-20, 40, 70, 42)},
-		{rect: pixel.R(-70, 60, 20, 62)},
-		{rect: pixel.R(-40, 80, 50, 82)},
-		{rect: pixel.R(70, 100, 160, 102)},
+	canvas.SetMatrix(screenMatrix())
+	if ambient {
+		gs.ambience.Clear()
+		sky.drawLight(gs.ambience, canvasBounds)
+		gs.ambience.Draw(canvas)
 	}
-	for i := range platforms {
-		platforms[i].color = randomNiceColor()
+	gs.danger.draw(canvas, canvasBounds)
+	gs.gauge.Clear()
+	drawStamina(gs.gauge, &gs.Phys.Stamina, canvasBounds)
+	gs.gauge.Draw(canvas)
+	for _, g := range gs.Goals.All() {
+		gs.arrow.draw(canvas, g.Pos, gs.cam, canvasBounds)
 	}
-
-	face, err := loadTTF("intuitive.ttf", 80)
-	if err != nil {
-		panic(err)
+	gs.Prestige.Draw(gs.badge, canvas, canvasBounds)
+	best := gs.st.BestScore
+	if tower.Score > best {
+		best = tower.Score
 	}
-
-	atlas := text.NewAtlas(face, text.ASCII)
-	txt := text.New(pixel.V(50, 500), atlas)
-
-	txt.Color = colornames.Lightgrey
-
-	fps := time.Tick(time.Second / 120)
-
-	// {rect: pixel.R(-20, 80, 30, 82)},
-	gol := &goal{
-		pos:    pixel.V(5, 92),
-		radius: 5,
-		step:   1.0 / 7,
+	hud.set("score", strconv.Itoa(tower.Score))
+	hud.set("best", strconv.Itoa(best))
+	hud.set("height", fmt.Sprintf("%.0f", playerHeight(gs.Phys)))
+	gs.idle.draw(canvas, canvasBounds)
+	gs.show.draw(canvas, canvasBounds, ctl.prompt("{skip} to skip"))
+	gs.restarting.draw(canvas, canvasBounds)
+	if gs.set.InputDisplay {
+		gs.inputs.draw(canvas)
 	}
+	if gs.showMoves {
+		gs.moves.draw(canvas)
+	}
+	if gs.set.Captions {
+		gs.captions.draw(canvas)
+	}
+}
 
-	canvas := pixelgl.NewCanvas(pixel.R(-320/2, -240/2, 320/2, 240/2))
-	imd := imdraw.New(sheet)
-	imd.Precision = 32
-
-	camPos := pixel.ZV
-
-	last := time.Now()
-	for !win.Closed() {
-		dt := time.Since(last).Seconds()
-		last = time.Now()
-
-		cam := pixel.IM.Moved(camPos.Scaled(-1))
-		canvas.SetMatrix(cam)
+var (
+	dataDir        = flag.String("data", "", "keep the saves, settings, replays... in this directory instead of the usual one for the OS, for portable installs")
+	uiScale        = flag.Float64("scale", 0, "scale of the window and the text, instead of the one worked out from the monitor's DPI")
+	monitor        = flag.String("monitor", "", "put the game on this monitor, by number (1 is the first) or name, and remember it")
+	startFloor     = flag.Int("floor", 0, "start the runs at this floor, in practice mode")
+	logRuns        = flag.Bool("runs", false, "write a summary of every run to the runs directory")
+	telemetryURL   = flag.String("telemetry", "", "opt in to sending anonymous gameplay events to this URL")
+	leaderboardURL = flag.String("leaderboard", "", "submit the scores to the leaderboard server at this URL")
+	challengesURL  = flag.String("challenges", "", "get the daily and weekly challenges and their events from the server at this URL (the server's /challenges)")
+	levelsURL      = flag.String("levels", "", "browse, play and rate the community levels on the level server at this URL (the server's /levels)")
+	raceURL        = flag.String("race", "", "race friends' ghosts up the same tower, in rooms on the server at this URL (the server's /rooms)")
+	overlayPort    = flag.Int("overlay-port", 0, "stream the score, floor, speed and state of the run as JSON over a WebSocket on this port of localhost, for stream overlays")
+
+	verify      = flag.String("verify", "", "play this replay headless and print the hash of the simulation, instead of the game")
+	verifyEvery = flag.Int("verify-every", 0, "also print the hash every this many steps of the replay")
+	renderVideo = flag.String("render-replay", "", "render this replay to the video file named after the flags (a .gif, or anything ffmpeg can write), instead of the game")
+	gameCamera  = flag.String("camera", "gameplay", "the camera while playing, gameplay or cinematic")
+	videoCamera = flag.String("replay-camera", "cinematic", "the camera of the rendered replays, gameplay or cinematic")
+	videoStamp  = flag.Bool("replay-stamp", true, "write the tower's code, the mode, the floor and the score in the corner of the rendered replays")
+	recordBot   = flag.String("record-bot", "", "let the bot play a minute and save it as a replay to this file, instead of the game")
+	botSeed     = flag.Int64("record-bot-seed", 1, "seed of the tower the bot plays for -record-bot")
+
+	exportLevel     = flag.String("export-level", "", "make a community level of this chunk library and write it to the file named after the flags, instead of the game")
+	levelName       = flag.String("level-name", "", "name of the exported level")
+	levelAuthor     = flag.String("level-author", "", "author of the exported level")
+	levelDifficulty = flag.Int("level-difficulty", 3, "difficulty of the exported level, from 1 to 5")
+	uploadLevel     = flag.String("upload-level", "", "upload this exported level to the -levels server, instead of the game")
+
+	exportArchive = flag.String("export-profile", "", "write the settings, stats and high scores, profile, challenge log, replays and runs to this archive, to move them to another computer, instead of the game")
+	importArchive = flag.String("import-profile", "", "bring in the saves of this archive made by -export-profile, the ones it replaces are kept as .bak, instead of the game")
+)
 
-		// slow motion with tab
-		if win.Pressed(pixelgl.KeyTab) {
-			dt /= 8
+func main() {
+	flag.Parse()
+	if *dataDir != "" {
+		storage.SetDir(*dataDir)
+	}
+	for _, name := range []string{*gameCamera, *videoCamera} {
+		if _, ok := render.CameraNames[name]; !ok {
+			fmt.Fprintf(os.Stderr, "no such camera %q, there's gameplay and cinematic\n", name)
+			os.Exit(2)
 		}
-		// if spe < 45 {
-		// 	spe += dt
-		// }
-
-		// restart the level on pressing enter
-		if win.JustPressed(pixelgl.KeyEnter) {
-			phys.rect = phys.rect.Moved(phys.rect.Center().Scaled(-1))
-			phys.vel = pixel.ZV
+	}
+	if *verify != "" || *recordBot != "" || *renderVideo != "" || *exportLevel != "" || *uploadLevel != "" || *exportArchive != "" || *importArchive != "" {
+		if err := runTool(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
 		}
+		return
+	}
+	pixelgl.Run(run)
+}
 
-		// control the gopher with keys
-		ctrl := pixel.ZV
-		if win.Pressed(pixelgl.KeyLeft) {
-			ctrl.X--
+// runTool runs one of the headless tools picked on the command line
+func runTool() error {
+	switch {
+	case *exportArchive != "":
+		return exportProfile(*exportArchive)
+	case *importArchive != "":
+		return importProfile(*importArchive)
+	case *exportLevel != "":
+		if flag.NArg() == 0 {
+			return errors.New("-export-level needs the level file to write after the flags")
 		}
-		if win.Pressed(pixelgl.KeyRight) {
-			ctrl.X++
+		l, err := makeLevel(*exportLevel, *levelName, *levelAuthor, *levelDifficulty)
+		if err != nil {
+			return err
 		}
-		if win.JustPressed(pixelgl.KeyUp) {
-			ctrl.Y = 1
+		return saveLevel(l, flag.Arg(0))
+	case *uploadLevel != "":
+		if *levelsURL == "" {
+			return errors.New("-upload-level needs the -levels server")
 		}
-
-		// update the physics and animation
-		phys.update(dt, ctrl, platforms)
-		gol.update(dt)
-		anim.update(dt, phys)
-
-		// update the platforms
-		platforms = updatePlatforms(dt, platforms)
-		*gol = updategoal(gol, platforms, phys)
-
-		// draw the scene to the canvas using IMDraw
-		canvas.Clear(colornames.Black)
-		imd.Clear()
-		for _, p := range platforms {
-			p.draw(imd)
+		l, err := loadLevel(*uploadLevel)
+		if err != nil {
+			return err
 		}
-		gol.draw(imd)
-		anim.draw(imd, phys)
-		imd.Draw(canvas)
-
-		txt.WriteString(string(rune(score)))
-
-		// stretch the canvas to the window
-		win.Clear(colornames.White)
-		win.SetMatrix(pixel.IM.Scaled(pixel.ZV,
-			math.Min(
-				win.Bounds().W()/canvas.Bounds().W(),
-				win.Bounds().H()/canvas.Bounds().H(),
-			),
-		).Moved(win.Bounds().Center()))
-		canvas.Draw(win, pixel.IM.Moved(canvas.Bounds().Center()))
-		txt.Draw(win, pixel.IM.Moved(win.Bounds().Center().Sub(txt.Bounds().Center())))
-		win.Update()
-
-		<-fps
-	}
-	fmt.Println(spe)
-}
+		lc, err := newLevelClient(*levelsURL)
+		if err != nil {
+			return err
+		}
+		up, err := lc.upload(l)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("uploaded %s, id %s\n", up.Name, up.ID)
+		return nil
+	}
 
-func main() {
-	pixelgl.Run(run)
+	chunks, err := tower.LoadChunks("chunks.json")
+	if err != nil {
+		return err
+	}
+	picks, err := tower.LoadMutators("picks.json", tower.MaxPicks)
+	if err != nil {
+		return err
+	}
+	switch {
+	case *verify != "":
+		r, err := loadReplay(*verify)
+		if err != nil {
+			return err
+		}
+		verifyReplay(os.Stdout, r, chunks, picks, *verifyEvery)
+	case *recordBot != "":
+		return recordBotReplay(chunks, picks, *botSeed, 60).save(*recordBot)
+	case *renderVideo != "":
+		if flag.NArg() == 0 {
+			return errors.New("-render-replay needs the video file to write after the flags")
+		}
+		r, err := loadReplay(*renderVideo)
+		if err != nil {
+			return err
+		}
+		_, anims, err := sprite.LoadSheet("sheet.png", "sheet.csv", 12, render.GopherAnimations...)
+		if err != nil {
+			return err
+		}
+		return renderReplay(r, chunks, picks, anims, render.CameraNames[*videoCamera], *videoStamp, flag.Arg(0))
+	}
+	return nil
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+)
+
+// mods are scripts players drop in the mods directory, they hear about the runs and can change
+// the tower. The Lua ones are only built in with the lua tag, see mods_lua.go.
+type mods interface {
+	// start is called with the sim of every new run
+	start(s *tower.Sim)
+	onEvent(e tower.Event)
+	close()
+}
+
+// noMods is what the game runs with when it has no mods
+type noMods struct{}
+
+func (noMods) start(s *tower.Sim)    {}
+func (noMods) onEvent(e tower.Event) {}
+func (noMods) close()                {}
+
+// modAssets are the assets directories of the mods, mods/<name>/assets, in name order. Their
+// files replace the game's own with the same path, like mods/hd/assets/sheet.png.
+func modAssets() []string {
+	dir, err := storage.Path(storage.Mods)
+	if err != nil {
+		return nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var layers []string
+	for _, f := range files {
+		assets := filepath.Join(dir, f.Name(), "assets")
+		if st, err := os.Stat(assets); err == nil && st.IsDir() {
+			layers = append(layers, assets)
+		}
+	}
+	return layers
+}
@@ -0,0 +1,145 @@
+//go:build lua
+// +build lua
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"GoTower/events"
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// luaMods runs every .lua script of the mods directory in one Lua state. The scripts define the
+// hooks they want as globals:
+//
+//	onRunStart()
+//	onFloorReached(floor, height)
+//	onGoalCollected(value)
+//	onEvent(kind, fields)
+//
+// and can call spawnPlatform(x, y, width) and setGravity(gravity) from them. onEvent gets every
+// event of the GoTower/events package, the kind like "goalCollected" and a table of its fields
+// by their JSON names. A hook that fails is printed and turned off, so a broken mod doesn't take
+// the game down with it.
+type luaMods struct {
+	l   *lua.LState
+	cur *tower.Sim
+}
+
+func loadMods() (mods, error) {
+	dir, err := storage.Path(storage.Mods)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading mods")
+	}
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return noMods{}, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading mods")
+	}
+	var scripts []string
+	for _, f := range files {
+		if !f.IsDir() && strings.EqualFold(filepath.Ext(f.Name()), ".lua") {
+			scripts = append(scripts, filepath.Join(dir, f.Name()))
+		}
+	}
+	if len(scripts) == 0 {
+		return noMods{}, nil
+	}
+	sort.Strings(scripts)
+
+	m := &luaMods{l: lua.NewState()}
+	m.l.SetGlobal("spawnPlatform", m.l.NewFunction(m.spawnPlatform))
+	m.l.SetGlobal("setGravity", m.l.NewFunction(m.setGravity))
+	for _, path := range scripts {
+		// the other scripts still load
+		if err := m.l.DoFile(path); err != nil {
+			fmt.Println(errors.Wrapf(err, "error loading mod %s", filepath.Base(path)))
+			continue
+		}
+		fmt.Println("loaded mod", filepath.Base(path))
+	}
+	return m, nil
+}
+
+// call runs the hook if a script defined it
+func (m *luaMods) call(hook string, args ...lua.LValue) {
+	fn, ok := m.l.GetGlobal(hook).(*lua.LFunction)
+	if !ok {
+		return
+	}
+	if err := m.l.CallByParam(lua.P{Fn: fn, Protect: true}, args...); err != nil {
+		fmt.Println(errors.Wrapf(err, "error in mod hook %s, turning it off", hook))
+		m.l.SetGlobal(hook, lua.LNil)
+	}
+}
+
+func (m *luaMods) start(s *tower.Sim) {
+	m.cur = s
+	m.call("onRunStart")
+}
+
+func (m *luaMods) onEvent(e tower.Event) {
+	if ee := exported(e); ee != nil {
+		fields := m.l.NewTable()
+		for k, v := range events.Fields(ee) {
+			switch v := v.(type) {
+			case float64:
+				fields.RawSetString(k, lua.LNumber(v))
+			case string:
+				fields.RawSetString(k, lua.LString(v))
+			case bool:
+				fields.RawSetString(k, lua.LBool(v))
+			}
+		}
+		m.call("onEvent", lua.LString(events.Kind(ee)), fields)
+	}
+	switch e := e.(type) {
+	case physics.FloorReached:
+		m.call("onFloorReached", lua.LNumber(e.Floor), lua.LNumber(e.Height))
+	case tower.GoalCollected:
+		m.call("onGoalCollected", lua.LNumber(e.Value))
+	case physics.Died:
+		// the gopher starts again right away, that's a new run
+		m.call("onRunStart")
+	}
+}
+
+func (m *luaMods) close() {
+	m.l.Close()
+}
+
+// spawnPlatform(x, y, width) adds a platform, in the world coordinates of the screen, x from
+// -160 to 160 and y from -120 to 120
+func (m *luaMods) spawnPlatform(l *lua.LState) int {
+	x, y, w := float64(l.CheckNumber(1)), float64(l.CheckNumber(2)), float64(l.CheckNumber(3))
+	if m.cur != nil && w > 0 {
+		m.cur.Platforms.Add(entity.Platform{Rect: pixel.R(x, y, x+w, y+2), Color: tower.RandomNiceColor()})
+	}
+	return 0
+}
+
+// setGravity(gravity) changes the gravity, -512 is the normal one, the generator follows so the
+// platforms stay in reach
+func (m *luaMods) setGravity(l *lua.LState) int {
+	g := float64(l.CheckNumber(1))
+	if m.cur != nil && g < 0 {
+		m.cur.Phys.Gravity = g
+		m.cur.Platforms.Spawner.Gravity = g
+	}
+	return 0
+}
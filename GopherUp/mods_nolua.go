@@ -0,0 +1,9 @@
+//go:build !lua
+// +build !lua
+
+package main
+
+// loadMods has nothing to load in builds without the lua tag
+func loadMods() (mods, error) {
+	return noMods{}, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// moveStats is the movement overlay, for speedrunners tuning their movement: the gopher's
+// velocity, how long it's been in the air and the last jump was, the jumps this run and how far
+// the next platform up is
+type moveStats struct {
+	vel pixel.Vec
+	// air is the time in the air so far, lastAir the whole of the last time
+	air, lastAir float64
+	jumps        int
+	// next is from the gopher's feet to the nearest bit of the closest platform above them, ok
+	// is false when there's none
+	next pixel.Vec
+	ok   bool
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newMoveStats() *moveStats {
+	return &moveStats{
+		imd: imdraw.New(nil),
+		txt: text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (ms *moveStats) onEvent(e tower.Event) {
+	switch e.(type) {
+	case physics.Jumped:
+		ms.jumps++
+	case physics.Died:
+		ms.jumps = 0
+	}
+}
+
+// update reads the gopher's physics after the step
+func (ms *moveStats) update(dt float64, gp *physics.Body, platforms []*entity.Platform) {
+	ms.vel = gp.Vel
+	switch {
+	case !gp.Ground:
+		ms.air += dt
+	case ms.air > 0:
+		ms.lastAir, ms.air = ms.air, 0
+	}
+
+	feet := pixel.V(gp.Rect.Center().X, gp.Rect.Min.Y)
+	ms.ok = false
+	for _, p := range platforms {
+		x := math.Max(p.Rect.Min.X, math.Min(feet.X, p.Rect.Max.X))
+		d := pixel.V(x, p.Top(x)).Sub(feet)
+		if d.Y <= 0 {
+			continue
+		}
+		if !ms.ok || d.Y < ms.next.Y {
+			ms.next, ms.ok = d, true
+		}
+	}
+}
+
+func (ms *moveStats) draw(canvas *pixelgl.Canvas) {
+	canvas.SetMatrix(screenMatrix())
+	ms.txt.Clear()
+	ms.txt.Color = colornames.White
+	fmt.Fprintf(ms.txt, "vel  %6.1f %6.1f\n", ms.vel.X, ms.vel.Y)
+	fmt.Fprintf(ms.txt, "air  %6.2fs (last %.2fs)\n", ms.air, ms.lastAir)
+	fmt.Fprintf(ms.txt, "jumps %d\n", ms.jumps)
+	if ms.ok {
+		fmt.Fprintf(ms.txt, "next %+6.1f %+6.1f", ms.next.X, ms.next.Y)
+	} else {
+		ms.txt.WriteString("next  none")
+	}
+
+	// in the top left corner, on a dark box so it reads over anything
+	bounds := ms.txt.Bounds()
+	at := pixel.V(canvasBounds.Min.X+6-bounds.Min.X, canvasBounds.Max.Y-6-bounds.Max.Y)
+	box := bounds.Moved(at)
+	ms.imd.Clear()
+	ms.imd.Color = pixel.Alpha(0.6)
+	ms.imd.Push(box.Min.Sub(pixel.V(3, 2)), box.Max.Add(pixel.V(3, 2)))
+	ms.imd.Rectangle(0)
+	ms.imd.Draw(canvas)
+	ms.txt.Draw(canvas, pixel.IM.Moved(at))
+}
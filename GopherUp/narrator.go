@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+)
+
+// speechPrograms are the text to speech programs tried on each system, the first one installed
+// is used. Windows has its speech in PowerShell, the text goes in on stdin.
+var speechPrograms = map[string][]string{
+	"darwin":  {"say"},
+	"windows": {"powershell"},
+	"linux":   {"espeak-ng", "espeak", "spd-say"},
+}
+
+// narrator reads the menus and the important moments of a run out loud, for players who can't
+// see the screen well. Anything new cuts off what's being said, so going through a menu doesn't
+// lag behind the keys.
+type narrator struct {
+	program string
+	on      func() bool
+
+	mu  sync.Mutex
+	cmd *exec.Cmd
+}
+
+// speech is the narrator, nil when the system has no speech
+var speech *narrator
+
+// newNarrator finds the system's speech, it's nil when there's none. It only talks while on says
+// so.
+func newNarrator(on func() bool) *narrator {
+	for _, p := range speechPrograms[runtime.GOOS] {
+		if path, err := exec.LookPath(p); err == nil {
+			return &narrator{program: path, on: on}
+		}
+	}
+	return nil
+}
+
+// say speaks the text, if narration is on
+func say(s string) {
+	if speech != nil {
+		speech.say(s)
+	}
+}
+
+func (n *narrator) say(s string) {
+	if !n.on() {
+		return
+	}
+	// the line breaks of the screens are pauses
+	s = strings.Join(strings.Fields(strings.Replace(s, "\n", ". ", -1)), " ")
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.cmd != nil {
+		// it's already done, more often than not
+		n.cmd.Process.Kill()
+	}
+	cmd := exec.Command(n.program, s)
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command(n.program, "-NoProfile", "-Command",
+			"Add-Type -AssemblyName System.Speech; (New-Object System.Speech.Synthesis.SpeechSynthesizer).Speak([Console]::In.ReadToEnd())")
+		cmd.Stdin = strings.NewReader(s)
+	}
+	if err := cmd.Start(); err != nil {
+		fmt.Println("error narrating:", err)
+		n.cmd = nil
+		return
+	}
+	n.cmd = cmd
+	go cmd.Wait()
+}
+
+func (n *narrator) onEvent(e tower.Event) {
+	switch e := e.(type) {
+	case tower.GoalCollected:
+		n.say(fmt.Sprintf("score %d", tower.Score))
+	case physics.FloorReached:
+		if milestone(e.Floor) {
+			n.say(fmt.Sprintf("floor %d", e.Floor))
+		}
+	case tower.BossWarned:
+		if e.Dir > 0 {
+			n.say("bird from the left")
+		} else {
+			n.say("bird from the right")
+		}
+	case tower.PrestigeReached:
+		n.say(fmt.Sprintf("prestige %d", e.Tier))
+	case tower.CountdownTick:
+		// the numbers would cut off what's said when a run ends
+		if e.Left == 0 {
+			n.say("go")
+		}
+	case physics.LifeLost:
+		n.say(fmt.Sprintf("life lost, %d left", e.Left))
+	case physics.Died:
+		n.say(fmt.Sprintf("game over, %s, at floor %d, score %d", e.Cause, int(e.Height/physics.FloorHeight), tower.Score))
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"GoTower/internal/storage"
+
+	"github.com/pkg/errors"
+)
+
+// the outbox waits outboxBackoff after a failed post, twice as long after each one in a row, up
+// to outboxMaxBackoff. It keeps outboxMax posts, the oldest go when there are more.
+const (
+	outboxBackoff    = time.Second
+	outboxMaxBackoff = 5 * time.Minute
+	outboxMax        = 200
+)
+
+// outboxPost is a post waiting to go out
+type outboxPost struct {
+	ID    int64           `json:"id"`
+	URL   string          `json:"url"`
+	Body  json.RawMessage `json:"body"`
+	Tries int             `json:"tries"`
+}
+
+// outbox posts JSON in the background, one post at a time and in order, retrying with backoff
+// while the server can't be reached, so a flaky connection never holds up the game. A post the
+// server turns down is dropped. When it has a file, what's still waiting is kept there and sent
+// on the next start. It's for the posts nothing waits on, the scores and the telemetry: the level
+// browser, the races and the challenges need the server's answer there and then, they ask from
+// their own goroutines and time out instead.
+type outbox struct {
+	what   string
+	file   string
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []outboxPost
+	nextID  int64
+
+	wake chan struct{}
+	stop chan struct{}
+	done chan struct{}
+	// rnd jitters the backoff, away from the gameplay and cosmetic numbers
+	rnd *rand.Rand
+}
+
+// outboxSave is the outbox file
+type outboxSave struct {
+	Version int          `json:"version"`
+	Pending []outboxPost `json:"pending"`
+}
+
+func newOutbox(what string, client *http.Client, file string) *outbox {
+	o := &outbox{
+		what:   what,
+		file:   file,
+		client: client,
+		wake:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		rnd:    rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if file != "" {
+		o.load()
+	}
+	go o.run()
+	return o
+}
+
+// load reads the posts left from the last time, a broken file is only reported
+func (o *outbox) load() {
+	data, err := readSave(outboxFile, o.file)
+	if os.IsNotExist(err) {
+		return
+	}
+	var save outboxSave
+	if err == nil {
+		err = json.Unmarshal(data, &save)
+	}
+	if err != nil {
+		fmt.Println(errors.Wrapf(err, "error loading the %s outbox", o.what))
+		return
+	}
+	o.pending = save.Pending
+	for _, p := range o.pending {
+		if p.ID >= o.nextID {
+			o.nextID = p.ID + 1
+		}
+	}
+}
+
+// save writes the pending posts, the lock has to be held
+func (o *outbox) save() {
+	if o.file == "" {
+		return
+	}
+	data, err := json.MarshalIndent(outboxSave{Version: outboxFile.Version(), Pending: o.pending}, "", "\t")
+	if err == nil {
+		err = storage.WriteFile(data, 0644, o.file)
+	}
+	if err != nil {
+		fmt.Println(errors.Wrapf(err, "error saving the %s outbox", o.what))
+	}
+}
+
+// post queues v to be posted to url as JSON, it never blocks
+func (o *outbox) post(url string, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		fmt.Println(errors.Wrapf(err, "error sending %s", o.what))
+		return
+	}
+	o.mu.Lock()
+	o.pending = append(o.pending, outboxPost{ID: o.nextID, URL: url, Body: body})
+	o.nextID++
+	if len(o.pending) > outboxMax {
+		o.pending = o.pending[len(o.pending)-outboxMax:]
+	}
+	o.save()
+	o.mu.Unlock()
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// backoff is how long to wait after tries failed posts in a row, with some jitter so a server
+// coming back isn't hit by every game at once
+func (o *outbox) backoff(tries int) time.Duration {
+	d := outboxMaxBackoff
+	if tries < 20 {
+		if b := outboxBackoff << uint(tries-1); b < d {
+			d = b
+		}
+	}
+	return d/2 + time.Duration(o.rnd.Int63n(int64(d/2)+1))
+}
+
+func (o *outbox) run() {
+	defer close(o.done)
+	var wait time.Duration
+	for {
+		o.mu.Lock()
+		empty := len(o.pending) == 0
+		var p outboxPost
+		if !empty {
+			p = o.pending[0]
+		}
+		o.mu.Unlock()
+		if empty {
+			select {
+			case <-o.wake:
+				continue
+			case <-o.stop:
+				return
+			}
+		}
+		if wait > 0 {
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-o.stop:
+				timer.Stop()
+				return
+			}
+		}
+
+		retry, err := o.send(p)
+		o.mu.Lock()
+		for i := range o.pending {
+			if o.pending[i].ID != p.ID {
+				continue
+			}
+			if retry {
+				o.pending[i].Tries++
+				p.Tries = o.pending[i].Tries
+			} else {
+				o.pending = append(o.pending[:i], o.pending[i+1:]...)
+			}
+			break
+		}
+		o.save()
+		o.mu.Unlock()
+
+		wait = 0
+		switch {
+		case err == nil:
+		case !retry:
+			fmt.Println(errors.Wrapf(err, "error sending %s, dropped", o.what))
+		default:
+			if p.Tries == 1 {
+				fmt.Println(errors.Wrapf(err, "error sending %s, retrying", o.what))
+			}
+			wait = o.backoff(p.Tries)
+		}
+	}
+}
+
+// send posts p, retry is whether it's worth trying again: the server couldn't be reached, was
+// busy or failed, rather than turning the post down
+func (o *outbox) send(p outboxPost) (retry bool, err error) {
+	resp, err := o.client.Post(p.URL, "application/json", bytes.NewReader(p.Body))
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		return false, nil
+	}
+	msg, _ := ioutil.ReadAll(resp.Body)
+	err = errors.Errorf("%s: %s", resp.Status, bytes.TrimSpace(msg))
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout, err
+}
+
+// close stops the outbox once what's waiting went out, or after wait, whichever comes first.
+// What's left stays in the file for the next start.
+func (o *outbox) close(wait time.Duration) {
+	deadline := time.After(wait)
+	for o.waiting() {
+		select {
+		case <-deadline:
+			close(o.stop)
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+	close(o.stop)
+	<-o.done
+}
+
+func (o *outbox) waiting() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.pending) > 0
+}
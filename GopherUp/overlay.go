@@ -0,0 +1,239 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"GoTower/internal/tower"
+)
+
+// overlayRate is how many times a second the overlay feed sends the state
+const overlayRate = 4
+
+// overlayState is what the overlay feed sends, for streamers' browser overlays
+type overlayState struct {
+	// State is menu, countdown, playing, picking or paused
+	State  string  `json:"state"`
+	Code   string  `json:"code,omitempty"`
+	Score  int     `json:"score"`
+	Floor  int     `json:"floor"`
+	Height float64 `json:"height"`
+	Speed  float64 `json:"speed"`
+	Lives  int     `json:"lives"`
+}
+
+// overlayStateOf is the state of the game, from the screens: the run is the tower on the stack,
+// what's on top of it says whether it's going on
+func overlayStateOf(screens *screenStack) overlayState {
+	var gs *gameScreen
+	for _, s := range screens.screens {
+		if g, ok := s.(*gameScreen); ok {
+			gs = g
+		}
+	}
+	if gs == nil {
+		return overlayState{State: "menu"}
+	}
+	st := overlayState{
+		State:  "paused",
+		Code:   gs.runs.code.String(),
+		Score:  tower.Score,
+		Floor:  gs.Phys.Floor,
+		Height: playerHeight(gs.Phys),
+		Speed:  tower.Scroll,
+		Lives:  gs.Phys.Lives,
+	}
+	switch screens.top().(type) {
+	case *gameScreen:
+		st.State = "playing"
+		if gs.Hold > 0 {
+			st.State = "countdown"
+		}
+	case *pickScreen:
+		st.State = "picking"
+	}
+	return st
+}
+
+// overlayFeed streams the state over WebSockets on localhost, for overlays that would otherwise
+// have to read the screen. A plain GET gets the state once, as JSON.
+type overlayFeed struct {
+	mu      sync.Mutex
+	state   overlayState
+	clients map[net.Conn]bool
+	srv     *http.Server
+	done    chan struct{}
+}
+
+// startOverlay serves the feed on the port until the returned feed is stopped, it does nothing
+// for port 0, a nil feed takes the state and throws it away
+func startOverlay(port int) *overlayFeed {
+	if port == 0 {
+		return nil
+	}
+	f := &overlayFeed{clients: map[net.Conn]bool{}, done: make(chan struct{})}
+	f.srv = &http.Server{Addr: fmt.Sprintf("127.0.0.1:%d", port), Handler: http.HandlerFunc(f.serve)}
+	go func() {
+		if err := f.srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			fmt.Println("overlay feed:", err)
+		}
+	}()
+	go f.broadcast()
+	return f
+}
+
+// set is the state to send next, called every frame
+func (f *overlayFeed) set(st overlayState) {
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	f.state = st
+	f.mu.Unlock()
+}
+
+func (f *overlayFeed) stop() {
+	if f == nil {
+		return
+	}
+	close(f.done)
+	f.srv.Close()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for c := range f.clients {
+		c.Close()
+	}
+}
+
+func (f *overlayFeed) serve(w http.ResponseWriter, r *http.Request) {
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") {
+		f.mu.Lock()
+		st := f.state
+		f.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		// the overlays are pages of their own, on file:// or a streaming tool's origin
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		json.NewEncoder(w).Encode(st)
+		return
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	hj, ok := w.(http.Hijacker)
+	if key == "" || !ok {
+		http.Error(w, "bad websocket handshake", http.StatusBadRequest)
+		return
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return
+	}
+	sum := sha1.Sum([]byte(key + "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return
+	}
+	f.mu.Lock()
+	f.clients[conn] = true
+	f.mu.Unlock()
+	go f.listen(conn, rw.Reader)
+}
+
+// listen reads what the client sends, only to notice when it's gone: overlays don't talk back
+func (f *overlayFeed) listen(conn net.Conn, r *bufio.Reader) {
+	defer f.drop(conn)
+	for {
+		var head [2]byte
+		if _, err := io.ReadFull(r, head[:]); err != nil {
+			return
+		}
+		// a close frame
+		if head[0]&0x0f == 8 {
+			return
+		}
+		n := uint64(head[1] & 0x7f)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(r, ext[:]); err != nil {
+				return
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		// the mask key, clients always mask
+		if head[1]&0x80 != 0 {
+			n += 4
+		}
+		if _, err := io.CopyN(ioutil.Discard, r, int64(n)); err != nil {
+			return
+		}
+	}
+}
+
+func (f *overlayFeed) drop(conn net.Conn) {
+	f.mu.Lock()
+	delete(f.clients, conn)
+	f.mu.Unlock()
+	conn.Close()
+}
+
+// broadcast sends the state to every client overlayRate times a second
+func (f *overlayFeed) broadcast() {
+	tick := time.NewTicker(time.Second / overlayRate)
+	defer tick.Stop()
+	for {
+		select {
+		case <-f.done:
+			return
+		case <-tick.C:
+		}
+		f.mu.Lock()
+		msg, _ := json.Marshal(f.state)
+		var clients []net.Conn
+		for c := range f.clients {
+			clients = append(clients, c)
+		}
+		f.mu.Unlock()
+		frame := textFrame(msg)
+		for _, c := range clients {
+			c.SetWriteDeadline(time.Now().Add(time.Second))
+			if _, err := c.Write(frame); err != nil {
+				f.drop(c)
+			}
+		}
+	}
+}
+
+// textFrame is msg as an unmasked WebSocket text frame, how a server sends
+func textFrame(msg []byte) []byte {
+	frame := []byte{0x81}
+	switch n := len(msg); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n < 1<<16:
+		frame = append(frame, 126, byte(n>>8), byte(n))
+	default:
+		frame = append(frame, 127)
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(frame, ext[:]...)
+	}
+	return append(frame, msg...)
+}
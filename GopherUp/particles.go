@@ -0,0 +1,127 @@
+package main
+
+import (
+	"math"
+
+	"GoTower/internal/rng"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// emitter is how a particle system makes its particles, it's data, from trails.json
+type emitter struct {
+	// Rate is how many particles a second, Life how long each one lasts in seconds
+	Rate float64 `json:"rate"`
+	Life float64 `json:"life"`
+	// particles go off at Speed in a random direction up to Spread degrees from straight down,
+	// and fall with Gravity (up if it's negative)
+	Speed   float64 `json:"speed"`
+	Spread  float64 `json:"spread"`
+	Gravity float64 `json:"gravity"`
+	// Size is the radius a particle starts with, it shrinks to nothing over its life when Shrink
+	// is set
+	Size   float64 `json:"size"`
+	Shrink bool    `json:"shrink,omitempty"`
+	// Colors are names from the CSS colors, a particle gets a random one, or the next one every
+	// particle with Cycle
+	Colors []string `json:"colors"`
+	Cycle  bool     `json:"cycle,omitempty"`
+}
+
+type particle struct {
+	pos, vel  pixel.Vec
+	age, life float64
+	size      float64
+	color     pixel.RGBA
+}
+
+// particleSystem emits and moves the particles of one emitter, with the cosmetic random numbers
+type particleSystem struct {
+	em     emitter
+	colors []pixel.RGBA
+
+	particles []particle
+	// lowPower emits lowPowerParticles of the particles and doesn't stretch them
+	lowPower bool
+	// owed is the part of a particle the last update didn't emit
+	owed float64
+	next int
+}
+
+func newParticleSystem(em emitter) *particleSystem {
+	ps := &particleSystem{em: em}
+	for _, name := range em.Colors {
+		ps.colors = append(ps.colors, pixel.ToRGBA(namedColor(name)))
+	}
+	if len(ps.colors) == 0 {
+		ps.colors = []pixel.RGBA{pixel.RGB(1, 1, 1)}
+	}
+	return ps
+}
+
+// update ages and moves the particles, the tower scrolls them down like everything else, and
+// emits new ones at at, if emitting
+func (ps *particleSystem) update(dt float64, at pixel.Vec, emitting bool) {
+	alive := ps.particles[:0]
+	for _, p := range ps.particles {
+		p.age += dt
+		if p.age >= p.life {
+			continue
+		}
+		p.vel.Y -= ps.em.Gravity * dt
+		p.pos = p.pos.Add(p.vel.Scaled(dt))
+		p.pos.Y -= dt * tower.Scroll
+		alive = append(alive, p)
+	}
+	ps.particles = alive
+
+	if !emitting {
+		ps.owed = 0
+		return
+	}
+	rate := ps.em.Rate
+	if ps.lowPower {
+		rate *= lowPowerParticles
+	}
+	ps.owed += rate * dt
+	for ; ps.owed >= 1; ps.owed-- {
+		angle := -math.Pi/2 + (rng.Cosmetic.Float64()*2-1)*ps.em.Spread*math.Pi/180
+		c := ps.colors[rng.Cosmetic.Intn(len(ps.colors))]
+		if ps.em.Cycle {
+			c = ps.colors[ps.next%len(ps.colors)]
+			ps.next++
+		}
+		ps.particles = append(ps.particles, particle{
+			pos:   at,
+			vel:   pixel.V(math.Cos(angle), math.Sin(angle)).Scaled(ps.em.Speed * (0.5 + rng.Cosmetic.Float64()/2)),
+			life:  ps.em.Life * (0.75 + rng.Cosmetic.Float64()/2),
+			size:  ps.em.Size,
+			color: c,
+		})
+	}
+}
+
+// slowmoStretch is how many seconds of movement a particle is stretched over in slow motion
+const slowmoStretch = 0.15
+
+// draw adds the particles to imd, fading out over their life, in slow motion they stretch into
+// streaks behind them unless it's the low-power mode
+func (ps *particleSystem) draw(imd *imdraw.IMDraw) {
+	for _, p := range ps.particles {
+		left := 1 - p.age/p.life
+		size := p.size
+		if ps.em.Shrink {
+			size *= left
+		}
+		imd.Color = p.color.Mul(pixel.Alpha(left))
+		if streak := p.vel.Add(pixel.V(0, -tower.Scroll)).Scaled(slowmoStretch * slowmo); !ps.lowPower && streak.Len() > size {
+			imd.Push(p.pos, p.pos.Sub(streak))
+			imd.Line(2 * size)
+			continue
+		}
+		imd.Push(p.pos)
+		imd.Circle(size, 0)
+	}
+}
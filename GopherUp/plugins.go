@@ -0,0 +1,6 @@
+package main
+
+// the packages that subscribe to the GoTower/events from outside the game are linked in here, with
+// a blank import each, like
+//
+//	import _ "example.com/someone/gotower-overlay"
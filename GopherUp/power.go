@@ -0,0 +1,40 @@
+package main
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// the game runs at fullFPS, lowPowerFPS in the low-power mode, where the trails also emit
+// lowPowerParticles of their particles and there's no slow motion treatment
+const (
+	fullFPS           = 120
+	lowPowerFPS       = 30
+	lowPowerParticles = 0.5
+)
+
+// onBattery is whether the computer is running on its battery, false when that can't be told
+func onBattery() bool {
+	switch runtime.GOOS {
+	case "linux":
+		supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+		for _, s := range supplies {
+			kind, _ := ioutil.ReadFile(filepath.Join(s, "type"))
+			status, _ := ioutil.ReadFile(filepath.Join(s, "status"))
+			if strings.TrimSpace(string(kind)) == "Battery" && strings.TrimSpace(string(status)) == "Discharging" {
+				return true
+			}
+		}
+	case "darwin":
+		out, err := exec.Command("pmset", "-g", "batt").Output()
+		return err == nil && strings.Contains(string(out), "'Battery Power'")
+	case "windows":
+		// 1 is discharging, see Win32_Battery
+		out, err := exec.Command("powershell", "-NoProfile", "-Command", "(Get-CimInstance Win32_Battery).BatteryStatus").Output()
+		return err == nil && strings.TrimSpace(string(out)) == "1"
+	}
+	return false
+}
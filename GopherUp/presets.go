@@ -0,0 +1,22 @@
+package main
+
+// controlPreset is a named layout of the binds, on top of the default one: the binds it leaves
+// out keep their keys and buttons
+type controlPreset struct {
+	Name string `json:"name"`
+	// Keys and Buttons are by the binds' names, like "jump", the keys by pixelgl's names, like
+	// "W" or "LeftShift", and the buttons by the Xbox ones, like "A" or "RB"
+	Keys    map[string][]string `json:"keys,omitempty"`
+	Buttons map[string][]string `json:"buttons,omitempty"`
+}
+
+// builtinPresets are the presets every profile has, the first one is the default
+var builtinPresets = []controlPreset{
+	{Name: "Arrows"},
+	{Name: "WASD", Keys: map[string][]string{
+		"up": {"W"}, "down": {"S"}, "left": {"A"}, "right": {"D"}, "jump": {"W"}, "slowmo": {"LeftShift"},
+	}},
+	{Name: "Pad swapped", Buttons: map[string][]string{
+		"jump": {"B"}, "confirm": {"B"}, "back": {"A"}, "skip": {"Y"},
+	}},
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"sort"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/profile"
+	"GoTower/internal/storage"
+
+	"github.com/pkg/errors"
+)
+
+// playerProfile is the name and avatar the player picked, they go on the high scores, the
+// leaderboard and the ghosts in a race
+type playerProfile struct {
+	Version int    `json:"version"`
+	Name    string `json:"name"`
+	// Avatar is from profile.Avatars
+	Avatar int `json:"avatar"`
+	// Trail is the id of the particle trail behind the gopher, none if it's empty
+	Trail string `json:"trail,omitempty"`
+	// Style is how the gopher moves, from profile.Styles, classic if it's empty
+	Style string `json:"style,omitempty"`
+	// Presets are the player's own control presets, next to the built-in ones, and Controls the
+	// name of the one in use, the first built-in one if it's empty
+	Presets  []controlPreset `json:"presets,omitempty"`
+	Controls string          `json:"controls,omitempty"`
+
+	// made is whether the player has set up the profile, it's asked for the first time otherwise
+	made bool
+}
+
+// loadProfile reads the profile, a missing file is one to be set up. A broken one is too, the
+// name is checked like the server will.
+func loadProfile() (*playerProfile, error) {
+	p := &playerProfile{}
+	data, err := readSave(profileFile, storage.Profile)
+	if os.IsNotExist(err) {
+		return p, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the profile")
+	}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, errors.Wrap(err, "error loading the profile")
+	}
+	p.made = profile.CheckName(p.Name) == nil
+	if !p.made {
+		p.Name = ""
+	}
+	p.Avatar = indexOf(p.Avatar, len(profile.Avatars))
+	if profile.CheckStyle(p.Style) != nil {
+		p.Style = ""
+	}
+	return p, nil
+}
+
+// presets are the control presets to pick from, the built-in ones and then the player's, one of
+// the player's with the name of a built-in one takes its place
+func (p *playerProfile) presets() []controlPreset {
+	presets := append([]controlPreset(nil), builtinPresets...)
+	for _, own := range p.Presets {
+		replaced := false
+		for i := range presets {
+			if presets[i].Name == own.Name {
+				presets[i], replaced = own, true
+			}
+		}
+		if !replaced {
+			presets = append(presets, own)
+		}
+	}
+	return presets
+}
+
+// indexOf is i if it's in range of n things, 0 otherwise
+func indexOf(i, n int) int {
+	if i < 0 || i >= n {
+		return 0
+	}
+	return i
+}
+
+func (p *playerProfile) save() error {
+	p.Version = profileFile.Version()
+	data, err := json.MarshalIndent(p, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving the profile")
+	}
+	p.made = true
+	return errors.Wrap(storage.WriteFile(data, 0644, storage.Profile), "error saving the profile")
+}
+
+// shownName is the name the others see, "gopher" until one is picked
+func (p *playerProfile) shownName() string {
+	if p.Name == "" {
+		return "gopher"
+	}
+	return p.Name
+}
+
+// maxTopRuns is how many runs the local high scores keep
+const maxTopRuns = 5
+
+// topRun is a run on the local high scores, with the profile it was played with
+type topRun struct {
+	Name   string `json:"name"`
+	Avatar int    `json:"avatar"`
+	Score  int    `json:"score"`
+	Floor  int    `json:"floor"`
+	Code   string `json:"code"`
+	Style  string `json:"style,omitempty"`
+}
+
+// record puts a finished run on the high scores if it's good enough, practice runs don't count
+func (st *stats) record(rs runSummary, p *playerProfile) {
+	if practicing {
+		return
+	}
+	st.Top = append(st.Top, topRun{
+		Name:   p.shownName(),
+		Avatar: p.Avatar,
+		Score:  rs.Score,
+		Floor:  int(rs.Height / physics.FloorHeight),
+		Code:   rs.Code,
+		Style:  rs.Style,
+	})
+	sort.SliceStable(st.Top, func(i, j int) bool { return st.Top[i].Score > st.Top[j].Score })
+	if len(st.Top) > maxTopRuns {
+		st.Top = st.Top[:maxTopRuns]
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"GoTower/internal/profile"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// profileScreen sets up the player's profile: the name is typed, left and right go through the
+// avatars, up and down through the trails, slowmo through the movement styles and confirm saves
+// it, once the name is a valid one and the trail is unlocked. It comes up by itself on the first
+// start, back keeps the default then. The keys that type are left to the name.
+type profileScreen struct {
+	win     *pixelgl.Window
+	screens *screenStack
+	prof    *playerProfile
+	trails  []*trail
+	st      *stats
+
+	name   string
+	avatar int
+	// trail is the index of the picked trail in trails, -1 for none, style the one in
+	// moveStyles
+	trail int
+	style int
+	err   string
+
+	gopher *pixel.Sprite
+	txt    *text.Text
+}
+
+func newProfileScreen(win *pixelgl.Window, screens *screenStack, prof *playerProfile, gopher *animationSheet, trails []*trail, st *stats) *profileScreen {
+	front := gopher.anims["Front"][0]
+	trail := -1
+	for i, t := range trails {
+		if t.ID == prof.Trail {
+			trail = i
+		}
+	}
+	style := 0
+	for i, ms := range tower.MoveStyles {
+		if ms.Name == prof.Style {
+			style = i
+		}
+	}
+	return &profileScreen{
+		win:     win,
+		screens: screens,
+		prof:    prof,
+		trails:  trails,
+		st:      st,
+		name:    prof.Name,
+		avatar:  prof.Avatar,
+		trail:   trail,
+		style:   style,
+		gopher:  pixel.NewSprite(front.Pic, front.Rect),
+		txt:     text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (ps *profileScreen) update(dt float64) {
+	win := ps.win
+	if ctl.typingJustPressed(bindBack) {
+		ps.screens.pop()
+		return
+	}
+	for _, r := range win.Typed() {
+		if profile.CanType(r) && len(ps.name) < profile.MaxName {
+			ps.name += string(r)
+			ps.err = ""
+		}
+	}
+	if (win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace)) && len(ps.name) > 0 {
+		ps.name = ps.name[:len(ps.name)-1]
+		ps.err = ""
+	}
+	n := len(profile.Avatars)
+	if ctl.typingJustPressed(bindLeft) {
+		ps.avatar = (ps.avatar + n - 1) % n
+	}
+	if ctl.typingJustPressed(bindRight) {
+		ps.avatar = (ps.avatar + 1) % n
+	}
+	// none and then the trails
+	if ctl.typingJustPressed(bindUp) {
+		ps.trail--
+		if ps.trail < -1 {
+			ps.trail = len(ps.trails) - 1
+		}
+		ps.err = ""
+	}
+	if ctl.typingJustPressed(bindDown) {
+		ps.trail++
+		if ps.trail >= len(ps.trails) {
+			ps.trail = -1
+		}
+		ps.err = ""
+	}
+	if ctl.typingJustPressed(bindSlowmo) {
+		ps.style = (ps.style + 1) % len(tower.MoveStyles)
+	}
+	if !ctl.typingJustPressed(bindConfirm) {
+		return
+	}
+	if err := profile.CheckName(ps.name); err != nil {
+		ps.err = err.Error()
+		return
+	}
+	trail := ""
+	if ps.trail >= 0 {
+		t := ps.trails[ps.trail]
+		if !t.Unlock.met(ps.st) {
+			ps.err = t.Name + " is locked"
+			return
+		}
+		trail = t.ID
+	}
+	ps.prof.Name, ps.prof.Avatar, ps.prof.Trail = ps.name, ps.avatar, trail
+	ps.prof.Style = tower.MoveStyles[ps.style].Name
+	if err := ps.prof.save(); err != nil {
+		ps.err = err.Error()
+		return
+	}
+	ps.screens.pop()
+}
+
+func (ps *profileScreen) draw(canvas *pixelgl.Canvas) {
+	canvas.Clear(colornames.Black)
+	canvas.SetMatrix(interfaceMatrix(canvasBounds))
+
+	avatar := profile.AvatarAt(ps.avatar)
+	ps.gopher.DrawColorMask(canvas, pixel.IM.Scaled(pixel.ZV, 48/ps.gopher.Frame().H()).Moved(pixel.V(0, 20)), avatar.Color)
+
+	ps.txt.Clear()
+	ps.txt.Color = colornames.Gold
+	ps.txt.WriteString("PROFILE")
+	ps.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ps.txt.Bounds().W()/2, 80)))
+
+	ps.txt.Clear()
+	ps.txt.Color = avatar.Color
+	fmt.Fprintf(ps.txt, "< %s >", avatar.Name)
+	ps.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ps.txt.Bounds().W()/2, -20)))
+
+	ps.txt.Clear()
+	ps.txt.Color = colornames.White
+	ps.txt.WriteString("NAME " + ps.name)
+	if time.Now().UnixNano()/int64(time.Second/2)%2 == 0 {
+		ps.txt.WriteString("_")
+	}
+	ps.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-70, -44)))
+
+	ps.txt.Clear()
+	ps.txt.Color = colornames.Lightgrey
+	if ps.trail < 0 {
+		ps.txt.WriteString("TRAIL none")
+	} else if t := ps.trails[ps.trail]; t.Unlock.met(ps.st) {
+		ps.txt.WriteString("TRAIL " + t.Name)
+	} else {
+		ps.txt.Color = colornames.Dimgray
+		fmt.Fprintf(ps.txt, "TRAIL %s, locked: %s", t.Name, t.Unlock)
+	}
+	ps.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(pixel.V(-70, -58)))
+
+	ps.txt.Clear()
+	ps.txt.Color = colornames.Lightgrey
+	ps.txt.WriteString("STYLE " + tower.MoveStyles[ps.style].Name)
+	ps.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(pixel.V(-70, -68)))
+
+	ps.txt.Clear()
+	ps.txt.Color = colornames.Dimgray
+	ps.txt.WriteString(ctl.prompt("type a name, {left}/{right} for the color\n{up}/{down} for the trail, {slowmo} for the style\n{confirm} to save, {back} to leave it"))
+	if ps.err != "" {
+		ps.txt.Color = colornames.Red
+		ps.txt.WriteString("\n" + ps.err)
+	}
+	ps.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ps.txt.Bounds().W()/2, -84)))
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"os/user"
+	"strings"
+	"sync"
+	"time"
+
+	"GoTower/internal/lobby"
+
+	"github.com/pkg/errors"
+)
+
+// raceInterval is how often a race posts its ghost and gets the others'
+const raceInterval = 100 * time.Millisecond
+
+// raceClient makes and joins the ghost race rooms on the server
+type raceClient struct {
+	url    string
+	client *http.Client
+	prof   *playerProfile
+}
+
+func newRaceClient(url string, prof *playerProfile) *raceClient {
+	return &raceClient{url: strings.TrimSuffix(url, "/"), client: &http.Client{Timeout: 5 * time.Second}, prof: prof}
+}
+
+// racerName is what the other racers see, the profile's name, or the user's login name until
+// there is one
+func (rc *raceClient) racerName() string {
+	if rc.prof.made {
+		return rc.prof.Name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		// just the name on Windows, without the domain
+		name := u.Username[strings.LastIndex(u.Username, `\`)+1:]
+		if len(name) > lobby.MaxName {
+			name = name[:lobby.MaxName]
+		}
+		return name
+	}
+	return "gopher"
+}
+
+// create makes a room on the tower with the mutators and joins it
+func (rc *raceClient) create(seed int64, mutators []string) (*race, error) {
+	var j lobby.Joined
+	sent := time.Now()
+	err := doJSON(rc.client, http.MethodPost, rc.url, lobby.Create{Name: rc.racerName(), Avatar: rc.prof.Avatar, Seed: seed, Mutators: mutators}, &j)
+	if err != nil {
+		return nil, errors.Wrap(err, "error making the room")
+	}
+	return rc.start(j, sent), nil
+}
+
+// join joins the room with the code
+func (rc *raceClient) join(code string) (*race, error) {
+	var j lobby.Joined
+	sent := time.Now()
+	err := doJSON(rc.client, http.MethodPost, rc.url+"/"+url.PathEscape(strings.ToUpper(code))+"/join", lobby.Join{Name: rc.racerName(), Avatar: rc.prof.Avatar}, &j)
+	if err != nil {
+		return nil, errors.Wrap(err, "error joining the room")
+	}
+	return rc.start(j, sent), nil
+}
+
+func (rc *raceClient) start(j lobby.Joined, sent time.Time) *race {
+	r := &race{rc: rc, id: j.ID, token: j.Token, room: j.Room, stop: make(chan struct{})}
+	r.sync(j.Room.Now, sent)
+	go r.poll()
+	return r
+}
+
+// race is a room the game is in, it posts to the server every raceInterval in the background:
+// whether the player is ready and where their ghost is, and keeps the room it gets back
+type race struct {
+	rc    *raceClient
+	id    int
+	token string
+	stop  chan struct{}
+
+	mu   sync.Mutex
+	room lobby.Room
+	// offset is how far ahead the server's clock is
+	offset time.Duration
+	ready  bool
+	ghost  *lobby.Ghost
+	err    error
+
+	// emote is waiting to be said with the next update, said is when the last one was
+	emote int
+	said  time.Time
+}
+
+// sync works out the offset from the server's clock, taking it was read halfway through the
+// request, the lock has to be held
+func (r *race) sync(serverNow, sent time.Time) {
+	received := time.Now()
+	r.offset = serverNow.Sub(sent.Add(received.Sub(sent) / 2))
+}
+
+func (r *race) poll() {
+	tick := time.NewTicker(raceInterval)
+	defer tick.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-tick.C:
+		}
+		r.mu.Lock()
+		u := lobby.Update{Token: r.token, Ready: r.ready, Ghost: r.ghost, Emote: r.emote}
+		r.mu.Unlock()
+
+		var room lobby.Room
+		sent := time.Now()
+		err := doJSON(r.rc.client, http.MethodPost, r.rc.url+"/"+url.PathEscape(r.room.Code)+"/update", u, &room)
+		r.mu.Lock()
+		r.err = errors.Wrap(err, "error updating the room")
+		if err == nil {
+			r.room = room
+			r.sync(room.Now, sent)
+			if r.emote == u.Emote {
+				r.emote = 0
+			}
+		}
+		r.mu.Unlock()
+	}
+}
+
+// state is the room as of the last answer, the time left until the race starts (negative once
+// it has, zero while it isn't planned yet) and the last error, if the last update failed
+func (r *race) state() (room lobby.Room, left time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.room.StartAt.IsZero() {
+		left = r.room.StartAt.Sub(time.Now().Add(r.offset))
+		if left == 0 {
+			left = -1
+		}
+	}
+	return r.room, left, r.err
+}
+
+func (r *race) setReady() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = true
+}
+
+// setGhost is where the player's gopher is, it's posted with the next update
+func (r *race) setGhost(g lobby.Ghost) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ghost = &g
+}
+
+// say says QuickChat[i] to the room, unless something was said less than EmoteGap ago
+func (r *race) say(i int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.said) < lobby.EmoteGap {
+		return
+	}
+	r.emote, r.said = i+1, time.Now()
+}
+
+// saying is what the player is saying right now, if anything
+func (r *race) saying(p lobby.Player) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if p.Emote == 0 || p.Emote > len(lobby.QuickChat) || time.Now().Add(r.offset).Sub(p.EmoteAt) > lobby.EmoteShown {
+		return ""
+	}
+	return lobby.QuickChat[p.Emote-1]
+}
+
+// leave stops posting, the server forgets the room once nobody posts to it
+func (r *race) leave() {
+	close(r.stop)
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+
+	"GoTower/internal/profile"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// raceScreen is the lobby of a ghost race. Confirm makes a room, on a new tower with the mutators
+// picked on the title screen, or joins the one whose code is typed. In the room confirm says the
+// player is ready, and once everyone is the countdown starts and then the race.
+type raceScreen struct {
+	win     *pixelgl.Window
+	screens *screenStack
+	rc      *raceClient
+	picked  []*tower.Mutator
+	start   func(r *race)
+
+	code string
+	txt  *text.Text
+
+	mu     sync.Mutex
+	race   *race
+	status string
+	busy   bool
+}
+
+func newRaceScreen(win *pixelgl.Window, screens *screenStack, rc *raceClient, picked []*tower.Mutator, start func(r *race)) *raceScreen {
+	return &raceScreen{win: win, screens: screens, rc: rc, picked: picked, start: start, txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// enter makes or joins the room in the background
+func (rs *raceScreen) enter() {
+	code := rs.code
+	rs.busy, rs.status = true, "joining..."
+	if code == "" {
+		rs.status = "making a room..."
+	}
+	go func() {
+		var r *race
+		var err error
+		if code == "" {
+			r, err = rs.rc.create(newTowerCode().seed, tower.MutatorIDs(rs.picked))
+		} else {
+			r, err = rs.rc.join(code)
+		}
+		rs.mu.Lock()
+		defer rs.mu.Unlock()
+		rs.busy = false
+		if err != nil {
+			rs.status = err.Error()
+			return
+		}
+		rs.race, rs.status = r, ""
+	}()
+}
+
+func (rs *raceScreen) update(dt float64) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	win := rs.win
+	if ctl.typingJustPressed(bindBack) {
+		if rs.race != nil {
+			rs.race.leave()
+		}
+		rs.screens.pop()
+		return
+	}
+
+	if rs.race != nil {
+		if _, left, _ := rs.race.state(); left < 0 {
+			rs.screens.pop()
+			rs.start(rs.race)
+			return
+		}
+		if ctl.justPressed(bindConfirm) {
+			rs.race.setReady()
+		}
+		return
+	}
+
+	if rs.busy {
+		return
+	}
+	for _, r := range strings.ToUpper(string(win.Typed())) {
+		if r >= 'A' && r <= 'Z' && len(rs.code) < 6 {
+			rs.code += string(r)
+			rs.status = ""
+		}
+	}
+	if (win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace)) && len(rs.code) > 0 {
+		rs.code = rs.code[:len(rs.code)-1]
+	}
+	if ctl.typingJustPressed(bindConfirm) {
+		rs.enter()
+	}
+}
+
+func (rs *raceScreen) draw(canvas *pixelgl.Canvas) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+
+	rs.txt.Clear()
+	rs.txt.Color = colornames.Gold
+	rs.txt.WriteString("GHOST RACE\n\n")
+	rs.txt.Color = colornames.Lightgrey
+	if rs.race == nil {
+		if rs.code == "" {
+			rs.txt.WriteString(ctl.prompt("{confirm} to make a room\nor type a room code to join one\n\n"))
+		} else {
+			fmt.Fprintf(rs.txt, ctl.prompt("{confirm} to join %s\n\n"), rs.code)
+		}
+	} else {
+		room, left, err := rs.race.state()
+		fmt.Fprintf(rs.txt, "ROOM %s, tower %s\n", room.Code, runCode{seed: room.Seed})
+		if len(room.Mutators) > 0 {
+			fmt.Fprintf(rs.txt, "with %s\n", strings.Join(room.Mutators, ", "))
+		}
+		rs.txt.WriteString("\n")
+		for _, p := range room.Players {
+			rs.txt.Color = colornames.Dimgray
+			if p.Ready {
+				rs.txt.Color = colornames.Lightgreen
+			}
+			fmt.Fprintf(rs.txt, "%-12s %s\n", p.Name, map[bool]string{true: "ready", false: "..."}[p.Ready])
+		}
+		rs.txt.Color = colornames.Lightgrey
+		switch {
+		case left > 0:
+			fmt.Fprintf(rs.txt, "\nstarting in %d", int(math.Ceil(left.Seconds())))
+		case len(room.Players) < 2:
+			rs.txt.WriteString("\nwaiting for someone to join")
+		default:
+			rs.txt.WriteString(ctl.prompt("\n{confirm} when you're ready"))
+		}
+		if err != nil {
+			rs.txt.Color = colornames.Red
+			rs.txt.WriteString("\n" + err.Error())
+		}
+		rs.txt.WriteString("\n")
+	}
+	if rs.status != "" {
+		rs.txt.Color = colornames.Red
+		rs.txt.WriteString(rs.status + "\n")
+	}
+	rs.txt.Color = colornames.Dimgray
+	rs.txt.WriteString(ctl.prompt("{back} to leave"))
+	at := pixel.V(-rs.txt.Bounds().W()/2, 100)
+	canvas.SetMatrix(interfaceMatrix(rs.txt.Bounds().Moved(at)))
+	rs.txt.Draw(canvas, pixel.IM.Moved(at))
+}
+
+// ghostAlpha is how see-through the other racers are
+const ghostAlpha = 0.4
+
+// drawGhosts draws the other racers where they were last, with their names and their floors, and
+// what everyone is saying in bubbles
+func (gs *gameScreen) drawGhosts(canvas *pixelgl.Canvas) {
+	room, _, _ := gs.race.state()
+	if gs.ghosts == nil {
+		gs.ghosts = pixel.NewSprite(nil, pixel.Rect{})
+		gs.names = text.New(pixel.ZV, text.Atlas7x13)
+		gs.bubble = imdraw.New(nil)
+	}
+	gs.ghosts.Set(gs.anim.Frame.Pic, gs.anim.Frame.Rect)
+	gs.names.Clear()
+	gs.bubble.Clear()
+	for _, p := range room.Players {
+		pos := gs.Phys.Rect.Center()
+		if p.ID != gs.race.id {
+			if p.Ghost.Tick == 0 {
+				continue
+			}
+			pos = pixel.V(p.Ghost.X, p.Ghost.Height-tower.Climbed-120+gs.Phys.Rect.H()/2)
+			gs.ghosts.DrawColorMask(canvas, pixel.IM.
+				ScaledXY(pixel.ZV, pixel.V(
+					gs.Phys.Rect.W()/gs.ghosts.Frame().W(),
+					gs.Phys.Rect.H()/gs.ghosts.Frame().H(),
+				)).
+				Moved(pos),
+				pixel.ToRGBA(profile.AvatarAt(p.Avatar).Color).Mul(pixel.Alpha(ghostAlpha)),
+			)
+			gs.names.Dot = pos.Add(pixel.V(-gs.names.BoundsOf(p.Name).W()/2, gs.Phys.Rect.H()/2+2))
+			gs.names.Color = pixel.Alpha(0.6)
+			fmt.Fprintf(gs.names, "%s %d\n", p.Name, p.Ghost.Floor)
+		}
+
+		if said := gs.race.saying(p); said != "" {
+			size := gs.names.BoundsOf(said).Size()
+			at := pos.Add(pixel.V(-size.X/2, gs.Phys.Rect.H()/2+16))
+			gs.bubble.Color = pixel.Alpha(0.85)
+			gs.bubble.Push(at.Sub(pixel.V(3, 3)), at.Add(size).Add(pixel.V(3, 1)))
+			gs.bubble.Rectangle(0)
+			gs.bubble.Push(at.Add(pixel.V(size.X/2-3, -3)), at.Add(pixel.V(size.X/2+3, -3)), at.Add(pixel.V(size.X/2, -8)))
+			gs.bubble.Polygon(0)
+			gs.names.Dot = at.Add(pixel.V(0, size.Y-gs.names.Atlas().Ascent()))
+			gs.names.Color = colornames.Black
+			gs.names.WriteString(said + "\n")
+		}
+	}
+	gs.bubble.Draw(canvas)
+	gs.names.Draw(canvas, pixel.IM)
+}
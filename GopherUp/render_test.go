@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/render"
+	"GoTower/internal/rng"
+	"GoTower/internal/sprite"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden images instead of comparing against them")
+
+// checkGolden compares img against testdata/golden/name.png. Small differences are fine, like
+// rounding in a different rasterizer: a channel may be off by tolerance, and only a few pixels
+// by more than that. With -update the golden image is rewritten instead.
+func checkGolden(t *testing.T, name string, img *image.RGBA) {
+	t.Helper()
+	const (
+		tolerance = 8
+		maxBad    = 0.001
+	)
+	path := filepath.Join("testdata", "golden", name+".png")
+	if *update {
+		if err := writePNG(path, img); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	defer f.Close()
+	want, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("error decoding %s: %v", path, err)
+	}
+	if want.Bounds() != img.Bounds() {
+		t.Fatalf("%s: got a %v image, want %v", name, img.Bounds(), want.Bounds())
+	}
+
+	bad := 0
+	for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+		for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+			got := img.RGBAAt(x, y)
+			w := color.RGBAModel.Convert(want.At(x, y)).(color.RGBA)
+			if diff(got.R, w.R) > tolerance || diff(got.G, w.G) > tolerance ||
+				diff(got.B, w.B) > tolerance || diff(got.A, w.A) > tolerance {
+				bad++
+			}
+		}
+	}
+	if float64(bad) > maxBad*float64(img.Rect.Dx()*img.Rect.Dy()) {
+		out := filepath.Join(os.TempDir(), "gotower-"+name+".png")
+		if err := writePNG(out, img); err != nil {
+			t.Log(err)
+		}
+		t.Errorf("%s: %d pixels differ from the golden image, got %s", name, bad, out)
+	}
+}
+
+func diff(a, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+	return int(b - a)
+}
+
+func writePNG(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func TestGoldenPlatforms(t *testing.T) {
+	rng.Cosmetic.Seed(1)
+	platforms := []*entity.Platform{
+		{Rect: pixel.R(-150, -100, -60, -98)},
+		{Rect: pixel.R(-20, -80, 100, -78), Mat: tower.MaterialByName("ice")},
+		{Rect: pixel.R(40, -40, 120, -38), Slope: 12},
+		{Rect: pixel.R(-120, -30, -40, -28), Mat: tower.MaterialByName("rubber"), Slope: -10},
+		{Rect: pixel.R(-60, 10, 40, 12), Mat: tower.MaterialByName("mud")},
+		{Rect: pixel.R(60, 30, 150, 32)},
+		{Rect: pixel.R(-140, 60, -40, 62)},
+	}
+	platforms[5].Hazard = &entity.Hazard{Kind: entity.Saw, Offset: 30, Radius: 5, Spin: 0.3}
+	platforms[6].Hazard = &entity.Hazard{Kind: entity.Spikes, Offset: 40, Width: 20, Radius: 5}
+	for _, p := range platforms {
+		p.Color = tower.RandomNiceColor()
+	}
+
+	imd := imdraw.New(nil)
+	imd.Precision = 32
+	for _, p := range platforms {
+		p.Draw(imd)
+	}
+	for _, p := range platforms {
+		p.DrawHazard(imd)
+	}
+	st := newSoftTarget(pixel.R(-160, -120, 160, 120), 1)
+	imd.Draw(st)
+	checkGolden(t, "platforms", st.img)
+}
+
+func TestGoldenGoal(t *testing.T) {
+	rng.Cosmetic.Seed(1)
+	gol := &entity.Goal{Radius: 5, Step: 1.0 / 7}
+	// fill up all the rings
+	for i := 0; i < len(gol.Cols); i++ {
+		gol.Update(gol.Step+0.001, 0)
+	}
+	gol.Pos = pixel.ZV
+
+	imd := imdraw.New(nil)
+	imd.Precision = 32
+	gol.Draw(imd)
+	st := newSoftTarget(pixel.R(-8, -8, 8, 8), 4)
+	imd.Draw(st)
+	checkGolden(t, "goal", st.img)
+}
+
+func TestGoldenAnimationFrames(t *testing.T) {
+	_, anims, err := sprite.LoadSheet("sheet.png", "sheet.csv", 12, render.GopherAnimations...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phys := &physics.Body{Rect: pixel.R(-6, -7, 6, 7), Normal: pixel.V(0, 1)}
+	for _, name := range render.GopherAnimations {
+		for i, frame := range anims[name] {
+			ga := &render.GopherAnim{Frame: frame, Facing: +1}
+			st := newSoftTarget(pixel.R(-8, -8, 8, 8), 4)
+			ga.Draw(st, phys)
+			checkGolden(t, fmt.Sprintf("anim-%s-%d", name, i), st.img)
+		}
+	}
+}
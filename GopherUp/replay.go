@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/tower"
+
+	"github.com/pkg/errors"
+)
+
+// replay is all it takes to play a run again: the seed of the tower and the input of every step,
+// the steps are all step seconds long
+type replay struct {
+	Version int     `json:"version"`
+	Seed    int64   `json:"seed"`
+	Step    float64 `json:"step"`
+	// Style is the gopher's movement style, classic when it's empty, Assist and Adaptive are
+	// whether the timing assist and the adaptive difficulty were on
+	Style    string `json:"style,omitempty"`
+	Assist   bool   `json:"assist,omitempty"`
+	Adaptive bool   `json:"adaptive,omitempty"`
+	// Countdown is whether the runs start with the countdown, see setCountdown
+	Countdown bool `json:"countdown,omitempty"`
+	// Picks are the ids of the pool the milestones offer their picks from, in its order, the
+	// offers are drawn from it
+	Picks []string `json:"picks,omitempty"`
+	// Inputs has the actions of every step's command
+	Inputs []byte `json:"inputs"`
+}
+
+// command is the input of step i
+func (r *replay) command(i int) tower.Command {
+	return tower.Command{Tick: uint32(i), Actions: r.Inputs[i]}
+}
+
+func loadReplay(path string) (*replay, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading replay")
+	}
+	// replays are shared around, an old one is only upgraded in memory
+	if data, _, err = replayFile.Upgrade(data); err != nil {
+		return nil, errors.Wrapf(err, "error loading replay from %s", path)
+	}
+	r := &replay{}
+	if err := json.Unmarshal(data, r); err != nil {
+		return nil, errors.Wrapf(err, "error loading replay from %s", path)
+	}
+	if r.Step <= 0 {
+		return nil, errors.Errorf("error loading replay from %s: no step length", path)
+	}
+	return r, nil
+}
+
+func (r *replay) save(path string) error {
+	r.Version = replayFile.Version()
+	data, err := json.Marshal(r)
+	if err != nil {
+		return errors.Wrap(err, "error saving replay")
+	}
+	return errors.Wrap(ioutil.WriteFile(path, data, 0644), "error saving replay")
+}
+
+// play runs the replay on a fresh sim, calling step after every step, picks are all the picks
+// there are, the replay's pool is taken from them
+func (r *replay) play(chunks []*tower.Chunk, picks []*tower.Mutator, step func(i int, s *tower.Sim)) {
+	tower.ResetWorld(r.Seed)
+	s := tower.NewSim(chunks)
+	s.Goals.SetPalette(entity.GoalPalette(r.Seed))
+	s.Pool = tower.MutatorsByID(picks, r.Picks)
+	s.Restyle(r.Style)
+	s.SetAssist(r.Assist)
+	s.SetAdaptive(r.Adaptive)
+	s.SetCountdown(r.Countdown)
+	for i := range r.Inputs {
+		s.Update(r.Step, r.command(i))
+		step(i, s)
+	}
+}
+
+// recordBotReplay lets the bot play for duration seconds with the picks on offer at the
+// milestones and records it as a replay
+func recordBotReplay(chunks []*tower.Chunk, picks []*tower.Mutator, seed int64, duration float64) *replay {
+	r := &replay{Seed: seed, Step: tower.SimStep, Picks: tower.MutatorIDs(picks)}
+	tower.ResetWorld(seed)
+	s := tower.NewSim(chunks)
+	s.Goals.SetPalette(entity.GoalPalette(seed))
+	s.Pool = picks
+	b := &tower.Bot{}
+	for t := 0.0; t < duration; t += r.Step {
+		cmd := b.Control(s)
+		r.Inputs = append(r.Inputs, cmd.Actions)
+		s.Update(r.Step, cmd)
+	}
+	return r
+}
+
+// hashState adds the whole state of the sim to h, bit for bit
+func hashState(h hash.Hash64, s *tower.Sim) {
+	write := func(fs ...float64) {
+		for _, f := range fs {
+			binary.Write(h, binary.LittleEndian, math.Float64bits(f))
+		}
+	}
+	gp := s.Phys
+	write(gp.Rect.Min.X, gp.Rect.Min.Y, gp.Rect.Max.X, gp.Rect.Max.Y, gp.Vel.X, gp.Vel.Y)
+	write(float64(gp.GroundID), float64(gp.Floor), gp.Ledge, gp.Queued, gp.Stamina.Left)
+	for _, p := range s.Platforms.All() {
+		write(float64(p.ID), p.Rect.Min.X, p.Rect.Min.Y, p.Rect.Max.X, p.Rect.Max.Y, p.Slope, p.Crumble)
+		if p.Hazard != nil {
+			write(p.Hazard.Offset, p.Hazard.Speed)
+		}
+	}
+	for _, g := range s.Goals.All() {
+		write(g.Pos.X, g.Pos.Y, g.Vel.X, g.Vel.Y, float64(g.Value))
+	}
+	write(s.Boss.Pos.X, s.Boss.Pos.Y, s.Boss.Timer, float64(s.Boss.State))
+	write(tower.Climbed, tower.Scroll, float64(tower.Score), s.Elapsed)
+	write(float64(s.Prestige.Tier), float64(s.Prestige.Since), s.Prestige.Rewind)
+	write(s.Adapt.Level, s.Hold)
+}
+
+// verifyReplay plays the replay and writes the hash of the state after all the steps to w, and
+// every checkpoint steps too if checkpoint isn't zero. Builds that write the same hashes
+// simulate bit for bit the same.
+func verifyReplay(w io.Writer, r *replay, chunks []*tower.Chunk, picks []*tower.Mutator, checkpoint int) {
+	h := fnv.New64a()
+	r.play(chunks, picks, func(i int, s *tower.Sim) {
+		hashState(h, s)
+		if checkpoint > 0 && (i+1)%checkpoint == 0 {
+			fmt.Fprintf(w, "step %d: %016x\n", i+1, h.Sum64())
+		}
+	})
+	fmt.Fprintf(w, "%016x\n", h.Sum64())
+}
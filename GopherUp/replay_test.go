@@ -0,0 +1,88 @@
+package main
+
+import (
+	"bytes"
+	"hash/fnv"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"GoTower/internal/tower"
+)
+
+// TestCannedReplay checks that the simulation still plays the canned replay exactly like it did
+// when the hash was recorded, on every platform the tests run on
+func TestCannedReplay(t *testing.T) {
+	chunks, err := tower.LoadChunks("chunks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReplay(filepath.Join("testdata", "canned.replay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	picks, err := tower.LoadMutators("picks.json", tower.MaxPicks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got bytes.Buffer
+	verifyReplay(&got, r, chunks, picks, 0)
+
+	path := filepath.Join("testdata", "canned.hash")
+	if *update {
+		if err := ioutil.WriteFile(path, got.Bytes(), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(got.String()) != strings.TrimSpace(string(want)) {
+		t.Errorf("canned replay hash is %s, want %s", strings.TrimSpace(got.String()), strings.TrimSpace(string(want)))
+	}
+}
+
+// TestSnapshotBytes checks that a sim restored from Snapshot's bytes plays on exactly like the one
+// it was taken from
+func TestSnapshotBytes(t *testing.T) {
+	chunks, err := tower.LoadChunks("chunks.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := loadReplay(filepath.Join("testdata", "canned.replay"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	half := len(r.Inputs) / 2
+
+	tower.ResetWorld(r.Seed)
+	s := tower.NewSim(chunks)
+	for i := 0; i < half; i++ {
+		s.Update(r.Step, r.command(i))
+	}
+	data, err := s.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	play := func(s *tower.Sim) uint64 {
+		h := fnv.New64a()
+		for i := half; i < len(r.Inputs); i++ {
+			s.Update(r.Step, r.command(i))
+			hashState(h, s)
+		}
+		return h.Sum64()
+	}
+	want := play(s)
+
+	tower.ResetWorld(r.Seed)
+	restored := tower.NewSim(chunks)
+	if err := restored.Restore(data); err != nil {
+		t.Fatal(err)
+	}
+	if got := play(restored); got != want {
+		t.Errorf("restored sim hashes to %016x, want %016x", got, want)
+	}
+}
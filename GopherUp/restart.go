@@ -0,0 +1,60 @@
+package main
+
+import (
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// restartHold is how long R has to be held to restart the run, so a stray press doesn't throw it
+// away
+const restartHold = 0.6
+
+// restartKey is the quick restart, held down it fills a bar and restarts the run once it's full
+type restartKey struct {
+	held float64
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newRestartKey() *restartKey {
+	return &restartKey{imd: imdraw.New(nil), txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// update is whether the key has been held long enough, letting go of it starts over
+func (rk *restartKey) update(dt float64, pressed bool) bool {
+	if !pressed {
+		rk.held = 0
+		return false
+	}
+	rk.held += dt
+	if rk.held < restartHold {
+		return false
+	}
+	rk.held = 0
+	return true
+}
+
+// draw shows how far the key's been held, at the top of bounds
+func (rk *restartKey) draw(t pixel.Target, bounds pixel.Rect) {
+	if rk.held <= 0 {
+		return
+	}
+	rk.txt.Clear()
+	rk.txt.Color = colornames.White
+	rk.txt.WriteString("restarting")
+	at := pixel.V(bounds.Center().X, bounds.Max.Y-24)
+	rk.txt.Draw(t, pixel.IM.Moved(at.Sub(rk.txt.Bounds().Center())))
+
+	bar := pixel.R(-30, -16, 30, -12).Moved(at)
+	rk.imd.Clear()
+	rk.imd.Color = pixel.RGBA{A: 0.6}
+	rk.imd.Push(bar.Min, bar.Max)
+	rk.imd.Rectangle(0)
+	rk.imd.Color = colornames.White
+	rk.imd.Push(bar.Min, pixel.V(bar.Min.X+bar.W()*rk.held/restartHold, bar.Max.Y))
+	rk.imd.Rectangle(0)
+	rk.imd.Draw(t)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"time"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+
+	"github.com/pkg/errors"
+)
+
+// runSummary is written at the end of every run when the run log is on, for players and tools
+// that want to look at how they do over time
+type runSummary struct {
+	Seed     int64     `json:"seed"`
+	Code     string    `json:"code"`
+	Phrase   string    `json:"seedPhrase,omitempty"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"duration"`
+	Score    int       `json:"score"`
+	Floors   int       `json:"floors"`
+	Height   float64   `json:"height"`
+	Bosses   int       `json:"bossesSurvived"`
+	Cause    string    `json:"deathCause"`
+	// Prestige is the prestige tier the run looped up to
+	Prestige int `json:"prestige,omitempty"`
+	// Mutators are the ids of the mutators the run was played with, a modified run has some
+	Mutators []string `json:"mutators,omitempty"`
+	// Style is the gopher's movement style, see moveStyle, Assist and Adaptive are whether the
+	// timing assist and the adaptive difficulty were on
+	Style    string `json:"style"`
+	Assist   bool   `json:"assist,omitempty"`
+	Adaptive bool   `json:"adaptive,omitempty"`
+	// Level is the id of the community level the run was played on, if it was
+	Level string `json:"level,omitempty"`
+	// PowerUps are the ids of the picks the run took at the milestones, in order
+	PowerUps []string `json:"powerUps,omitempty"`
+	// Challenge is the daily or weekly challenge the run was, if it was
+	Challenge string `json:"challenge,omitempty"`
+	// InputHash is a hash of every step's command, its tick and all of its actions, the picks
+	// and the skip too, so two runs with the same seed and hash played out the same
+	InputHash string `json:"inputHash"`
+}
+
+// runLog follows the current run and writes its summary when the gopher dies, if save is set
+type runLog struct {
+	save     bool
+	code     runCode
+	mutators []string
+	level    string
+	style    string
+	assist   bool
+	adaptive bool
+	// finished get every run's summary
+	finished []func(rs runSummary)
+
+	cur   runSummary
+	score int
+	input hash.Hash64
+}
+
+func newRunLog(save bool, code runCode, mutators []string, level, style string, assist, adaptive bool) *runLog {
+	rl := &runLog{save: save, code: code, mutators: mutators, level: level, style: style, assist: assist, adaptive: adaptive}
+	rl.start()
+	return rl
+}
+
+func (rl *runLog) start() {
+	rl.cur = runSummary{Seed: rl.code.seed, Code: rl.code.String(), Phrase: rl.code.phrase, Challenge: rl.code.challenge, Started: time.Now(), Mutators: rl.mutators, Level: rl.level, Style: rl.style, Assist: rl.assist, Adaptive: rl.adaptive}
+	rl.score = tower.Score
+	rl.input = fnv.New64a()
+}
+
+// update is called every step with the command the gopher got
+func (rl *runLog) update(dt float64, cmd tower.Command) {
+	rl.cur.Duration += dt
+	binary.Write(rl.input, binary.LittleEndian, cmd.Tick)
+	rl.input.Write([]byte{cmd.Actions})
+}
+
+func (rl *runLog) onEvent(e tower.Event) {
+	switch e := e.(type) {
+	case physics.FloorReached:
+		if e.Floor > rl.cur.Floors {
+			rl.cur.Floors = e.Floor
+		}
+		if e.Height > rl.cur.Height {
+			rl.cur.Height = e.Height
+		}
+	case tower.BossSurvived:
+		rl.cur.Bosses++
+	case tower.PrestigeReached:
+		rl.cur.Prestige = e.Tier
+	case tower.PickTaken:
+		rl.cur.PowerUps = append(rl.cur.PowerUps, e.ID)
+	case physics.Died:
+		rl.cur.Cause = e.Cause
+		rl.cur.Score = tower.Score - rl.score
+		rl.cur.InputHash = fmt.Sprintf("%016x", rl.input.Sum64())
+		// practice runs don't count
+		if rl.save && !practicing {
+			if err := rl.cur.save(); err != nil {
+				fmt.Println(err)
+			}
+		}
+		if !practicing {
+			for _, f := range rl.finished {
+				f(rl.cur)
+			}
+		}
+		rl.start()
+	}
+}
+
+func (rs *runSummary) save() error {
+	data, err := json.MarshalIndent(rs, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving run")
+	}
+	name := rs.Started.Format("2006-01-02T15-04-05.000") + ".json"
+	return errors.Wrap(storage.WriteFile(data, 0644, storage.Runs, name), "error saving run")
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"GoTower/internal/savefile"
+	"GoTower/internal/storage"
+)
+
+// the versions of the save files, see savefile. When a format changes, a migration for it goes
+// on the end of its list.
+var (
+	settingsFile = savefile.NewKind("settings", savefile.Baseline)
+	statsFile    = savefile.NewKind("stats", savefile.Baseline)
+	replayFile   = savefile.NewKind("replay", savefile.Baseline)
+
+	challengeLogFile = savefile.NewKind("challenge log", savefile.Baseline)
+	profileFile      = savefile.NewKind("profile", savefile.Baseline)
+	outboxFile       = savefile.NewKind("outbox", savefile.Baseline)
+)
+
+// readSave reads a file from the data directory at the current version of its kind. An old file
+// is upgraded and written back, the old one is kept next to it as a .bak in case the upgrade
+// went wrong.
+func readSave(kind *savefile.Kind, name string) ([]byte, error) {
+	data, err := storage.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	current, upgraded, err := kind.Upgrade(data)
+	if err != nil || !upgraded {
+		return current, err
+	}
+	if err := storage.WriteFile(data, 0644, name+".bak"); err != nil {
+		return nil, err
+	}
+	return current, storage.WriteFile(current, 0644, name)
+}
@@ -0,0 +1,675 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// screen is one layer of the game, like the tower or a menu on top of it
+type screen interface {
+	update(dt float64)
+	draw(canvas *pixelgl.Canvas)
+}
+
+// screenStack holds the screens on top of each other, only the top one gets updated, but the
+// bottom one is drawn too, so the tower stays visible (frozen) underneath whichever menu is on top
+type screenStack struct {
+	screens []screen
+}
+
+func (ss *screenStack) push(s screen) {
+	ss.screens = append(ss.screens, s)
+}
+
+func (ss *screenStack) pop() {
+	if len(ss.screens) > 0 {
+		ss.screens = ss.screens[:len(ss.screens)-1]
+	}
+}
+
+func (ss *screenStack) top() screen {
+	if len(ss.screens) == 0 {
+		return nil
+	}
+	return ss.screens[len(ss.screens)-1]
+}
+
+func (ss *screenStack) update(dt float64) {
+	if top := ss.top(); top != nil {
+		top.update(dt)
+	}
+}
+
+func (ss *screenStack) draw(canvas *pixelgl.Canvas) {
+	if len(ss.screens) == 0 {
+		return
+	}
+	ss.screens[0].draw(canvas)
+	if len(ss.screens) > 1 {
+		ss.top().draw(canvas)
+	}
+}
+
+// canvasView is the matrix the canvas is drawn to the window with, set every frame
+var canvasView = pixel.IM
+
+// canvasZoom is how many canvas pixels a unit of the world takes, from the resolution setting
+var canvasZoom = 1.0
+
+// interfaceScale is how much bigger than usual the menus and the HUD are drawn, from the UI scale
+// setting, it leaves the tower alone
+var interfaceScale = 1.0
+
+// screenMatrix is the matrix the screens draw on the canvas with, in units of the world, the
+// canvas is canvasBounds big whatever its resolution
+func screenMatrix() pixel.Matrix {
+	return pixel.IM.Scaled(pixel.ZV, canvasZoom)
+}
+
+// interfaceZoom is interfaceScale, only as far as something bounds big still fits on the canvas
+// when it's scaled about the middle of it, something too big for it is shrunk until it does, like
+// the settings menu with all of its items
+func interfaceZoom(bounds pixel.Rect) float64 {
+	fit := math.Min(
+		canvasBounds.Max.X/math.Max(-bounds.Min.X, bounds.Max.X),
+		canvasBounds.Max.Y/math.Max(-bounds.Min.Y, bounds.Max.Y),
+	)
+	return math.Min(interfaceScale, fit)
+}
+
+// interfaceMatrix is screenMatrix for the screens laid out in layout, scaled by interfaceZoom
+func interfaceMatrix(layout pixel.Rect) pixel.Matrix {
+	return pixel.IM.Scaled(pixel.ZV, interfaceZoom(layout)).Chained(screenMatrix())
+}
+
+// mouseOnCanvas is where the mouse is in canvas coordinates, in units of the world
+func mouseOnCanvas(win *pixelgl.Window) pixel.Vec {
+	return canvasView.Unproject(win.MousePosition()).Scaled(1 / canvasZoom)
+}
+
+type menuItem struct {
+	label  func() string
+	action func()
+}
+
+// menu is a vertical list of items navigated with up/down and activated with enter, or hovered
+// and clicked with the mouse, the wheel moves through it too
+type menu struct {
+	title string
+	items []menuItem
+	sel   int
+	// spoken is the item the narrator read last, -1 before the menu was read at all
+	spoken int
+
+	// rects are where the items were drawn last frame, on the canvas
+	rects []pixel.Rect
+	mouse pixel.Vec
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newMenu(title string, items ...menuItem) *menu {
+	return &menu{
+		title:  title,
+		items:  items,
+		spoken: -1,
+		imd:    imdraw.New(nil),
+		txt:    text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+func (m *menu) update(win *pixelgl.Window) {
+	if ctl.justPressed(bindUp) {
+		m.sel = (m.sel + len(m.items) - 1) % len(m.items)
+	}
+	if ctl.justPressed(bindDown) {
+		m.sel = (m.sel + 1) % len(m.items)
+	}
+	if scroll := win.MouseScroll().Y; scroll != 0 {
+		if scroll > 0 {
+			m.sel = (m.sel + len(m.items) - 1) % len(m.items)
+		} else {
+			m.sel = (m.sel + 1) % len(m.items)
+		}
+	}
+
+	// only a mouse that moved takes the selection, so it doesn't fight the keys
+	mouse := mouseOnCanvas(win)
+	hover := -1
+	for i, r := range m.rects {
+		if r.Contains(mouse) {
+			hover = i
+		}
+	}
+	if hover >= 0 && mouse != m.mouse {
+		m.sel = hover
+	}
+	m.mouse = mouse
+	if hover >= 0 && win.JustPressed(pixelgl.MouseButtonLeft) {
+		m.sel = hover
+		m.activate()
+		return
+	}
+
+	if ctl.justPressed(bindConfirm) {
+		m.activate()
+	}
+	m.narrate()
+}
+
+// activate does what the selected item does, the narrator reads what it changed to if it's a
+// setting
+func (m *menu) activate() {
+	label := m.items[m.sel].label()
+	m.items[m.sel].action()
+	if now := m.items[m.sel].label(); now != label {
+		say(now)
+	}
+}
+
+// narrate reads the item that was moved to, the title too when the menu just came up
+func (m *menu) narrate() {
+	switch m.spoken {
+	case m.sel:
+		return
+	case -1:
+		say(m.title + "\n" + m.items[m.sel].label())
+	default:
+		say(m.items[m.sel].label())
+	}
+	m.spoken = m.sel
+}
+
+func (m *menu) draw(canvas *pixelgl.Canvas) {
+	canvas.SetMatrix(screenMatrix())
+
+	// darken whatever is underneath
+	m.imd.Clear()
+	m.imd.Color = pixel.Alpha(0.6)
+	m.imd.Push(canvasBounds.Min, canvasBounds.Max)
+	m.imd.Rectangle(0)
+	m.imd.Draw(canvas)
+
+	m.txt.Clear()
+	m.txt.Color = colornames.White
+	m.txt.WriteString(m.title + "\n\n")
+	lines := make([]float64, len(m.items))
+	for i, item := range m.items {
+		m.txt.Color = colornames.Lightgrey
+		prefix := "  "
+		if i == m.sel {
+			m.txt.Color = colornames.Gold
+			prefix = "> "
+		}
+		lines[i] = m.txt.Dot.Y
+		m.txt.WriteString(prefix + item.label() + "\n")
+	}
+	bounds, atlas := m.txt.Bounds(), m.txt.Atlas()
+	zoom := interfaceZoom(bounds.Moved(bounds.Center().Scaled(-1)))
+	place := pixel.IM.Moved(bounds.Center().Scaled(-1)).Scaled(pixel.ZV, zoom)
+	m.txt.Draw(canvas, place)
+
+	// every item takes the whole width of the menu and its line, for the mouse
+	m.rects = m.rects[:0]
+	for _, y := range lines {
+		m.rects = append(m.rects, pixel.Rect{
+			Min: place.Project(pixel.V(bounds.Min.X, y-atlas.Descent())),
+			Max: place.Project(pixel.V(bounds.Max.X, y+atlas.Ascent())),
+		})
+	}
+}
+
+func static(s string) func() string {
+	return func() string { return s }
+}
+
+// noticeScreen tells the player about something that went wrong but didn't stop the game, until
+// it's dismissed
+type noticeScreen struct {
+	win  *pixelgl.Window
+	menu *menu
+}
+
+func newNoticeScreen(win *pixelgl.Window, screens *screenStack, title string, lines []string) *noticeScreen {
+	return &noticeScreen{
+		win:  win,
+		menu: newMenu(title+"\n\n"+strings.Join(lines, "\n"), menuItem{static("OK"), screens.pop}),
+	}
+}
+
+// newFailureScreen tells the player what stopped the game, dismissing it closes the window
+func newFailureScreen(win *pixelgl.Window, title string, err error) *noticeScreen {
+	return &noticeScreen{
+		win:  win,
+		menu: newMenu(title+"\n\n"+err.Error(), menuItem{static("Quit"), func() { win.SetClosed(true) }}),
+	}
+}
+
+// newPowerScreen suggests the low-power mode when the game starts on battery, it's only asked
+// once
+func newPowerScreen(win *pixelgl.Window, screens *screenStack, set *settings) *noticeScreen {
+	answer := func(on bool) func() {
+		return func() {
+			set.LowPower, set.PowerAsked = on, true
+			if err := set.save(); err != nil {
+				fmt.Println(err)
+			}
+			screens.pop()
+		}
+	}
+	return &noticeScreen{
+		win: win,
+		menu: newMenu("RUNNING ON BATTERY\n\nThe low-power mode saves it: 30 frames a second,\nfewer particles, no slow motion tint.\nIt can be changed in the settings later.",
+			menuItem{static("Not now"), answer(false)},
+			menuItem{static("Turn on low-power mode"), answer(true)},
+		),
+	}
+}
+
+func (ns *noticeScreen) update(dt float64) {
+	if ctl.justPressed(bindBack) {
+		ns.menu.items[0].action()
+		return
+	}
+	ns.menu.update(ns.win)
+}
+
+func (ns *noticeScreen) draw(canvas *pixelgl.Canvas) {
+	ns.menu.draw(canvas)
+}
+
+// sessionScreen sums up the session when leaving it, for the title screen or for good
+type sessionScreen struct {
+	win  *pixelgl.Window
+	menu *menu
+}
+
+// newSessionScreen shows the session's summary, done is called once it's dismissed
+func newSessionScreen(win *pixelgl.Window, sess *session, done func()) *sessionScreen {
+	return &sessionScreen{
+		win:  win,
+		menu: newMenu("THIS SESSION\n\n"+strings.Join(sess.summary(), "\n"), menuItem{static("OK"), done}),
+	}
+}
+
+func (ss *sessionScreen) update(dt float64) {
+	if ctl.justPressed(bindBack) {
+		ss.menu.items[0].action()
+		return
+	}
+	ss.menu.update(ss.win)
+}
+
+func (ss *sessionScreen) draw(canvas *pixelgl.Canvas) {
+	ss.menu.draw(canvas)
+}
+
+// gameOverScreen ends the run when the gopher falls off the tower, over the tower where it fell
+type gameOverScreen struct {
+	win  *pixelgl.Window
+	menu *menu
+	quit func()
+}
+
+// newGameOverScreen shows how the run went, again starts a fresh run and quit leaves for the title
+// screen
+func newGameOverScreen(win *pixelgl.Window, screens *screenStack, died physics.Died, best int, again, quit func()) *gameOverScreen {
+	title := fmt.Sprintf("GAME OVER\n\nScore %d, best %d\nFloor %d, %s", tower.Score, best, int(died.Height/physics.FloorHeight), died.Cause)
+	return &gameOverScreen{
+		win: win,
+		menu: newMenu(title,
+			menuItem{static("Play again"), func() {
+				screens.pop()
+				again()
+			}},
+			menuItem{static("Quit to title"), quit},
+		),
+		quit: quit,
+	}
+}
+
+func (gos *gameOverScreen) update(dt float64) {
+	if ctl.justPressed(bindBack) {
+		gos.quit()
+		return
+	}
+	gos.menu.update(gos.win)
+}
+
+func (gos *gameOverScreen) draw(canvas *pixelgl.Canvas) {
+	gos.menu.draw(canvas)
+}
+
+// pickScreen freezes the tower at a milestone until one of the picks on offer is taken, there's
+// no way around it
+type pickScreen struct {
+	win  *pixelgl.Window
+	menu *menu
+}
+
+// newPickScreen offers the picks at the floor, chose is called with the index of the one taken
+func newPickScreen(win *pixelgl.Window, screens *screenStack, floor int, offer []*tower.Mutator, chose func(i int)) *pickScreen {
+	var items []menuItem
+	for i, m := range offer {
+		i := i
+		items = append(items, menuItem{static(m.Name), func() {
+			screens.pop()
+			chose(i)
+		}})
+	}
+	return &pickScreen{win: win, menu: newMenu(fmt.Sprintf("FLOOR %d\nPick one for the rest of the run", floor), items...)}
+}
+
+func (ps *pickScreen) update(dt float64) {
+	ps.menu.update(ps.win)
+}
+
+func (ps *pickScreen) draw(canvas *pixelgl.Canvas) {
+	ps.menu.draw(canvas)
+}
+
+// pauseScreen freezes the tower until resumed
+type pauseScreen struct {
+	win  *pixelgl.Window
+	menu *menu
+}
+
+// newPauseScreen pauses the run on the tower of the code, quit leaves it for the title screen
+func newPauseScreen(win *pixelgl.Window, screens *screenStack, st *stats, set *settings, code runCode, quit func()) *pauseScreen {
+	ps := &pauseScreen{win: win}
+	title := "PAUSED\nTower code: " + code.String()
+	if code.phrase != "" {
+		title += "\nSeed: " + code.phrase
+	}
+	if code.challenge != "" {
+		title += "\nChallenge: " + code.challenge
+	}
+	ps.menu = newMenu(title,
+		menuItem{static("Resume"), screens.pop},
+		menuItem{static("Settings"), func() {
+			tower.Bus.Publish(featureUsed{"settings"})
+			screens.push(newSettingsScreen(win, screens, set))
+		}},
+		menuItem{static("Stats"), func() { screens.push(newStatsScreen(win, screens, st)) }},
+		menuItem{
+			label:  func() string { return "Controls: " + ctl.presetName() },
+			action: ctl.nextPreset,
+		},
+		menuItem{
+			label: func() string {
+				if practicing {
+					return "Practice: F5 save, F8 load, F6 re-roll"
+				}
+				return "Practice mode"
+			},
+			action: func() {
+				if !practicing {
+					tower.Bus.Publish(featureUsed{"practice"})
+				}
+				practicing = true
+			},
+		},
+		menuItem{static("Quit to title"), quit},
+		menuItem{static("Quit"), func() { win.SetClosed(true) }},
+	)
+	return ps
+}
+
+func (ps *pauseScreen) update(dt float64) {
+	// the pause button resumes too
+	if ctl.justPressed(bindBack) || ctl.justPressed(bindPause) {
+		ps.menu.items[0].action()
+		return
+	}
+	ps.menu.update(ps.win)
+}
+
+func (ps *pauseScreen) draw(canvas *pixelgl.Canvas) {
+	ps.menu.draw(canvas)
+}
+
+// settingsScreen is pushed over the pause menu and pops back to it
+type settingsScreen struct {
+	win     *pixelgl.Window
+	screens *screenStack
+	set     *settings
+	menu    *menu
+}
+
+func newSettingsScreen(win *pixelgl.Window, screens *screenStack, set *settings) *settingsScreen {
+	ss := &settingsScreen{win: win, screens: screens, set: set}
+	ss.menu = newMenu("SETTINGS",
+		menuItem{
+			label: func() string {
+				if win.VSync() {
+					return "VSync: on"
+				}
+				return "VSync: off"
+			},
+			action: func() {
+				win.SetVSync(!win.VSync())
+				set.VSync = win.VSync()
+			},
+		},
+		menuItem{
+			label: func() string {
+				if set.Display != set.active {
+					return "Display: " + set.Display.String() + " (after restart)"
+				}
+				return "Display: " + set.Display.String()
+			},
+			action: func() {
+				for i, dm := range displayModes {
+					if dm == set.Display {
+						set.setDisplay(win, displayModes[(i+1)%len(displayModes)])
+						break
+					}
+				}
+			},
+		},
+		menuItem{
+			label: func() string {
+				monitors, cur := pixelgl.Monitors(), set.monitor().Name()
+				for i, m := range monitors {
+					if m.Name() == cur {
+						return fmt.Sprintf("Monitor: %d/%d %s", i+1, len(monitors), cur)
+					}
+				}
+				return "Monitor: " + cur
+			},
+			action: func() {
+				monitors, cur := pixelgl.Monitors(), set.monitor().Name()
+				next := 0
+				for i, m := range monitors {
+					if m.Name() == cur {
+						next = (i + 1) % len(monitors)
+					}
+				}
+				set.setMonitor(win, monitors[next].Name())
+			},
+		},
+		menuItem{
+			label: func() string { return "Resolution: " + set.Resolution },
+			action: func() {
+				for i, r := range resolutions {
+					if r == set.Resolution {
+						set.Resolution = resolutions[(i+1)%len(resolutions)]
+						break
+					}
+				}
+			},
+		},
+		menuItem{
+			label: func() string {
+				if set.LowPower {
+					return "Low-power mode: on"
+				}
+				return "Low-power mode: off"
+			},
+			action: func() { set.LowPower = !set.LowPower },
+		},
+		menuItem{
+			label: func() string {
+				if set.IdlePause == 0 {
+					return "Pause when idle: never"
+				}
+				return fmt.Sprintf("Pause when idle: after %ds", set.IdlePause)
+			},
+			action: func() {
+				next := idleTimes[0]
+				for i, t := range idleTimes {
+					if t == set.IdlePause {
+						next = idleTimes[(i+1)%len(idleTimes)]
+					}
+				}
+				set.IdlePause = next
+			},
+		},
+		menuItem{
+			label: func() string { return fmt.Sprintf("UI scale: %d%%", set.UIScale) },
+			action: func() {
+				next := uiScales[0]
+				for i, p := range uiScales {
+					if p == set.UIScale {
+						next = uiScales[(i+1)%len(uiScales)]
+					}
+				}
+				set.UIScale = next
+			},
+		},
+		menuItem{
+			label: func() string { return "Platforms: " + set.Platforms },
+			action: func() {
+				for i, ps := range tower.PlatformStyles {
+					if ps == set.Platforms {
+						set.Platforms = tower.PlatformStyles[(i+1)%len(tower.PlatformStyles)]
+						break
+					}
+				}
+			},
+		},
+		menuItem{
+			label: func() string { return "Theme: " + set.Theme },
+			action: func() {
+				for i, n := range themeNames {
+					if n == set.Theme {
+						set.Theme = themeNames[(i+1)%len(themeNames)]
+						break
+					}
+				}
+			},
+		},
+		menuItem{
+			label: func() string { return "Time of day: " + set.Ambient },
+			action: func() {
+				for i, a := range ambients {
+					if a == set.Ambient {
+						set.Ambient = ambients[(i+1)%len(ambients)]
+						break
+					}
+				}
+			},
+		},
+		menuItem{
+			label: func() string {
+				if set.InputDisplay {
+					return "Input display: on"
+				}
+				return "Input display: off"
+			},
+			action: func() { set.InputDisplay = !set.InputDisplay },
+		},
+		menuItem{
+			label: func() string {
+				if set.Music {
+					return "Music: on"
+				}
+				return "Music: off"
+			},
+			action: func() { set.Music = !set.Music },
+		},
+		menuItem{
+			label: func() string {
+				if set.MusicTempo {
+					return "Music tempo: follows the speed"
+				}
+				return "Music tempo: steady"
+			},
+			action: func() { set.MusicTempo = !set.MusicTempo },
+		},
+		menuItem{
+			label: func() string {
+				if set.Captions {
+					return "Captions: on"
+				}
+				return "Captions: off"
+			},
+			action: func() { set.Captions = !set.Captions },
+		},
+		menuItem{
+			label: func() string {
+				switch {
+				case speech == nil:
+					return "Narration: no speech on this system"
+				case set.Narration:
+					return "Narration: on"
+				}
+				return "Narration: off"
+			},
+			action: func() {
+				set.Narration = !set.Narration && speech != nil
+			},
+		},
+		menuItem{
+			label: func() string {
+				if set.Assist {
+					return "Timing assist: on from the next run"
+				}
+				return "Timing assist: off"
+			},
+			action: func() { set.Assist = !set.Assist },
+		},
+		menuItem{
+			label: func() string {
+				if set.Adaptive {
+					return "Adaptive difficulty: on from the next run"
+				}
+				return "Adaptive difficulty: off"
+			},
+			action: func() { set.Adaptive = !set.Adaptive },
+		},
+		menuItem{static("Back"), ss.back},
+	)
+	return ss
+}
+
+// back saves the settings and goes back to the pause menu
+func (ss *settingsScreen) back() {
+	if err := ss.set.save(); err != nil {
+		fmt.Println(err)
+	}
+	ss.screens.pop()
+}
+
+func (ss *settingsScreen) update(dt float64) {
+	if ctl.justPressed(bindBack) {
+		ss.back()
+		return
+	}
+	ss.menu.update(ss.win)
+}
+
+func (ss *settingsScreen) draw(canvas *pixelgl.Canvas) {
+	ss.menu.draw(canvas)
+}
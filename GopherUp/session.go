@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+)
+
+// session adds up the runs played since the game was opened, from the gameplay events, for the
+// summary shown when leaving. Practice doesn't count, like for the stats.
+type session struct {
+	started time.Time
+	runs    int
+	best    int
+	floors  int
+
+	// score and floor are where the current run started from, climbing is set once it got
+	// anywhere
+	score, floor int
+	climbing     bool
+}
+
+func newSession() *session {
+	return &session{started: time.Now()}
+}
+
+// start is a new run on a new tower
+func (s *session) start() {
+	s.score, s.floor, s.climbing = tower.Score, 0, false
+}
+
+func (s *session) onEvent(e tower.Event) {
+	if practicing {
+		return
+	}
+	switch e := e.(type) {
+	case physics.FloorReached:
+		if e.Floor > s.floor {
+			s.floors += e.Floor - s.floor
+			s.floor = e.Floor
+		}
+		s.climbing = true
+	case physics.Died:
+		s.end()
+	}
+}
+
+// end counts the current run, the tower goes on from where it is for the next one
+func (s *session) end() {
+	s.runs++
+	if tower.Score-s.score > s.best {
+		s.best = tower.Score - s.score
+	}
+	s.score, s.climbing = tower.Score, false
+}
+
+// finish counts the run in progress too, if it got anywhere, before the summary
+func (s *session) finish() {
+	if s.climbing {
+		s.end()
+	}
+}
+
+// summary is the lines of the session summary
+func (s *session) summary() []string {
+	return []string{
+		fmt.Sprintf("Runs played:     %d", s.runs),
+		fmt.Sprintf("Best score:      %d", s.best),
+		fmt.Sprintf("Floors climbed:  %d", s.floors),
+		fmt.Sprintf("Time played:     %s", time.Since(s.started).Round(time.Second)),
+	}
+}
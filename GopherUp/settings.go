@@ -0,0 +1,333 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/pkg/errors"
+)
+
+// displayMode is how the window takes up the screen
+type displayMode string
+
+const (
+	windowed   displayMode = "windowed"
+	fullscreen displayMode = "fullscreen"
+	// borderless is an undecorated window the size of the desktop, it looks like fullscreen but
+	// alt-tabbing out of it doesn't switch video modes
+	borderless displayMode = "borderless"
+)
+
+var displayModes = []displayMode{windowed, fullscreen, borderless}
+
+var displayNames = map[displayMode]string{
+	windowed:   "windowed",
+	fullscreen: "fullscreen",
+	borderless: "borderless windowed",
+}
+
+func (dm displayMode) String() string {
+	return displayNames[dm]
+}
+
+// resolutions are the sizes the canvas can be drawn at. The view of the tower is the same at all
+// of them, with more pixels to it: the first is chunky retro pixels, the last is smooth.
+var resolutions = []string{"320x240", "480x360", "640x480"}
+
+// resolutionZoom is how many canvas pixels a unit of the world takes at the resolution, 0 if it
+// isn't one of resolutions
+func resolutionZoom(res string) float64 {
+	for _, r := range resolutions {
+		if r == res {
+			var w, h float64
+			fmt.Sscanf(res, "%fx%f", &w, &h)
+			return w / canvasBounds.W()
+		}
+	}
+	return 0
+}
+
+// uiScales are the steps of the UI scale setting, in percent, smallest to biggest
+var uiScales = []int{75, 100, 125, 150, 175, 200}
+
+func knownUIScale(percent int) bool {
+	for _, p := range uiScales {
+		if p == percent {
+			return true
+		}
+	}
+	return false
+}
+
+// windowSize is the size of the window in windowed mode, on a 96dpi monitor
+var windowSize = pixel.R(0, 0, 1024, 768)
+
+// settings are the player's choices in the settings menu, kept between runs
+type settings struct {
+	Version int         `json:"version"`
+	VSync   bool        `json:"vsync"`
+	Display displayMode `json:"display"`
+	// Monitor is the name of the monitor the game goes on, the primary one if it's empty or
+	// isn't plugged in
+	Monitor string `json:"monitor,omitempty"`
+	// InputDisplay shows the held keys on screen, see inputDisplay
+	InputDisplay bool `json:"inputDisplay"`
+	Music        bool `json:"music"`
+	// MusicTempo speeds the music up with the tower
+	MusicTempo bool `json:"musicTempo"`
+	// Captions shows the important sounds as text
+	Captions bool `json:"captions"`
+	// Narration reads the menus and the important moments out loud, see narrator
+	Narration bool `json:"narration"`
+	// Assist is the timing assist, from the next run on, see setAssist
+	Assist bool `json:"assist"`
+	// Adaptive is the adaptive difficulty, from the next run on, see setAdaptive
+	Adaptive bool `json:"adaptive"`
+	// LowPower is the battery friendly mode, see lowPowerFPS, PowerAsked whether the game
+	// suggested it already
+	LowPower   bool `json:"lowPower"`
+	PowerAsked bool `json:"powerAsked"`
+	// IdlePause is how many seconds without input pause a run, zero never
+	IdlePause int `json:"idlePause"`
+	// Resolution is the size of the canvas, from resolutions
+	Resolution string `json:"resolution"`
+	// Platforms is how the platforms are drawn, from platformStyles
+	Platforms string `json:"platforms"`
+	// Theme is the look of the tower, from themeNames
+	Theme string `json:"theme"`
+	// Ambient is the time of day the sky, the light and the music show, from ambients
+	Ambient string `json:"ambient"`
+	// UIScale is how big the menus and the HUD are drawn, in percent, from uiScales
+	UIScale int `json:"uiScale"`
+
+	// active is the display mode the window is in, Display can only differ from it until a
+	// restart when going to or from borderless
+	active displayMode
+	// scale is the content scale of the window, see contentScale
+	scale float64
+	// problems are what was wrong with the settings file, short enough for the notice shown when
+	// the game starts
+	problems []string
+}
+
+// loadSettings reads the settings file, a missing file is the defaults. A broken setting doesn't
+// stop the game, it's put back to its default and problems says what was ignored and why.
+func loadSettings() *settings {
+	s := &settings{VSync: true, Display: windowed, Music: true, MusicTempo: true, Resolution: resolutions[0], Platforms: tower.PlatformStyles[0], Theme: themeNames[0], Ambient: ambients[0], IdlePause: idleTimes[0], UIScale: 100, scale: 1}
+	defer func() { s.active = s.Display }()
+	data, err := readSave(settingsFile, storage.Settings)
+	if os.IsNotExist(err) {
+		return s
+	}
+	if err != nil {
+		fmt.Println(err)
+		s.problems = []string{"settings.json can't be read"}
+		return s
+	}
+	s.problems = decodeSettings(data, s)
+	if _, ok := displayNames[s.Display]; !ok {
+		s.problems = append(s.problems, fmt.Sprintf("display: no mode %q", s.Display))
+		s.Display = windowed
+	}
+	if resolutionZoom(s.Resolution) == 0 {
+		s.problems = append(s.problems, fmt.Sprintf("resolution: no resolution %q, there's %s", s.Resolution, strings.Join(resolutions, ", ")))
+		s.Resolution = resolutions[0]
+	}
+	if !tower.KnownPlatformStyle(s.Platforms) {
+		s.problems = append(s.problems, fmt.Sprintf("platforms: no style %q, there's %s", s.Platforms, strings.Join(tower.PlatformStyles, ", ")))
+		s.Platforms = tower.PlatformStyles[0]
+	}
+	if s.IdlePause < 0 {
+		s.problems = append(s.problems, fmt.Sprintf("idlePause: %d seconds", s.IdlePause))
+		s.IdlePause = idleTimes[0]
+	}
+	if !knownTheme(s.Theme) {
+		s.problems = append(s.problems, fmt.Sprintf("theme: no theme %q, there's %s", s.Theme, strings.Join(themeNames, ", ")))
+		s.Theme = themeNames[0]
+	}
+	if !knownUIScale(s.UIScale) {
+		s.problems = append(s.problems, fmt.Sprintf("uiScale: %d%%, it goes from %d to %d in steps of 25", s.UIScale, uiScales[0], uiScales[len(uiScales)-1]))
+		s.UIScale = 100
+	}
+	if !knownAmbient(s.Ambient) {
+		s.problems = append(s.problems, fmt.Sprintf("ambient: no time of day %q, there's %s", s.Ambient, strings.Join(ambients, ", ")))
+		s.Ambient = ambients[0]
+	}
+	return s
+}
+
+// decodeSettings reads the settings one field at a time, a field that doesn't fit keeps its
+// default, the problems are the fields that were ignored
+func decodeSettings(data []byte, s *settings) []string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return []string{"settings.json isn't a JSON object"}
+	}
+	var problems []string
+	v, t := reflect.ValueOf(s).Elem(), reflect.TypeOf(*s)
+	known := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if key == "" {
+			continue
+		}
+		known[key] = true
+		raw, ok := fields[key]
+		if !ok {
+			continue
+		}
+		// decoded into a copy, so the default survives a bad value
+		f := reflect.New(t.Field(i).Type)
+		f.Elem().Set(v.Field(i))
+		if err := json.Unmarshal(raw, f.Interface()); err != nil {
+			shown := string(raw)
+			if len(shown) > 12 {
+				shown = shown[:10] + ".."
+			}
+			problems = append(problems, fmt.Sprintf("%s: %s isn't %s", key, shown, kindName(t.Field(i).Type.Kind())))
+			continue
+		}
+		v.Field(i).Set(f.Elem())
+	}
+	var unknown []string
+	for key := range fields {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Sprintf("%s: no such setting", key))
+	}
+	return problems
+}
+
+// kindName is what a value of the kind looks like in the settings file
+func kindName(k reflect.Kind) string {
+	switch k {
+	case reflect.Bool:
+		return "true or false"
+	case reflect.String:
+		return "text"
+	case reflect.Int:
+		return "a whole number"
+	}
+	return "a " + k.String()
+}
+
+func (s *settings) save() error {
+	s.Version = settingsFile.Version()
+	data, err := json.MarshalIndent(s, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving settings")
+	}
+	return errors.Wrap(storage.WriteFile(data, 0644, storage.Settings), "error saving settings")
+}
+
+// monitor is the monitor the game goes on
+func (s *settings) monitor() *pixelgl.Monitor {
+	for _, m := range pixelgl.Monitors() {
+		if m.Name() == s.Monitor {
+			return m
+		}
+	}
+	return pixelgl.PrimaryMonitor()
+}
+
+// pickMonitor sets the monitor by its number (starting at 1, in the order pixelgl lists them) or
+// its name
+func (s *settings) pickMonitor(which string) error {
+	monitors := pixelgl.Monitors()
+	if n, err := strconv.Atoi(which); err == nil && n >= 1 && n <= len(monitors) {
+		s.Monitor = monitors[n-1].Name()
+		return nil
+	}
+	var names []string
+	for i, m := range monitors {
+		if m.Name() == which {
+			s.Monitor = which
+			return nil
+		}
+		names = append(names, fmt.Sprintf("%d: %s", i+1, m.Name()))
+	}
+	return errors.Errorf("no monitor %q, the monitors are\n%s", which, strings.Join(names, "\n"))
+}
+
+// windowBounds is windowSize at the content scale
+func (s *settings) windowBounds() pixel.Rect {
+	return pixel.R(0, 0, windowSize.W()*s.scale, windowSize.H()*s.scale)
+}
+
+// windowPos is where the window goes to be centered on the monitor
+func (s *settings) windowPos(m *pixelgl.Monitor) pixel.Vec {
+	x, y := m.Position()
+	w, h := m.Size()
+	size := s.windowBounds().Size()
+	return pixel.V(x+(w-size.X)/2, y+(h-size.Y)/2)
+}
+
+// windowConfig is the config to open the window with
+func (s *settings) windowConfig(title string) pixelgl.WindowConfig {
+	monitor := s.monitor()
+	cfg := pixelgl.WindowConfig{
+		Title:    title,
+		Bounds:   s.windowBounds(),
+		Position: s.windowPos(monitor),
+		VSync:    s.VSync,
+	}
+	switch s.active {
+	case fullscreen:
+		cfg.Monitor = monitor
+	case borderless:
+		w, h := monitor.Size()
+		x, y := monitor.Position()
+		cfg.Bounds = pixel.R(0, 0, w, h)
+		cfg.Position = pixel.V(x, y)
+		cfg.Undecorated = true
+	}
+	return cfg
+}
+
+// setDisplay switches the window to the display mode, pixelgl can't take the decorations off a
+// window once it's open, so going to or from borderless waits for a restart
+func (s *settings) setDisplay(win *pixelgl.Window, dm displayMode) {
+	s.Display = dm
+	if dm == s.active || dm == borderless || s.active == borderless {
+		return
+	}
+	if dm == fullscreen {
+		win.SetMonitor(s.monitor())
+	} else {
+		win.SetMonitor(nil)
+	}
+	s.active = dm
+}
+
+// setMonitor moves the window to the monitor with the name, keeping the display mode
+func (s *settings) setMonitor(win *pixelgl.Window, name string) {
+	s.Monitor = name
+	monitor := s.monitor()
+	switch s.active {
+	case windowed:
+		win.SetPos(s.windowPos(monitor))
+	case fullscreen:
+		// back to a window first, so it comes back out of fullscreen on the new monitor
+		win.SetMonitor(nil)
+		win.SetPos(s.windowPos(monitor))
+		win.SetMonitor(monitor)
+	case borderless:
+		w, h := monitor.Size()
+		win.SetPos(pixel.V(monitor.Position()))
+		win.SetBounds(pixel.R(0, 0, w, h))
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"hash/fnv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// the game modes and difficulties a tower can be played in, there's only the one of each so far
+// but the codes have room for more
+const (
+	modeClassic      = 0
+	difficultyNormal = 0
+)
+
+// runCode is everything that makes two towers the same, it's shared as a short code like
+// 020D1-B7K1V or as a gotower://run/020D1-B7K1V link
+type runCode struct {
+	seed       int64
+	mode       byte
+	difficulty byte
+	// phrase is the seed phrase the tower was made from, if it was, it isn't part of the code
+	phrase string
+	// challenge is the daily or weekly challenge the tower is, if it is, like "daily 2026-10-15"
+	challenge string
+}
+
+// phraseCode is the tower for a seed phrase, the hash is cut to the size of a random tower's seed
+// to keep its code as short
+func phraseCode(phrase string) runCode {
+	h := fnv.New64a()
+	h.Write([]byte(phrase))
+	return runCode{seed: int64(h.Sum64() % (1 << 28)), phrase: phrase}
+}
+
+// codeAlphabet is Crockford's base32, without the letters that look like digits
+const codeAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+const codeScheme = "gotower://run/"
+
+// String is the short code
+func (rc runCode) String() string {
+	data := []byte{rc.mode<<4 | rc.difficulty&0xf}
+	data = append(data, make([]byte, binary.MaxVarintLen64)...)
+	data = data[:1+binary.PutUvarint(data[1:], uint64(rc.seed))]
+	data = append(data, byte(crc32.ChecksumIEEE(data)))
+
+	// five bits per character
+	var sb strings.Builder
+	bits, n := uint(0), uint(0)
+	for _, b := range data {
+		bits = bits<<8 | uint(b)
+		n += 8
+		for n >= 5 {
+			n -= 5
+			sb.WriteByte(codeAlphabet[bits>>n&31])
+		}
+	}
+	if n > 0 {
+		sb.WriteByte(codeAlphabet[bits<<(5-n)&31])
+	}
+
+	// in groups of five for reading it out to a friend
+	code := sb.String()
+	var grouped []string
+	for len(code) > 5 {
+		grouped = append(grouped, code[:5])
+		code = code[5:]
+	}
+	return strings.Join(append(grouped, code), "-")
+}
+
+// parseRunCode reads a code or a gotower:// link, it's forgiving about case, dashes and spaces
+// and the letters that look like digits. What comes after the code in a link is left alone, the
+// game has nothing to do with it.
+func parseRunCode(s string) (runCode, error) {
+	var rc runCode
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(strings.ToLower(s), codeScheme) {
+		s = s[len(codeScheme):]
+		if i := strings.IndexByte(s, '?'); i >= 0 {
+			s = s[:i]
+		}
+	}
+
+	var data []byte
+	bits, n := uint(0), uint(0)
+	for _, r := range strings.ToUpper(s) {
+		switch r {
+		case '-', ' ':
+			continue
+		case 'O':
+			r = '0'
+		case 'I', 'L':
+			r = '1'
+		}
+		v := strings.IndexRune(codeAlphabet, r)
+		if v < 0 {
+			return rc, errors.Errorf("bad tower code: %q isn't in a code", r)
+		}
+		bits = bits<<5 | uint(v)
+		n += 5
+		if n >= 8 {
+			n -= 8
+			data = append(data, byte(bits>>n))
+		}
+	}
+	if len(data) < 3 || byte(crc32.ChecksumIEEE(data[:len(data)-1])) != data[len(data)-1] {
+		return rc, errors.New("bad tower code: check for typos")
+	}
+	seed, k := binary.Uvarint(data[1 : len(data)-1])
+	if k <= 0 || 1+k != len(data)-1 {
+		return rc, errors.New("bad tower code: check for typos")
+	}
+	rc.seed = int64(seed)
+	rc.mode, rc.difficulty = data[0]>>4, data[0]&0xf
+	if rc.mode != modeClassic || rc.difficulty != difficultyNormal {
+		return rc, errors.New("the tower code is from a newer version of the game")
+	}
+	return rc, nil
+}
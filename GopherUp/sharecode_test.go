@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+// TestRunCodeRoundTrip checks that a code and its link read back as the tower they were made for,
+// however they were typed
+func TestRunCodeRoundTrip(t *testing.T) {
+	for _, seed := range []int64{0, 1, 12345, 1<<28 - 1, 1 << 40} {
+		code := runCode{seed: seed}.String()
+		for _, typed := range []string{
+			code,
+			codeScheme + code,
+			"  " + code + " ",
+			codeScheme + code + "?from=a-friend",
+		} {
+			rc, err := parseRunCode(typed)
+			if err != nil {
+				t.Errorf("parseRunCode(%q): %v", typed, err)
+				continue
+			}
+			if rc.seed != seed || rc.mode != modeClassic || rc.difficulty != difficultyNormal {
+				t.Errorf("parseRunCode(%q) is seed %d mode %d difficulty %d, want seed %d", typed, rc.seed, rc.mode, rc.difficulty, seed)
+			}
+			if got := rc.String(); got != code {
+				t.Errorf("%q reads back as %s, want %s", typed, got, code)
+			}
+		}
+	}
+}
+
+// TestRunCodeTypos checks that the look-alike letters are forgiven and real typos aren't
+func TestRunCodeTypos(t *testing.T) {
+	code := runCode{seed: 0x1010101}.String()
+	rc, err := parseRunCode(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, r := range code {
+		var sloppy rune
+		switch r {
+		case '0':
+			sloppy = 'o'
+		case '1':
+			sloppy = 'l'
+		default:
+			continue
+		}
+		typed := code[:i] + string(sloppy) + code[i+1:]
+		if got, err := parseRunCode(typed); err != nil || got.seed != rc.seed {
+			t.Errorf("parseRunCode(%q) = %d, %v, want %d", typed, got.seed, err, rc.seed)
+		}
+	}
+	typo := []byte(code)
+	if typo[0] == 'X' {
+		typo[0] = 'Y'
+	} else {
+		typo[0] = 'X'
+	}
+	if _, err := parseRunCode(string(typo)); err == nil {
+		t.Errorf("parseRunCode(%q) took a typo", typo)
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+)
+
+// softTarget is a pixel.Target that rasterizes into an image in memory, so the scenes can be
+// rendered without a window or a GPU. It does what the pixelgl canvas does with the default
+// settings: premultiplied alpha blending and nearest pixel sampling.
+type softTarget struct {
+	img    *image.RGBA
+	bounds pixel.Rect
+	scale  float64
+}
+
+// newSoftTarget covers bounds of the world with scale image pixels per world unit
+func newSoftTarget(bounds pixel.Rect, scale float64) *softTarget {
+	w, h := int(bounds.W()*scale), int(bounds.H()*scale)
+	return &softTarget{img: image.NewRGBA(image.Rect(0, 0, w, h)), bounds: bounds, scale: scale}
+}
+
+func (st *softTarget) MakeTriangles(t pixel.Triangles) pixel.TargetTriangles {
+	td := pixel.MakeTrianglesData(t.Len())
+	td.Update(t)
+	return &softTriangles{TrianglesData: td, dst: st}
+}
+
+func (st *softTarget) MakePicture(p pixel.Picture) pixel.TargetPicture {
+	return &softPicture{PictureData: pixel.PictureDataFromPicture(p), dst: st}
+}
+
+type softTriangles struct {
+	*pixel.TrianglesData
+	dst *softTarget
+}
+
+func (t *softTriangles) Draw() {
+	t.dst.fill(t.TrianglesData, nil)
+}
+
+type softPicture struct {
+	*pixel.PictureData
+	dst *softTarget
+}
+
+func (p *softPicture) Draw(tri pixel.TargetTriangles) {
+	p.dst.fill(tri.(*softTriangles).TrianglesData, p.PictureData)
+}
+
+// fill rasterizes the triangles, interpolating colors and picture positions across them
+func (st *softTarget) fill(td *pixel.TrianglesData, pic *pixel.PictureData) {
+	toImage := func(v pixel.Vec) pixel.Vec {
+		v = v.Sub(st.bounds.Min).Scaled(st.scale)
+		return pixel.V(v.X, float64(st.img.Rect.Dy())-v.Y)
+	}
+	for i := 0; i+2 < td.Len(); i += 3 {
+		a, b, c := toImage(td.Position(i)), toImage(td.Position(i+1)), toImage(td.Position(i+2))
+		area := (b.X-a.X)*(c.Y-a.Y) - (c.X-a.X)*(b.Y-a.Y)
+		if area == 0 {
+			continue
+		}
+		minX := int(math.Max(0, math.Floor(math.Min(a.X, math.Min(b.X, c.X)))))
+		maxX := int(math.Min(float64(st.img.Rect.Dx()), math.Ceil(math.Max(a.X, math.Max(b.X, c.X)))))
+		minY := int(math.Max(0, math.Floor(math.Min(a.Y, math.Min(b.Y, c.Y)))))
+		maxY := int(math.Min(float64(st.img.Rect.Dy()), math.Ceil(math.Max(a.Y, math.Max(b.Y, c.Y)))))
+		for y := minY; y < maxY; y++ {
+			for x := minX; x < maxX; x++ {
+				p := pixel.V(float64(x)+0.5, float64(y)+0.5)
+				wa := ((b.X-p.X)*(c.Y-p.Y) - (c.X-p.X)*(b.Y-p.Y)) / area
+				wb := ((c.X-p.X)*(a.Y-p.Y) - (a.X-p.X)*(c.Y-p.Y)) / area
+				wc := 1 - wa - wb
+				if wa < 0 || wb < 0 || wc < 0 {
+					continue
+				}
+				col := td.Color(i).Scaled(wa).Add(td.Color(i + 1).Scaled(wb)).Add(td.Color(i + 2).Scaled(wc))
+				if pic != nil {
+					pa, ia := td.Picture(i)
+					pb, ib := td.Picture(i + 1)
+					pc, ic := td.Picture(i + 2)
+					at := pa.Scaled(wa).Add(pb.Scaled(wb)).Add(pc.Scaled(wc))
+					intensity := ia*wa + ib*wb + ic*wc
+					tex := pic.Color(pixel.V(math.Floor(at.X), math.Floor(at.Y)))
+					col = col.Mul(tex.Scaled(intensity).Add(pixel.Alpha(1 - intensity)))
+				}
+				st.blend(x, y, col)
+			}
+		}
+	}
+}
+
+// blend draws the premultiplied col over the pixel
+func (st *softTarget) blend(x, y int, col pixel.RGBA) {
+	dst := st.img.RGBAAt(x, y)
+	over := func(src float64, dst uint8) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(1, src+float64(dst)/255*(1-col.A))) * 255))
+	}
+	st.img.SetRGBA(x, y, color.RGBA{over(col.R, dst.R), over(col.G, dst.G), over(col.B, dst.B), over(col.A, dst.A)})
+}
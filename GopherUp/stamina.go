@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"golang.org/x/image/colornames"
+)
+
+// drawStamina puts the bar of st in the bottom left corner of bounds, red while it's too low for a
+// jump
+func drawStamina(imd *imdraw.IMDraw, st *physics.Stamina, bounds pixel.Rect) {
+	if !st.On {
+		return
+	}
+	bar := pixel.R(0, 0, 60, 4).Moved(bounds.Min.Add(pixel.V(6, 6)))
+	imd.Color = pixel.RGBA{A: 0.6}
+	imd.Push(bar.Min, bar.Max)
+	imd.Rectangle(0)
+	imd.Color = colornames.White
+	if !st.CanJump() {
+		imd.Color = colornames.Red
+	}
+	imd.Push(bar.Min, pixel.V(bar.Min.X+bar.W()*math.Max(0, st.Left), bar.Max.Y))
+	imd.Rectangle(0)
+	// a mark for every jump's worth
+	imd.Color = pixel.RGBA{A: 0.6}
+	for x := physics.StaminaJump; x < 1; x += physics.StaminaJump {
+		imd.Push(pixel.V(bar.Min.X+bar.W()*x, bar.Min.Y), pixel.V(bar.Min.X+bar.W()*x, bar.Max.Y))
+		imd.Line(1)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/storage"
+	"GoTower/internal/tower"
+
+	"github.com/pkg/errors"
+)
+
+// stats is everything we remember about the player between runs
+type stats struct {
+	Version    int     `json:"version"`
+	BestHeight float64 `json:"bestHeight"`
+	BestScore  int     `json:"bestScore"`
+	Runs       int     `json:"runs"`
+	// Deaths counts the deaths in each section of the tower, see deathSectionAt
+	Deaths map[int]int `json:"deaths,omitempty"`
+	// Top are the best runs, see record
+	Top []topRun `json:"top,omitempty"`
+}
+
+// loadStats reads the stats file, a missing file is just a fresh player
+func loadStats() (*stats, error) {
+	st := &stats{}
+	data, err := readSave(statsFile, storage.Stats)
+	if os.IsNotExist(err) {
+		return st, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading stats")
+	}
+	if err := json.Unmarshal(data, st); err != nil {
+		return nil, errors.Wrap(err, "error loading stats")
+	}
+	return st, nil
+}
+
+// track keeps the records up to date from the gameplay events
+func (st *stats) track(bus *tower.EventBus) {
+	bus.Subscribe(func(e tower.Event) {
+		if practicing {
+			return
+		}
+		switch e := e.(type) {
+		case physics.FloorReached:
+			if e.Height > st.BestHeight {
+				st.BestHeight = e.Height
+			}
+		case tower.GoalCollected, tower.BossSurvived:
+			if tower.Score > st.BestScore {
+				st.BestScore = tower.Score
+			}
+		case physics.Died:
+			st.Runs++
+			if st.Deaths == nil {
+				st.Deaths = map[int]int{}
+			}
+			st.Deaths[deathSectionAt(e.Height)]++
+		}
+	})
+}
+
+func (st *stats) save() error {
+	st.Version = statsFile.Version()
+	data, err := json.MarshalIndent(st, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving stats")
+	}
+	return errors.Wrap(storage.WriteFile(data, 0644, storage.Stats), "error saving stats")
+}
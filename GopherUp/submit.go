@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"net/http"
+	"os"
+	"time"
+
+	"GoTower/internal/leaderboard"
+	"GoTower/internal/storage"
+
+	"github.com/pkg/errors"
+)
+
+// loadInstallKey reads the key this install signs its scores with, making one the first time
+func loadInstallKey() (ed25519.PrivateKey, error) {
+	data, err := storage.ReadFile(storage.InstallKey)
+	if err == nil && len(data) == ed25519.SeedSize {
+		return ed25519.NewKeyFromSeed(data), nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "error loading the install key")
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "error making the install key")
+	}
+	return key, errors.Wrap(storage.WriteFile(key.Seed(), 0600, storage.InstallKey), "error saving the install key")
+}
+
+// submitter signs the summary of every finished run and posts it to the leaderboard, through an
+// outbox kept on disk, so scores made offline are submitted later
+type submitter struct {
+	url  string
+	key  ed25519.PrivateKey
+	out  *outbox
+	prof *playerProfile
+}
+
+func newSubmitter(url string, prof *playerProfile) (*submitter, error) {
+	key, err := loadInstallKey()
+	if err != nil {
+		return nil, err
+	}
+	out := newOutbox("score", &http.Client{Timeout: 10 * time.Second}, storage.Outbox)
+	return &submitter{url: url, key: key, out: out, prof: prof}, nil
+}
+
+// submit queues the run
+func (s *submitter) submit(rs runSummary) {
+	s.out.post(s.url, leaderboard.Sign(leaderboard.Score{
+		Score:     rs.Score,
+		Height:    rs.Height,
+		Prestige:  rs.Prestige,
+		Code:      rs.Code,
+		InputHash: rs.InputHash,
+		Mutators:  rs.Mutators,
+		Style:     rs.Style,
+		Assist:    rs.Assist,
+		Adaptive:  rs.Adaptive,
+		Time:      rs.Started.UTC().Truncate(time.Second),
+		Name:      s.prof.Name,
+		Avatar:    s.prof.Avatar,
+	}, s.key))
+}
+
+// close gives the scores still waiting a moment to go out, the rest wait for the next start
+func (s *submitter) close() {
+	s.out.close(2 * time.Second)
+}
@@ -0,0 +1,95 @@
+//go:build !notelemetry
+// +build !notelemetry
+
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+)
+
+// telemetryBatch is the number of events sent to the endpoint at once
+const telemetryBatch = 50
+
+// telemetryEvent is all that leaves the machine: what happened and when in the session, nothing
+// about the player
+type telemetryEvent struct {
+	Kind  string  `json:"kind"`
+	Time  float64 `json:"t"`
+	Value float64 `json:"value,omitempty"`
+	Name  string  `json:"name,omitempty"`
+}
+
+// telemetry batches gameplay events and posts them to the endpoint as JSON, through an outbox
+// that only keeps them in memory. The session is a random number picked at every launch, so
+// sessions can't be tied together.
+type telemetry struct {
+	endpoint string
+	session  string
+	out      *outbox
+
+	start    time.Time
+	runStart time.Time
+	batch    []telemetryEvent
+}
+
+// startTelemetry sends the gameplay events to endpoint until the returned stop is called, it does
+// nothing unless the player opted in by giving an endpoint
+func startTelemetry(endpoint string) (stop func()) {
+	if endpoint == "" {
+		return func() {}
+	}
+	t := &telemetry{
+		endpoint: endpoint,
+		session:  fmt.Sprintf("%016x", rand.New(rand.NewSource(time.Now().UnixNano())).Uint64()),
+		out:      newOutbox("telemetry", &http.Client{Timeout: 5 * time.Second}, ""),
+		start:    time.Now(),
+		runStart: time.Now(),
+	}
+	tower.Bus.Subscribe(t.onEvent)
+	return t.stop
+}
+
+func (t *telemetry) onEvent(e tower.Event) {
+	switch e := e.(type) {
+	case physics.Died:
+		t.add(telemetryEvent{Kind: "death", Value: e.Height, Name: e.Cause})
+		t.add(telemetryEvent{Kind: "run", Value: time.Since(t.runStart).Seconds()})
+		t.runStart = time.Now()
+	case tower.BossSurvived:
+		t.add(telemetryEvent{Kind: "boss"})
+	case featureUsed:
+		t.add(telemetryEvent{Kind: "feature", Name: e.name})
+	}
+}
+
+func (t *telemetry) add(ev telemetryEvent) {
+	ev.Time = time.Since(t.start).Seconds()
+	t.batch = append(t.batch, ev)
+	if len(t.batch) >= telemetryBatch {
+		t.flush()
+	}
+}
+
+// flush queues the batch
+func (t *telemetry) flush() {
+	if len(t.batch) == 0 {
+		return
+	}
+	t.out.post(t.endpoint, struct {
+		Session string           `json:"session"`
+		Events  []telemetryEvent `json:"events"`
+	}{t.session, t.batch})
+	t.batch = nil
+}
+
+// stop sends what's left and gives it a moment to go out
+func (t *telemetry) stop() {
+	t.flush()
+	t.out.close(2 * time.Second)
+}
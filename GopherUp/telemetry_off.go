@@ -0,0 +1,9 @@
+//go:build notelemetry
+// +build notelemetry
+
+package main
+
+// startTelemetry is compiled out of notelemetry builds, the endpoint is ignored
+func startTelemetry(endpoint string) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/sprite"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// theme is a look for the tower, picked in the settings. It only swaps how things are drawn, the
+// goals and everything else work the same in all of them. skies are the times of day, for the
+// ambient setting.
+type theme struct {
+	name  string
+	goals tower.GoalRenderer
+	skies [daytimes]sky
+}
+
+// themeNames are the themes in the settings, the first one is the default
+var themeNames = []string{"classic", "shiny"}
+
+// loadThemes makes the themes, a theme whose pictures can't be loaded falls back to the classic
+// looks for them
+func loadThemes(scope *assetScope) []*theme {
+	var shiny tower.GoalRenderer = circleGoals{}
+	if sheet, err := scope.picture("goal.png"); err != nil {
+		fmt.Println(err)
+	} else {
+		shiny = newSpriteGoals(sheet)
+	}
+	return []*theme{
+		{name: "classic", goals: circleGoals{}, skies: defaultSkies},
+		{name: "shiny", goals: shiny, skies: defaultSkies},
+	}
+}
+
+// themeByName is the theme with the name, the first one if there's none
+func themeByName(themes []*theme, name string) *theme {
+	for _, th := range themes {
+		if th.name == name {
+			return th
+		}
+	}
+	return themes[0]
+}
+
+func knownTheme(name string) bool {
+	for _, n := range themeNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// circleGoals are the classic goals, rings of their cycling colors
+type circleGoals struct{}
+
+func (circleGoals) Update(dt float64)                       {}
+func (circleGoals) Draw(imd *imdraw.IMDraw, g *entity.Goal) { g.Draw(imd) }
+func (circleGoals) Flush(t pixel.Target)                    {}
+
+// the star spins at spinRate frames a second, glowing up to glowSize times the goal's radius
+const (
+	spinRate = 12
+	glowSize = 2.2
+)
+
+// spriteGoals draw the goals as a spinning star from goal.png, a row of square frames, in the
+// goal's newest color with a glow of it around
+type spriteGoals struct {
+	frames []*pixel.Sprite
+	batch  *pixel.Batch
+	t      float64
+}
+
+func newSpriteGoals(sheet pixel.Picture) *spriteGoals {
+	sg := &spriteGoals{batch: pixel.NewBatch(&pixel.TrianglesData{}, sheet)}
+	for _, r := range sprite.SliceFrames(sheet, sheet.Bounds().H()) {
+		sg.frames = append(sg.frames, pixel.NewSprite(sheet, r))
+	}
+	return sg
+}
+
+func (sg *spriteGoals) Update(dt float64) {
+	sg.t += dt
+}
+
+func (sg *spriteGoals) Draw(imd *imdraw.IMDraw, g *entity.Goal) {
+	c := g.Cols[0]
+	if c == (pixel.RGBA{}) {
+		c = pixel.RGB(1, 1, 1)
+	}
+	// the glow pulses, fainter further out
+	pulse := 1 + 0.15*math.Sin(sg.t*5+g.Offset)
+	for i := 3; i >= 1; i-- {
+		imd.Color = c.Scaled(0.12 * float64(4-i))
+		imd.Push(g.Pos)
+		imd.Circle(g.Radius*glowSize*pulse*float64(i)/3, 0)
+	}
+
+	// every goal at its own point of the spin, the risky ones faster
+	rate := spinRate
+	if g.Risky {
+		rate *= 2
+	}
+	f := sg.frames[int(sg.t*float64(rate)+g.Offset)%len(sg.frames)]
+	s := 2 * g.Radius / f.Frame().W()
+	f.DrawColorMask(sg.batch, pixel.IM.Scaled(pixel.ZV, s).Moved(g.Pos), c)
+}
+
+func (sg *spriteGoals) Flush(t pixel.Target) {
+	sg.batch.Draw(t)
+	sg.batch.Clear()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"GoTower/internal/physics"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+)
+
+// a frame simulates at most maxSteps steps, after a hitch the tower slows down instead of every
+// frame taking longer to catch up. The gopher is drawn where it is when it moved more than
+// snapDistance in a step, it was put there rather than moved, like after dying.
+const (
+	maxSteps     = 8
+	snapDistance = 16
+)
+
+// heldActions are the actions that go with every step while they're held, the others happen once
+const heldActions = tower.InputLeft | tower.InputRight | tower.InputDown
+
+// fixedClock cuts the frames into steps of simStep, the time left over waits for the next frame
+type fixedClock struct {
+	acc float64
+	// held are the held actions of the frame, pending the others since the last step, so a jump
+	// pressed on a frame too short for a step isn't lost
+	held, pending byte
+	// from is the gopher before the last step, moved along with the tower
+	from pixel.Rect
+}
+
+// advance adds the frame's time and actions and returns how many steps to simulate
+func (fc *fixedClock) advance(dt float64, actions byte) int {
+	fc.held = actions & heldActions
+	fc.pending |= actions &^ heldActions
+	fc.acc += dt
+	n := int(fc.acc / tower.SimStep)
+	if n > maxSteps {
+		n, fc.acc = maxSteps, 0
+	} else {
+		fc.acc -= float64(n) * tower.SimStep
+	}
+	return n
+}
+
+// next is the actions of the next step, the pending ones go with the first
+func (fc *fixedClock) next() byte {
+	a := fc.held | fc.pending
+	fc.pending = 0
+	return a
+}
+
+// stepped remembers where the gopher was before a step, scroll is how far the tower scrolled in it
+func (fc *fixedClock) stepped(before pixel.Rect, scroll float64) {
+	fc.from = before.Moved(pixel.V(0, -scroll))
+}
+
+// drawn is the gopher where it's drawn: between where it was before the last step and where it
+// is now, by how far the frame is into the next step. It's a step behind, but moves smoothly at
+// any refresh rate.
+func (fc *fixedClock) drawn(gp *physics.Body) *physics.Body {
+	d := gp.Rect.Min.Sub(fc.from.Min)
+	if d.Len() > snapDistance {
+		return gp
+	}
+	at := *gp
+	at.Rect = gp.Rect.Moved(d.Scaled(fc.acc/tower.SimStep - 1))
+	return &at
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"GoTower/internal/challenge"
+	"GoTower/internal/profile"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/pixelgl"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// newTowerCode picks a random tower, the seeds are kept small so the codes stay short
+func newTowerCode() runCode {
+	return runCode{seed: time.Now().UnixNano() % (1 << 28)}
+}
+
+// titleScreen starts a run, on a new random tower, on the one from a code a friend shared or on
+// the one made from a seed phrase, with the mutators that are picked. Or on a community level,
+// from the level browser. Or in a ghost race, from the lobby.
+type titleScreen struct {
+	win   *pixelgl.Window
+	start func(rc runCode, muts []*tower.Mutator, lvl *customLevel)
+	// browse opens the level browser, it's nil without a level server
+	browse func(play func(lvl *customLevel))
+	// prof is shown under the title, editProfile opens the profile screen (HOME or a click on it)
+	prof        *playerProfile
+	editProfile func()
+	profRect    pixel.Rect
+	// lobby opens the ghost race lobby with the picked mutators, it's nil without a race server
+	lobby func(picked []*tower.Mutator)
+	// challenges are started with F10 (daily) and F11 (weekly), with the event's mutators only,
+	// the day and week go by the server's clock when there is one
+	challenges challenge.Current
+	clock      *netClock
+	clog       *challengeLog
+
+	// mutators are toggled with F1 to F8 or a click
+	mutators []*tower.Mutator
+	picked   []bool
+	mutRects []pixel.Rect
+
+	// fields are the code and the seed phrase, the selected one gets the typing
+	fields [2]string
+	sel    int
+	err    string
+	rects  [2]pixel.Rect
+	// spoken is the field the narrator read last, -1 before the screen was read at all
+	spoken int
+
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+const (
+	codeField = iota
+	seedField
+)
+
+// maxPhrase is the longest seed phrase, it has to fit in its field
+const maxPhrase = 28
+
+func newTitleScreen(win *pixelgl.Window, code string, mutators []*tower.Mutator, challenges challenge.Current, clock *netClock, clog *challengeLog, prof *playerProfile, editProfile func(), browse func(play func(lvl *customLevel)), lobby func(picked []*tower.Mutator), start func(rc runCode, muts []*tower.Mutator, lvl *customLevel)) *titleScreen {
+	// two columns of mutators at the bottom, in small print
+	var mutRects []pixel.Rect
+	for i := range mutators {
+		x, y := -150+float64(i%2)*155, -92-float64(i/2)*10
+		mutRects = append(mutRects, pixel.R(x, y-2, x+145, y+8))
+	}
+	return &titleScreen{
+		win:         win,
+		start:       start,
+		prof:        prof,
+		editProfile: editProfile,
+		profRect:    pixel.R(-60, 34, 60, 46),
+		browse:      browse,
+		lobby:       lobby,
+		challenges:  challenges,
+		clock:       clock,
+		clog:        clog,
+		mutators:    mutators,
+		picked:      make([]bool, len(mutators)),
+		mutRects:    mutRects,
+		fields:      [2]string{codeField: code},
+		spoken:      -1,
+		rects: [2]pixel.Rect{
+			codeField: pixel.R(-110, -48, 110, -32),
+			seedField: pixel.R(-110, -72, 110, -56),
+		},
+		imd: imdraw.New(nil),
+		txt: text.New(pixel.ZV, text.Atlas7x13),
+	}
+}
+
+// accepts is whether the rune can be typed in the field
+func (ts *titleScreen) accepts(field int, r rune) bool {
+	if field == seedField {
+		return r >= ' ' && r <= '~' && len(ts.fields[seedField]) < maxPhrase
+	}
+	return strings.ContainsRune(codeAlphabet+"-", r) || r >= 'a' && r <= 'z' || strings.ContainsRune(":/?=%._&", r)
+}
+
+// fieldNames are what the narrator calls the fields
+var fieldNames = [2]string{codeField: "tower code", seedField: "seed phrase"}
+
+func (ts *titleScreen) update(dt float64) {
+	win := ts.win
+	defer ts.narrate()
+	// the W and S of the WASD preset are typed in the fields, not switched between them
+	if ctl.typingJustPressed(bindUp) || ctl.typingJustPressed(bindDown) {
+		ts.sel = 1 - ts.sel
+	}
+	if win.JustPressed(pixelgl.MouseButtonLeft) {
+		mouse := mouseOnCanvas(win).Scaled(1 / interfaceZoom(canvasBounds))
+		for i, r := range ts.rects {
+			if r.Contains(mouse) {
+				ts.sel = i
+			}
+		}
+		for i, r := range ts.mutRects {
+			if r.Contains(mouse) {
+				ts.toggle(i)
+			}
+		}
+		if ts.profRect.Contains(mouse) {
+			ts.editProfile()
+			return
+		}
+	}
+	if win.JustPressed(pixelgl.KeyHome) {
+		ts.editProfile()
+		return
+	}
+	for i := range ts.mutators {
+		if win.JustPressed(pixelgl.KeyF1 + pixelgl.Button(i)) {
+			ts.toggle(i)
+		}
+	}
+	for i, key := range []pixelgl.Button{pixelgl.KeyF10, pixelgl.KeyF11} {
+		if win.JustPressed(key) {
+			c := ts.current()[i]
+			ts.start(runCode{seed: c.Seed, challenge: c.String()}, eventMutators(ts.mutators, ts.challenges.Event), nil)
+			return
+		}
+	}
+	if ts.browse != nil && win.JustPressed(pixelgl.KeyF9) {
+		ts.browse(func(lvl *customLevel) {
+			ts.start(newTowerCode(), ts.pickedMutators(), lvl)
+		})
+		return
+	}
+	if ts.lobby != nil && win.JustPressed(pixelgl.KeyF12) {
+		ts.lobby(ts.pickedMutators())
+		return
+	}
+
+	field := &ts.fields[ts.sel]
+	for _, r := range win.Typed() {
+		if ts.accepts(ts.sel, r) {
+			*field += string(r)
+			ts.err = ""
+		}
+	}
+	if (win.JustPressed(pixelgl.KeyBackspace) || win.Repeated(pixelgl.KeyBackspace)) && len(*field) > 0 {
+		*field = (*field)[:len(*field)-1]
+		ts.err = ""
+	}
+	if ctl.typingJustPressed(bindBack) {
+		*field, ts.err = "", ""
+	}
+	if !ctl.typingJustPressed(bindConfirm) {
+		return
+	}
+
+	rc := newTowerCode()
+	switch {
+	case *field == "":
+	case ts.sel == seedField:
+		rc = phraseCode(*field)
+	default:
+		var err error
+		if rc, err = parseRunCode(*field); err != nil {
+			ts.err = err.Error()
+			say(ts.err)
+			return
+		}
+	}
+	ts.start(rc, ts.pickedMutators(), nil)
+}
+
+// toggle turns mutator i on or off
+func (ts *titleScreen) toggle(i int) {
+	ts.picked[i] = !ts.picked[i]
+	if ts.picked[i] {
+		say(ts.mutators[i].Name + " on")
+	} else {
+		say(ts.mutators[i].Name + " off")
+	}
+}
+
+// narrate reads the field that was moved to, with how to start when the screen just came up
+func (ts *titleScreen) narrate() {
+	switch ts.spoken {
+	case ts.sel:
+		return
+	case -1:
+		say("Gopher Up. " + ctl.prompt("{confirm}") + " climbs a new tower, or the one of the code or seed phrase typed in. " + fieldNames[ts.sel])
+	default:
+		say(fieldNames[ts.sel] + " " + ts.fields[ts.sel])
+	}
+	ts.spoken = ts.sel
+}
+
+// current is the daily and weekly challenges right now
+func (ts *titleScreen) current() [2]challenge.Challenge {
+	now := ts.clock.now()
+	return [2]challenge.Challenge{challenge.DailyAt(now), challenge.WeeklyAt(now)}
+}
+
+func (ts *titleScreen) pickedMutators() []*tower.Mutator {
+	var muts []*tower.Mutator
+	for i, m := range ts.mutators {
+		if ts.picked[i] {
+			muts = append(muts, m)
+		}
+	}
+	return muts
+}
+
+func (ts *titleScreen) draw(canvas *pixelgl.Canvas) {
+	// it's laid out on the whole canvas
+	canvas.SetMatrix(interfaceMatrix(canvasBounds))
+
+	ts.txt.Clear()
+	ts.txt.Color = colornames.Gold
+	ts.txt.WriteString("GOPHER UP")
+	ts.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 2).Moved(pixel.V(-ts.txt.Bounds().W(), 50)))
+
+	ts.txt.Clear()
+	ts.txt.Color = profile.AvatarAt(ts.prof.Avatar).Color
+	ts.txt.WriteString("HOME " + ts.prof.shownName())
+	ts.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(pixel.V(-ts.txt.Bounds().W()*0.75/2, ts.profRect.Min.Y+2)))
+
+	ts.txt.Clear()
+	ts.txt.Color = colornames.Dimgray
+	if ts.browse != nil {
+		ts.txt.WriteString("F9 community levels\n")
+	}
+	if ts.lobby != nil {
+		ts.txt.WriteString("F12 ghost race")
+	}
+	ts.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(pixel.V(-150, 108)))
+
+	ts.txt.Clear()
+	ts.txt.Color = colornames.Dimgray
+	for i, c := range ts.current() {
+		ts.txt.WriteString([]string{"F10 ", "  F11 "}[i] + c.Kind)
+		if r, ok := ts.clog.Done[c.String()]; ok {
+			fmt.Fprintf(ts.txt, " (%d)", r.Score)
+		}
+	}
+	if ev := ts.challenges.Event; ev != nil {
+		ts.txt.Color = colornames.Gold
+		ts.txt.WriteString("\n" + ev.Name)
+	}
+	ts.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(pixel.V(150-ts.txt.Bounds().W()*0.75, 108)))
+
+	ts.txt.Clear()
+	ts.txt.Color = colornames.Lightgrey
+	switch {
+	case ts.fields[ts.sel] == "":
+		ts.txt.WriteString(ctl.prompt("{confirm} to climb a new tower\n\nor type a tower code or a seed"))
+	case ts.sel == seedField:
+		ts.txt.WriteString(ctl.prompt("{confirm} to climb this seed\n\n{back} for a new tower"))
+	default:
+		ts.txt.WriteString(ctl.prompt("{confirm} to climb this tower\n\n{back} for a new one"))
+	}
+	ts.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ts.txt.Bounds().W()/2, 10)))
+
+	// the fields, the selected one with a blinking cursor
+	labels := [2]string{codeField: "CODE", seedField: "SEED"}
+	for i, field := range ts.rects {
+		ts.imd.Clear()
+		ts.imd.Color = colornames.Dimgray
+		if i == ts.sel {
+			ts.imd.Color = colornames.Lightgrey
+		}
+		ts.imd.Push(field.Min, field.Max)
+		ts.imd.Rectangle(1)
+		ts.imd.Draw(canvas)
+
+		ts.txt.Clear()
+		ts.txt.Color = colornames.Dimgray
+		ts.txt.WriteString(labels[i])
+		ts.txt.Draw(canvas, pixel.IM.Moved(pixel.V(field.Min.X-ts.txt.Bounds().W()-6, field.Min.Y+4)))
+
+		ts.txt.Clear()
+		ts.txt.Color = colornames.White
+		shown := ts.fields[i]
+		if i == codeField && !strings.HasPrefix(strings.ToLower(shown), codeScheme) {
+			shown = strings.ToUpper(shown)
+		}
+		for ts.txt.BoundsOf(shown+"_").W() > field.W()-8 {
+			shown = shown[1:]
+		}
+		ts.txt.WriteString(shown)
+		if i == ts.sel && time.Now().UnixNano()/int64(time.Second/2)%2 == 0 {
+			ts.txt.WriteString("_")
+		}
+		ts.txt.Draw(canvas, pixel.IM.Moved(pixel.V(field.Min.X+4, field.Min.Y+4)))
+	}
+
+	if ts.err != "" {
+		ts.txt.Clear()
+		ts.txt.Color = colornames.Red
+		ts.txt.WriteString(ts.err)
+		ts.txt.Draw(canvas, pixel.IM.Moved(pixel.V(-ts.txt.Bounds().W()/2, -84)))
+	}
+
+	for i, m := range ts.mutators {
+		ts.txt.Clear()
+		ts.txt.Color = colornames.Dimgray
+		box := "[ ]"
+		if ts.picked[i] {
+			ts.txt.Color = colornames.Gold
+			box = "[x]"
+		}
+		fmt.Fprintf(ts.txt, "F%d %s %s", i+1, box, m.Name)
+		ts.txt.Draw(canvas, pixel.IM.Scaled(pixel.ZV, 0.75).Moved(ts.mutRects[i].Min.Add(pixel.V(0, 2))))
+	}
+}
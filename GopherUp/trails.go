@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io/ioutil"
+	"strings"
+
+	"GoTower/internal/physics"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/colornames"
+)
+
+// trail is a cosmetic particle trail behind the gopher, picked on the profile screen once it's
+// unlocked. They're data, in trails.json.
+type trail struct {
+	ID      string  `json:"id"`
+	Name    string  `json:"name"`
+	Unlock  unlock  `json:"unlock"`
+	Emitter emitter `json:"emitter"`
+}
+
+// unlock is the records a trail needs, all of the ones that are set
+type unlock struct {
+	Floor int `json:"floor,omitempty"`
+	Score int `json:"score,omitempty"`
+	Runs  int `json:"runs,omitempty"`
+}
+
+func (u unlock) met(st *stats) bool {
+	return int(st.BestHeight/physics.FloorHeight) >= u.Floor && st.BestScore >= u.Score && st.Runs >= u.Runs
+}
+
+// String is what has to be done, for the locked trails
+func (u unlock) String() string {
+	var needs []string
+	if u.Floor > 0 {
+		needs = append(needs, fmt.Sprintf("reach floor %d", u.Floor))
+	}
+	if u.Score > 0 {
+		needs = append(needs, fmt.Sprintf("score %d", u.Score))
+	}
+	if u.Runs > 0 {
+		needs = append(needs, fmt.Sprintf("play %d runs", u.Runs))
+	}
+	return strings.Join(needs, " and ")
+}
+
+// namedColor is the CSS color with the name, white if there's none
+func namedColor(name string) color.Color {
+	if c, ok := colornames.Map[strings.ToLower(name)]; ok {
+		return c
+	}
+	return colornames.White
+}
+
+// loadTrails reads the trails and checks they make sense
+func loadTrails(path string) ([]*trail, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading trails")
+	}
+	var trails []*trail
+	if err := json.Unmarshal(data, &trails); err != nil {
+		return nil, errors.Wrapf(err, "error loading trails from %s", path)
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for i, t := range trails {
+		where := fmt.Sprintf("%s: trail %d (%s)", path, i, t.ID)
+		if t.ID == "" || t.Name == "" {
+			problems = append(problems, where+": needs an id and a name")
+		}
+		if seen[t.ID] {
+			problems = append(problems, where+": duplicate id")
+		}
+		seen[t.ID] = true
+		em := t.Emitter
+		if em.Rate <= 0 || em.Life <= 0 || em.Size <= 0 {
+			problems = append(problems, where+": the rate, life and size have to be positive")
+		}
+		for _, name := range em.Colors {
+			if _, ok := colornames.Map[strings.ToLower(name)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: no color called %q", where, name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return trails, nil
+}
+
+// trailByID is the trail with the id, nil for none
+func trailByID(trails []*trail, id string) *trail {
+	for _, t := range trails {
+		if t.ID == id {
+			return t
+		}
+	}
+	return nil
+}
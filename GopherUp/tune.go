@@ -0,0 +1,106 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// the music is synthesized when the game starts, there are no audio files to ship
+
+// tuneBPM is the tempo of the music at normal speed
+const tuneBPM = 132
+
+// voice is one line of the tune, notes are "name:beats" separated by spaces, like "A4:1 C5:.5",
+// with "-" for a rest
+type voice struct {
+	wave   func(phase float64) float64
+	volume float64
+	notes  string
+}
+
+var gameTune = []voice{
+	{square(0.25), 0.12, "A4:.5 C5:.5 E5:.5 A5:.5 G5:.5 E5:.5 C5:.5 E5:.5 " +
+		"F4:.5 A4:.5 C5:.5 F5:.5 E5:.5 C5:.5 A4:.5 C5:.5 " +
+		"G4:.5 B4:.5 D5:.5 G5:.5 F5:.5 D5:.5 B4:.5 D5:.5 " +
+		"E5:.5 -:.5 E5:.5 G#5:.5 B5:1 -:1"},
+	{triangle, 0.3, "A2:1 A2:1 A3:1 A2:1 F2:1 F2:1 F3:1 F2:1 G2:1 G2:1 G3:1 G2:1 E2:1 E2:1 E3:1 E2:1"},
+}
+
+func square(duty float64) func(float64) float64 {
+	return func(phase float64) float64 {
+		if phase < duty {
+			return 1
+		}
+		return -1
+	}
+}
+
+func triangle(phase float64) float64 {
+	return 4*math.Abs(phase-0.5) - 1
+}
+
+// pitch is the frequency of a note name like A4 or G#5, 0 for anything else
+func pitch(name string) float64 {
+	semis := map[byte]int{'C': -9, 'D': -7, 'E': -5, 'F': -4, 'G': -2, 'A': 0, 'B': 2}
+	if len(name) < 2 {
+		return 0
+	}
+	n, ok := semis[name[0]]
+	if !ok {
+		return 0
+	}
+	rest := name[1:]
+	if rest[0] == '#' {
+		n++
+		rest = rest[1:]
+	}
+	octave, err := strconv.Atoi(rest)
+	if err != nil {
+		return 0
+	}
+	n += (octave - 4) * 12
+	return 440 * math.Pow(2, float64(n)/12)
+}
+
+// renderTune plays the voices into one loop of stereo samples, the voices' loops all have to be
+// as long
+func renderTune(sampleRate int, voices []voice) [][2]float64 {
+	beat := float64(sampleRate) * 60 / tuneBPM
+	var out [][2]float64
+	for _, v := range voices {
+		at := 0
+		for _, n := range strings.Fields(v.notes) {
+			parts := strings.SplitN(n, ":", 2)
+			beats, _ := strconv.ParseFloat(parts[len(parts)-1], 64)
+			length := int(beats * beat)
+			for len(out) < at+length {
+				out = append(out, [2]float64{})
+			}
+			if freq := pitch(parts[0]); freq > 0 {
+				for i := 0; i < length; i++ {
+					// a pluck, loud at the start and dying away over the note
+					env := math.Exp(-3*float64(i)/float64(length)) * math.Min(1, float64(i)/64)
+					x := v.volume * env * v.wave(math.Mod(float64(i)*freq/float64(sampleRate), 1))
+					out[at+i][0] += x
+					out[at+i][1] += x
+				}
+			}
+			at += length
+		}
+	}
+	return out
+}
+
+// renderSweep is a sound effect, a tone sliding from one frequency to another and dying away
+func renderSweep(sampleRate int, wave func(float64) float64, from, to, seconds, volume float64) [][2]float64 {
+	out := make([][2]float64, int(seconds*float64(sampleRate)))
+	phase := 0.0
+	for i := range out {
+		t := float64(i) / float64(len(out))
+		phase = math.Mod(phase+(from+(to-from)*t)/float64(sampleRate), 1)
+		x := volume * (1 - t) * wave(phase)
+		out[i] = [2]float64{x, x}
+	}
+	return out
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"GoTower/internal/render"
+	"GoTower/internal/sprite"
+	"GoTower/internal/tower"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"github.com/faiface/pixel/text"
+	"github.com/pkg/errors"
+	"golang.org/x/image/colornames"
+)
+
+const (
+	// videoFPS is the frame rate of exported videos, GIFs count in hundredths of a second so it
+	// has to divide 100
+	videoFPS = 25
+	// videoScale is how many video pixels a canvas pixel gets, GIFs keep every frame in memory
+	// until the end, so they're left at 1
+	videoScale = 2
+)
+
+// videoEncoder takes the frames of a video one by one
+type videoEncoder interface {
+	frame(img *image.RGBA) error
+	close() error
+}
+
+// gifEncoder keeps the frames, made paletted, and writes the GIF at the end
+type gifEncoder struct {
+	path string
+	gif  gif.GIF
+}
+
+func (ge *gifEncoder) frame(img *image.RGBA) error {
+	p := image.NewPaletted(img.Bounds(), palette.Plan9)
+	// no dithering, the pixel art stays flat
+	draw.Draw(p, p.Rect, img, img.Rect.Min, draw.Src)
+	ge.gif.Image = append(ge.gif.Image, p)
+	ge.gif.Delay = append(ge.gif.Delay, 100/videoFPS)
+	return nil
+}
+
+func (ge *gifEncoder) close() error {
+	f, err := os.Create(ge.path)
+	if err != nil {
+		return err
+	}
+	if err := gif.EncodeAll(f, &ge.gif); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ffmpegEncoder pipes the raw frames to ffmpeg, which picks the format from the file name
+type ffmpegEncoder struct {
+	cmd *exec.Cmd
+	in  io.WriteCloser
+}
+
+func newFFmpegEncoder(path string, width, height int) (*ffmpegEncoder, error) {
+	cmd := exec.Command("ffmpeg", "-y", "-loglevel", "error",
+		"-f", "rawvideo", "-pix_fmt", "rgba", "-s", fmt.Sprintf("%dx%d", width, height), "-r", fmt.Sprint(videoFPS), "-i", "-",
+		"-pix_fmt", "yuv420p", path,
+	)
+	cmd.Stderr = os.Stderr
+	in, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "error starting ffmpeg, only GIFs can be made without it")
+	}
+	return &ffmpegEncoder{cmd: cmd, in: in}, nil
+}
+
+func (fe *ffmpegEncoder) frame(img *image.RGBA) error {
+	_, err := fe.in.Write(img.Pix)
+	return err
+}
+
+func (fe *ffmpegEncoder) close() error {
+	fe.in.Close()
+	return fe.cmd.Wait()
+}
+
+// stamp writes the tower's code, the mode, the floor and the score in the bottom left corner of a
+// frame, so a clip that's shared around is also a challenge anyone can play. The text keeps its
+// size whatever the camera's zoom.
+type stamp struct {
+	r   *replay
+	imd *imdraw.IMDraw
+	txt *text.Text
+}
+
+func newStamp(r *replay) *stamp {
+	return &stamp{r: r, imd: imdraw.New(nil), txt: text.New(pixel.ZV, text.Atlas7x13)}
+}
+
+// mode is the movement style and the assists the replay was played with
+func (sp *stamp) mode() string {
+	mode := sp.r.Style
+	if mode == "" {
+		mode = tower.MoveStyles[0].Name
+	}
+	if sp.r.Assist {
+		mode += ", assist"
+	}
+	if sp.r.Adaptive {
+		mode += ", adaptive"
+	}
+	return mode
+}
+
+func (sp *stamp) draw(st *softTarget, s *tower.Sim, zoom float64) {
+	sp.txt.Clear()
+	sp.txt.Color = colornames.White
+	fmt.Fprintf(sp.txt, "%s  %s\nfloor %d  score %d", runCode{seed: sp.r.Seed}, sp.mode(), s.Phys.Floor, tower.Score)
+
+	m := pixel.IM.Scaled(pixel.ZV, 1/zoom).Moved(st.bounds.Min.Add(pixel.V(4, 4).Scaled(1 / zoom)))
+	b := sp.txt.Bounds()
+	box := pixel.R(b.Min.X-2, b.Min.Y-1, b.Max.X+2, b.Max.Y+1)
+	m = pixel.IM.Moved(box.Min.Scaled(-1)).Chained(m)
+	sp.imd.Clear()
+	sp.imd.SetMatrix(m)
+	sp.imd.Color = pixel.RGBA{A: 0.6}
+	sp.imd.Push(box.Min, box.Max)
+	sp.imd.Rectangle(0)
+	sp.imd.Draw(st)
+	sp.txt.Draw(st, m)
+}
+
+// renderReplay plays the replay headless, draws the frames in memory and encodes them to out, a
+// GIF or any video ffmpeg can write, as seen by a camera with the profile. Stamped, the frames have
+// the tower's code, the mode, the floor and the score in a corner.
+func renderReplay(r *replay, chunks []*tower.Chunk, picks []*tower.Mutator, anims map[string][]sprite.Frame, profile render.CameraProfile, stamped bool, out string) (err error) {
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error rendering replay")
+		}
+	}()
+
+	scale := float64(videoScale)
+	var enc videoEncoder
+	if strings.EqualFold(filepath.Ext(out), ".gif") {
+		scale = 1
+		enc = &gifEncoder{path: out}
+	} else {
+		w, h := int(canvasBounds.W()*scale), int(canvasBounds.H()*scale)
+		if enc, err = newFFmpegEncoder(out, w, h); err != nil {
+			return err
+		}
+	}
+
+	anim := render.NewGopherAnim(anims)
+	cam := render.NewCamera(profile, canvasBounds)
+	imd := imdraw.New(nil)
+	imd.Precision = 32
+	sp := newStamp(r)
+
+	t, next := 0.0, 0.0
+	r.play(chunks, picks, func(i int, s *tower.Sim) {
+		anim.Update(r.Step, s.Phys)
+		cam.Update(r.Step, s.Phys, 0)
+		t += r.Step
+		if t < next || err != nil {
+			return
+		}
+		next += 1.0 / videoFPS
+
+		// the camera's view fills the same frame, a wider one with smaller pixels
+		st := newSoftTarget(cam.View(), scale*cam.Zoom)
+		draw.Draw(st.img, st.img.Rect, image.Black, image.Point{}, draw.Src)
+		s.Decor.Draw(st)
+		imd.Clear()
+		s.DrawTower(imd, nil, circleGoals{})
+		imd.Draw(st)
+		anim.Draw(st, s.Phys)
+		if stamped {
+			sp.draw(st, s, cam.Zoom)
+		}
+		err = enc.frame(st.img)
+	})
+	if err != nil {
+		enc.close()
+		return err
+	}
+	return enc.close()
+}
@@ -0,0 +1,29 @@
+// gotower-balance plays headless games of Gopher Up with the bot, no window or GPU needed, for
+// every difficulty config of the balance harness and prints how long the bot survived in each, so
+// difficulty changes can be compared with numbers.
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"GoTower/internal/tower"
+)
+
+func main() {
+	runs := flag.Int("runs", 1000, "headless runs per difficulty config")
+	seed := flag.Int64("seed", 1, "seed of the first run, run i of every config plays the tower of seed+i")
+	maxTime := flag.Float64("time", 300, "longest run, in seconds")
+	chunks := flag.String("chunks", "GopherUp/chunks.json", "chunk library the towers are built from")
+	flag.Parse()
+	if *runs <= 0 {
+		log.Fatal("-runs has to be at least 1")
+	}
+
+	lib, err := tower.LoadChunks(*chunks)
+	if err != nil {
+		log.Fatal(err)
+	}
+	tower.Balance(os.Stdout, lib, *runs, *seed, *maxTime)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"GoTower/internal/challenge"
+
+	"github.com/pkg/errors"
+)
+
+// challenges serves the daily and weekly challenges and the event going on, the events are
+// planned in a JSON file
+type challenges struct {
+	events []challenge.Event
+}
+
+func loadChallenges(path string) (*challenges, error) {
+	c := &challenges{}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading events")
+	}
+	if err := json.Unmarshal(data, &c.events); err != nil {
+		return nil, errors.Wrapf(err, "error loading events from %s", path)
+	}
+	for _, e := range c.events {
+		if !e.Until.After(e.From) {
+			return nil, errors.Errorf("error loading events from %s: %s ends before it starts", path, e.Name)
+		}
+	}
+	return c, nil
+}
+
+// current is what's going on at t, the first event planned for then wins
+func (c *challenges) current(t time.Time) challenge.Current {
+	cur := challenge.At(t)
+	for i, e := range c.events {
+		if !t.Before(e.From) && t.Before(e.Until) {
+			cur.Event = &c.events[i]
+			break
+		}
+	}
+	return cur
+}
+
+func (c *challenges) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.current(time.Now()))
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"GoTower/internal/levels"
+
+	"github.com/pkg/errors"
+)
+
+// storedLevel is a level with its ratings, by rater
+type storedLevel struct {
+	levels.Level
+	Stars map[string]int `json:"stars"`
+}
+
+func (sl *storedLevel) listing(id string) levels.Listing {
+	l := levels.Listing{ID: id, Name: sl.Name, Author: sl.Author, Difficulty: sl.Difficulty, Ratings: len(sl.Stars)}
+	for _, s := range sl.Stars {
+		l.Rating += float64(s)
+	}
+	if l.Ratings > 0 {
+		l.Rating /= float64(l.Ratings)
+	}
+	return l
+}
+
+// library is the custom levels, saved to a JSON file after every upload and rating
+type library struct {
+	path string
+
+	mu     sync.Mutex
+	levels map[string]*storedLevel
+}
+
+func loadLibrary(path string) (*library, error) {
+	lib := &library{path: path, levels: make(map[string]*storedLevel)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return lib, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading levels")
+	}
+	if err := json.Unmarshal(data, &lib.levels); err != nil {
+		return nil, errors.Wrapf(err, "error loading levels from %s", path)
+	}
+	return lib, nil
+}
+
+// save writes the library, the lock has to be held
+func (lib *library) save() error {
+	data, err := json.MarshalIndent(lib.levels, "", "\t")
+	if err != nil {
+		return errors.Wrap(err, "error saving levels")
+	}
+	return errors.Wrap(ioutil.WriteFile(lib.path, data, 0644), "error saving levels")
+}
+
+// add puts a checked level in the library, false if it was already there
+func (lib *library) add(l levels.Level) (string, bool, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	id := l.ID()
+	if _, ok := lib.levels[id]; ok {
+		return id, false, nil
+	}
+	lib.levels[id] = &storedLevel{Level: l, Stars: map[string]int{}}
+	return id, true, lib.save()
+}
+
+// rate records a verified rating, an install rating again replaces its stars
+func (lib *library) rate(r levels.Rating) (bool, error) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	sl, ok := lib.levels[r.Level]
+	if !ok {
+		return false, nil
+	}
+	sl.Stars[r.Rater()] = r.Stars
+	return true, lib.save()
+}
+
+// list is every level, the best rated first
+func (lib *library) list() []levels.Listing {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	list := []levels.Listing{}
+	for id, sl := range lib.levels {
+		list = append(list, sl.listing(id))
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Rating != list[j].Rating {
+			return list[i].Rating > list[j].Rating
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
+
+func (lib *library) get(id string) (levels.Level, bool) {
+	lib.mu.Lock()
+	defer lib.mu.Unlock()
+	sl, ok := lib.levels[id]
+	if !ok {
+		return levels.Level{}, false
+	}
+	return sl.Level, true
+}
+
+// ServeHTTP serves /levels (the list, and uploads), /levels/<id> (the level) and
+// /levels/<id>/rating (ratings)
+func (lib *library) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/levels"), "/"), "/")
+	switch {
+	case parts[0] == "" && r.Method == http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(lib.list())
+
+	case parts[0] == "" && r.Method == http.MethodPost:
+		var l levels.Level
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&l); err != nil {
+			http.Error(w, "bad level", http.StatusBadRequest)
+			return
+		}
+		if err := l.Check(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		id, added, err := lib.add(l)
+		if err != nil {
+			log.Println(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if added {
+			w.WriteHeader(http.StatusCreated)
+		}
+		json.NewEncoder(w).Encode(levels.Listing{ID: id, Name: l.Name, Author: l.Author, Difficulty: l.Difficulty})
+
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		l, ok := lib.get(parts[0])
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(l)
+
+	case len(parts) == 2 && parts[1] == "rating" && r.Method == http.MethodPost:
+		var rating levels.Rating
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&rating); err != nil {
+			http.Error(w, "bad rating", http.StatusBadRequest)
+			return
+		}
+		rating.Level = parts[0]
+		if err := rating.Verify(); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		found, err := lib.rate(rating)
+		if err != nil {
+			log.Println(err)
+		}
+		if !found {
+			http.NotFound(w, r)
+		}
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
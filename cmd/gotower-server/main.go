@@ -0,0 +1,155 @@
+// gotower-server keeps the leaderboard: it takes signed score submissions from the games,
+// checks the signatures, drops the ones it already has and serves the best scores. It also keeps
+// the custom levels the players upload and rate, and says what the daily and weekly challenges
+// are. Players race each other's ghosts in its rooms.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"GoTower/internal/leaderboard"
+
+	"github.com/pkg/errors"
+)
+
+// board is the leaderboard, saved to a JSON file after every new score
+type board struct {
+	path string
+
+	mu     sync.Mutex
+	scores []leaderboard.Submission
+	ids    map[string]bool
+}
+
+func loadBoard(path string) (*board, error) {
+	b := &board{path: path, ids: make(map[string]bool)}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading scores")
+	}
+	if err := json.Unmarshal(data, &b.scores); err != nil {
+		return nil, errors.Wrapf(err, "error loading scores from %s", path)
+	}
+	for i := range b.scores {
+		b.ids[b.scores[i].ID()] = true
+	}
+	return b, nil
+}
+
+// add puts a verified submission on the board, false if it was already there
+func (b *board) add(sub leaderboard.Submission) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.ids[sub.ID()] {
+		return false, nil
+	}
+	b.ids[sub.ID()] = true
+	b.scores = append(b.scores, sub)
+	sort.SliceStable(b.scores, func(i, j int) bool { return b.scores[i].Score.Score > b.scores[j].Score.Score })
+
+	data, err := json.MarshalIndent(b.scores, "", "\t")
+	if err != nil {
+		return true, errors.Wrap(err, "error saving scores")
+	}
+	return true, errors.Wrap(ioutil.WriteFile(b.path, data, 0644), "error saving scores")
+}
+
+// entry is a score as the board shows it
+type entry struct {
+	Player string `json:"player"`
+	leaderboard.Score
+}
+
+// top is the best n scores, only the ones on the tower code and with the movement style if they
+// aren't empty
+func (b *board) top(n int, code, style string) []entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	top := []entry{}
+	for i := range b.scores {
+		if len(top) == n {
+			break
+		}
+		if (code == "" || b.scores[i].Code == code) && (style == "" || b.scores[i].StyleOf() == style) {
+			top = append(top, entry{b.scores[i].Player(), b.scores[i].Score})
+		}
+	}
+	return top
+}
+
+func (b *board) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		n, err := strconv.Atoi(r.URL.Query().Get("n"))
+		if err != nil || n <= 0 || n > 100 {
+			n = 10
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(b.top(n, r.URL.Query().Get("code"), r.URL.Query().Get("style")))
+
+	case http.MethodPost:
+		var sub leaderboard.Submission
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&sub); err != nil {
+			http.Error(w, "bad submission", http.StatusBadRequest)
+			return
+		}
+		if err := sub.Verify(); err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		added, err := b.add(sub)
+		if err != nil {
+			log.Println(err)
+		}
+		if !added {
+			// already have it, the game may have retried after a lost response
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func main() {
+	addr := flag.String("addr", ":8080", "address to listen on")
+	path := flag.String("scores", "scores.json", "file to keep the scores in")
+	levelsPath := flag.String("levels", "levels.json", "file to keep the custom levels in")
+	eventsPath := flag.String("events", "events.json", "file with the planned challenge events")
+	flag.Parse()
+
+	b, err := loadBoard(*path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	lib, err := loadLibrary(*levelsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ch, err := loadChallenges(*eventsPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	http.Handle("/scores", b)
+	http.Handle("/challenges", ch)
+	rs := newRooms()
+	http.Handle("/rooms", rs)
+	http.Handle("/rooms/", rs)
+	http.Handle("/levels", lib)
+	http.Handle("/levels/", lib)
+	log.Printf("leaderboard on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
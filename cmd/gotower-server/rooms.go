@@ -0,0 +1,220 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"GoTower/internal/lobby"
+	"GoTower/internal/profile"
+)
+
+// roomIdle is how long a room nobody posts to is kept
+const roomIdle = 10 * time.Minute
+
+// roomCodeAlphabet leaves out the letters that look like digits, the codes are read out loud
+const roomCodeAlphabet = "ABCDEFGHJKMNPQRSTVWXYZ"
+
+// the server keeps up to maxRooms rooms at once and tries codeTries random codes for a new one,
+// a small share of the codes there are, so a free one is found right away until it's full
+const (
+	maxRooms  = 2000
+	codeTries = 20
+)
+
+type room struct {
+	lobby.Room
+	tokens []string
+	seen   time.Time
+}
+
+// rooms keeps the ghost race rooms, in memory only, a race doesn't outlive the server
+type rooms struct {
+	mu    sync.Mutex
+	rooms map[string]*room
+}
+
+func newRooms() *rooms {
+	return &rooms{rooms: make(map[string]*room)}
+}
+
+func randomString(alphabet string, n int) string {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		k, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			panic(err)
+		}
+		sb.WriteByte(alphabet[k.Int64()])
+	}
+	return sb.String()
+}
+
+// join adds a player to the room, the lock has to be held
+func (r *room) join(name string, avatar int) lobby.Joined {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		panic(err)
+	}
+	id := len(r.Players)
+	if avatar < 0 || avatar >= len(profile.Avatars) {
+		avatar = 0
+	}
+	r.Players = append(r.Players, lobby.Player{ID: id, Name: name, Avatar: avatar})
+	r.tokens = append(r.tokens, hex.EncodeToString(token))
+	return lobby.Joined{Room: r.Room, ID: id, Token: r.tokens[id]}
+}
+
+// player is the one with the token, -1 for nobody
+func (r *room) player(token string) int {
+	for i, t := range r.tokens {
+		if t == token {
+			return i
+		}
+	}
+	return -1
+}
+
+// cleanName makes a name fit the room, it's only shown to the others
+func cleanName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if len(name) > lobby.MaxName {
+		name = name[:lobby.MaxName]
+	}
+	if name == "" {
+		name = "gopher"
+	}
+	return name
+}
+
+// ServeHTTP serves POST /rooms (a new room), GET /rooms/<code> and POST /rooms/<code>/join and
+// /rooms/<code>/update
+func (rs *rooms) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/rooms"), "/"), "/")
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	now := time.Now()
+	for code, rm := range rs.rooms {
+		if now.Sub(rm.seen) > roomIdle {
+			delete(rs.rooms, code)
+		}
+	}
+	answer := func(v interface{}) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(v)
+	}
+
+	if parts[0] == "" {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var c lobby.Create
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&c); err != nil {
+			http.Error(w, "bad room", http.StatusBadRequest)
+			return
+		}
+		if len(rs.rooms) >= maxRooms {
+			http.Error(w, "too many rooms, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		code := ""
+		for i := 0; i < codeTries && code == ""; i++ {
+			if c := randomString(roomCodeAlphabet, 4); rs.rooms[c] == nil {
+				code = c
+			}
+		}
+		if code == "" {
+			http.Error(w, "no free room code, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		rm := &room{Room: lobby.Room{Code: code, Seed: c.Seed, Mutators: c.Mutators, Players: []lobby.Player{}}, seen: now}
+		rs.rooms[code] = rm
+		joined := rm.join(cleanName(c.Name), c.Avatar)
+		joined.Room.Now = now
+		w.WriteHeader(http.StatusCreated)
+		answer(joined)
+		return
+	}
+
+	rm := rs.rooms[strings.ToUpper(parts[0])]
+	if rm == nil {
+		http.Error(w, "no such room", http.StatusNotFound)
+		return
+	}
+	rm.seen = now
+	switch {
+	case len(parts) == 1 && r.Method == http.MethodGet:
+		rm.Now = now
+		answer(rm.Room)
+
+	case len(parts) == 2 && parts[1] == "join" && r.Method == http.MethodPost:
+		var j lobby.Join
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&j); err != nil {
+			http.Error(w, "bad join", http.StatusBadRequest)
+			return
+		}
+		if !rm.StartAt.IsZero() {
+			http.Error(w, "the race has started", http.StatusConflict)
+			return
+		}
+		if len(rm.Players) >= lobby.MaxPlayers {
+			http.Error(w, "the room is full", http.StatusConflict)
+			return
+		}
+		joined := rm.join(cleanName(j.Name), j.Avatar)
+		joined.Room.Now = now
+		answer(joined)
+
+	case len(parts) == 2 && parts[1] == "update" && r.Method == http.MethodPost:
+		var u lobby.Update
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<12)).Decode(&u); err != nil {
+			http.Error(w, "bad update", http.StatusBadRequest)
+			return
+		}
+		id := rm.player(u.Token)
+		if id < 0 {
+			http.Error(w, "not in the room", http.StatusForbidden)
+			return
+		}
+		// a bad update changes nothing
+		if u.Emote < 0 || u.Emote > len(lobby.QuickChat) {
+			http.Error(w, "bad emote", http.StatusBadRequest)
+			return
+		}
+		p := &rm.Players[id]
+		if u.Ready && rm.StartAt.IsZero() {
+			p.Ready = true
+			// the race starts once there are two racers and all of them are ready
+			all := len(rm.Players) > 1
+			for _, q := range rm.Players {
+				all = all && q.Ready
+			}
+			if all {
+				rm.StartAt = now.Add(lobby.Countdown)
+			}
+		}
+		if u.Ghost != nil && u.Ghost.Tick >= p.Ghost.Tick {
+			p.Ghost = *u.Ghost
+		}
+		// saying things too often is ignored
+		if u.Emote > 0 && now.Sub(p.EmoteAt) >= lobby.EmoteGap {
+			p.Emote, p.EmoteAt = u.Emote, now
+		}
+		rm.Now = now
+		answer(rm.Room)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
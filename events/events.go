@@ -0,0 +1,146 @@
+// Package events is the game's event bus seen from outside the game: the gameplay moments as
+// exported types, for tools, overlays and analytics to subscribe to. A package that wants them
+// subscribes from its init and is linked into the game with a blank import in the game's
+// plugins.go. The same events reach the Lua mods through their onEvent hook, by Kind and with the
+// fields named like the JSON ones.
+package events
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Event is one of the types below
+type Event interface{}
+
+// the events, X and Y are in the world coordinates of the screen, x from -160 to 160 and y from
+// -120 to 120, Score is the score of the run right after the event
+type (
+	// RunStarted is a new run, at the start of a tower or right after the gopher died
+	RunStarted struct {
+		Code string `json:"code"`
+	}
+	Jumped struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+	}
+	Landed struct {
+		X     float64 `json:"x"`
+		Y     float64 `json:"y"`
+		Speed float64 `json:"speed"`
+	}
+	GoalCollected struct {
+		X     float64 `json:"x"`
+		Y     float64 `json:"y"`
+		Value int     `json:"value"`
+		Score int     `json:"score"`
+	}
+	FloorReached struct {
+		Floor  int     `json:"floor"`
+		Height float64 `json:"height"`
+	}
+	// LifeLost is a death an extra life took, the run goes on
+	LifeLost struct {
+		Cause string `json:"cause"`
+		Left  int    `json:"left"`
+	}
+	// Died ends the run, Cause is what killed the gopher: fall, boss, saw, spikes or flame
+	Died struct {
+		Cause  string  `json:"cause"`
+		Height float64 `json:"height"`
+		Score  int     `json:"score"`
+	}
+	BossSurvived struct {
+		Bonus int `json:"bonus"`
+		Score int `json:"score"`
+	}
+	PrestigeReached struct {
+		Tier int `json:"tier"`
+	}
+	// FeatureUsed is for the things outside the gameplay: pause, slowmo, settings, restart...
+	FeatureUsed struct {
+		Name string `json:"name"`
+	}
+)
+
+// Kind is the name of the event's type, like "goalCollected", "" for anything that isn't one
+func Kind(e Event) string {
+	switch e.(type) {
+	case RunStarted:
+		return "runStarted"
+	case Jumped:
+		return "jumped"
+	case Landed:
+		return "landed"
+	case GoalCollected:
+		return "goalCollected"
+	case FloorReached:
+		return "floorReached"
+	case LifeLost:
+		return "lifeLost"
+	case Died:
+		return "died"
+	case BossSurvived:
+		return "bossSurvived"
+	case PrestigeReached:
+		return "prestigeReached"
+	case FeatureUsed:
+		return "featureUsed"
+	}
+	return ""
+}
+
+// Fields are the event's fields by their JSON names, for the Lua mods and anything else that
+// isn't Go
+func Fields(e Event) map[string]interface{} {
+	fields := map[string]interface{}{}
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fields
+	}
+	json.Unmarshal(data, &fields)
+	return fields
+}
+
+// subscriber is a handler and the number it's unsubscribed by
+type subscriber struct {
+	id     int
+	handle func(Event)
+}
+
+var (
+	mu     sync.Mutex
+	subs   []subscriber
+	nextID int
+)
+
+// Subscribe calls handle with every event from now on, until the returned unsubscribe is called.
+// handle runs on the game's loop, in the middle of a frame, anything slow has to be handed off to a
+// goroutine of its own.
+func Subscribe(handle func(Event)) (unsubscribe func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	nextID++
+	id := nextID
+	subs = append(subs, subscriber{id, handle})
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for i, s := range subs {
+			if s.id == id {
+				subs = append(subs[:i:i], subs[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish hands the event to every subscriber, in the order they subscribed, it's for the game
+func Publish(e Event) {
+	mu.Lock()
+	current := subs
+	mu.Unlock()
+	for _, s := range current {
+		s.handle(e)
+	}
+}
@@ -0,0 +1,74 @@
+// Package challenge has the daily and weekly challenges, shared by the game and the server. A
+// challenge's seed is worked out from its day or week (in UTC), so the game can make it up by
+// itself when it's offline, the server adds the event going on, if there is one.
+package challenge
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// the kinds of challenges
+const (
+	Daily  = "daily"
+	Weekly = "weekly"
+)
+
+// Challenge is the tower everyone plays for a day or a week
+type Challenge struct {
+	Kind string `json:"kind"`
+	// Period is the day, like 2026-10-15, or the ISO week, like 2026-W42
+	Period string `json:"period"`
+	Seed   int64  `json:"seed"`
+}
+
+// String is how the game shows it, like "daily 2026-10-15"
+func (c Challenge) String() string {
+	return c.Kind + " " + c.Period
+}
+
+// Event changes the rules of the challenges for a while, like a low gravity week
+type Event struct {
+	Name string `json:"name"`
+	// Mutators are the ids of the game's mutators the challenges are played with
+	Mutators []string  `json:"mutators"`
+	From     time.Time `json:"from"`
+	Until    time.Time `json:"until"`
+}
+
+// Current is what the server says is going on
+type Current struct {
+	Daily  Challenge `json:"daily"`
+	Weekly Challenge `json:"weekly"`
+	Event  *Event    `json:"event,omitempty"`
+	// Now is the server's clock, the games go by it for the challenges so they can't be played
+	// again by changing the time zone or the date
+	Now time.Time `json:"now"`
+}
+
+// seed is the same for the same period everywhere, cut to the size of a random tower's seed to
+// keep its code as short
+func seed(kind, period string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(kind + ":" + period))
+	return int64(h.Sum64() % (1 << 28))
+}
+
+// DailyAt is the daily challenge at t
+func DailyAt(t time.Time) Challenge {
+	period := t.UTC().Format("2006-01-02")
+	return Challenge{Kind: Daily, Period: period, Seed: seed(Daily, period)}
+}
+
+// WeeklyAt is the weekly challenge at t
+func WeeklyAt(t time.Time) Challenge {
+	year, week := t.UTC().ISOWeek()
+	period := fmt.Sprintf("%d-W%02d", year, week)
+	return Challenge{Kind: Weekly, Period: period, Seed: seed(Weekly, period)}
+}
+
+// At is the challenges at t without an event, what the game falls back to offline
+func At(t time.Time) Current {
+	return Current{Daily: DailyAt(t), Weekly: WeeklyAt(t), Now: t}
+}
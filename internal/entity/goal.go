@@ -0,0 +1,112 @@
+package entity
+
+import (
+	"math"
+	"math/rand"
+
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// Goal is picked up for points when the gopher touches it
+type Goal struct {
+	Pos    pixel.Vec
+	Radius float64
+	Step   float64
+	Value  int
+
+	Counter float64
+	Cols    [5]pixel.RGBA
+	// Palette is the colors the goal cycles through, it makes them up as it goes without one, and
+	// Shade how far through it the cycle is
+	Palette []pixel.RGBA
+	Shade   int
+
+	// Vel is how fast the goal is flying at the gopher, see Attract
+	Vel pixel.Vec
+
+	// On is the platform the goal rides along with, Offset from its left end, zero for none
+	On     PlatformID
+	Offset float64
+	// Risky goals are on the hard platforms and worth more, Bonus ones come on top of the usual two
+	Risky bool
+	Bonus bool
+}
+
+// a goal within attractRadius of the gopher speeds up towards it at attractAccel, up to
+// attractSpeed, and slows down again at the same rate once the gopher is gone
+const (
+	attractRadius = 24
+	attractAccel  = 900
+	attractSpeed  = 300
+)
+
+// Attract steers the goal towards the gopher at at when it's close, magnet multiplies how close
+func (g *Goal) Attract(dt float64, at pixel.Vec, magnet float64) {
+	to := at.Sub(g.Pos)
+	want := pixel.ZV
+	if d := to.Len(); d > 0 && d < attractRadius*magnet+g.Radius {
+		want = to.Unit().Scaled(attractSpeed)
+	}
+	steer := want.Sub(g.Vel)
+	if l := steer.Len(); l > attractAccel*dt {
+		steer = steer.Scaled(attractAccel * dt / l)
+	}
+	g.Vel = g.Vel.Add(steer)
+	g.Pos = g.Pos.Add(g.Vel.Scaled(dt))
+}
+
+// Update cycles the goal's colors and scrolls it down with the tower at scroll
+func (g *Goal) Update(dt, scroll float64) {
+	g.Counter += dt
+	g.Pos.Y -= dt * scroll
+	for g.Counter > g.Step {
+		g.Counter -= g.Step
+		for i := len(g.Cols) - 2; i >= 0; i-- {
+			g.Cols[i+1] = g.Cols[i]
+		}
+		if len(g.Palette) == 0 {
+			g.Cols[0] = NiceColor(rng.Cosmetic)
+			continue
+		}
+		g.Cols[0] = g.Palette[g.Shade%len(g.Palette)]
+		g.Shade++
+	}
+}
+
+func (g *Goal) Draw(imd *imdraw.IMDraw) {
+	for i := len(g.Cols) - 1; i >= 0; i-- {
+		imd.Color = g.Cols[i]
+		imd.Push(g.Pos)
+		imd.Circle(float64(i+1)*g.Radius/float64(len(g.Cols)), 0)
+	}
+}
+
+// goalColors is how many colors the goals cycle through
+const goalColors = 12
+
+// GoalPalette is the colors the goals cycle through on the tower of the seed, they come from the
+// seed so the same tower always has the same ones
+func GoalPalette(seed int64) []pixel.RGBA {
+	r := rng.CosmeticFor(seed)
+	palette := make([]pixel.RGBA, goalColors)
+	for i := range palette {
+		palette[i] = NiceColor(r)
+	}
+	return palette
+}
+
+// NiceColor is a bright color drawn from r
+func NiceColor(rnd *rand.Rand) pixel.RGBA {
+again:
+	r := rnd.Float64()
+	g := rnd.Float64()
+	b := rnd.Float64()
+	len := math.Sqrt(r*r + g*g + b*b)
+	if len == 0 {
+		goto again
+	}
+	return pixel.RGB(r/len, g/len, b/len)
+}
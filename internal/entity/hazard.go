@@ -0,0 +1,162 @@
+package entity
+
+import (
+	"math"
+
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"golang.org/x/image/colornames"
+)
+
+type HazardKind int
+
+const (
+	// saws run back and forth along the platform
+	Saw HazardKind = iota
+	// spikes sit still on part of the platform's top
+	Spikes
+	// flames burst from a spot on the platform every so often, only from the prestige tiers on
+	Flame
+)
+
+var HazardNames = map[string]HazardKind{
+	"saw":    Saw,
+	"spikes": Spikes,
+	"flame":  Flame,
+}
+
+// a flame burns for flameOn out of every flameCycle seconds, flameHeight high
+const (
+	flameCycle  = 2.4
+	flameOn     = 0.8
+	flameHeight = 18
+)
+
+func (k HazardKind) String() string {
+	for name, kind := range HazardNames {
+		if kind == k {
+			return name
+		}
+	}
+	return "hazard"
+}
+
+// Hazard is attached to a platform and moves along with it, touching it is deadly
+type Hazard struct {
+	Kind HazardKind
+
+	// Offset is from the left end of the platform, Width is the part covered by spikes or fire.
+	// Spin is the blade's angle for a saw, how far into its cycle a flame is.
+	Offset float64
+	Width  float64
+	Speed  float64
+	Radius float64
+	Spin   float64
+}
+
+func NewHazard(kind HazardKind, p *Platform) *Hazard {
+	h := &Hazard{Kind: kind, Radius: 5}
+	switch kind {
+	case Saw:
+		h.Speed = 30 + rng.Gameplay.Float64()*30
+		h.Offset = rng.Gameplay.Float64() * p.Rect.W()
+	case Spikes:
+		h.Width = math.Min(20, p.Rect.W()/2)
+		h.Offset = rng.Gameplay.Float64() * (p.Rect.W() - h.Width)
+	case Flame:
+		h.Width = math.Min(10, p.Rect.W()/2)
+		h.Offset = rng.Gameplay.Float64() * (p.Rect.W() - h.Width)
+		h.Spin = rng.Gameplay.Float64() * flameCycle
+	}
+	return h
+}
+
+// burning is whether a flame is on right now
+func (h *Hazard) burning() bool {
+	return math.Mod(h.Spin, flameCycle) < flameOn
+}
+
+func (h *Hazard) Update(dt float64, p *Platform) {
+	if h.Kind == Flame {
+		h.Spin += dt
+		return
+	}
+	if h.Kind != Saw {
+		return
+	}
+	h.Spin += dt * h.Speed / h.Radius
+	h.Offset += h.Speed * dt
+	if h.Offset < 0 || h.Offset > p.Rect.W() {
+		h.Speed = -h.Speed
+		h.Offset = math.Max(0, math.Min(h.Offset, p.Rect.W()))
+	}
+}
+
+// Area is the space the hazard takes up above the platform
+func (h *Hazard) Area(p *Platform) pixel.Rect {
+	switch h.Kind {
+	case Saw:
+		x := p.Rect.Min.X + h.Offset
+		c := pixel.V(x, p.Top(x))
+		return pixel.Rect{Min: c.Sub(pixel.V(h.Radius, h.Radius)), Max: c.Add(pixel.V(h.Radius, h.Radius))}
+	case Flame:
+		x := p.Rect.Min.X + h.Offset
+		y := math.Min(p.Top(x), p.Top(x+h.Width))
+		return pixel.R(x, y, x+h.Width, y+flameHeight)
+	default:
+		x := p.Rect.Min.X + h.Offset
+		y := math.Min(p.Top(x), p.Top(x+h.Width))
+		return pixel.R(x, y, x+h.Width, y+4)
+	}
+}
+
+func (h *Hazard) Hits(r pixel.Rect, p *Platform) bool {
+	if h.Kind == Flame && !h.burning() {
+		return false
+	}
+	return h.Area(p).Intersects(r)
+}
+
+func (h *Hazard) draw(imd *imdraw.IMDraw, p *Platform) {
+	a := h.Area(p)
+	switch h.Kind {
+	case Saw:
+		// a spinning blade with teeth
+		c := a.Center()
+		imd.Color = colornames.Silver
+		imd.Push(c)
+		imd.Circle(h.Radius-1, 0)
+		for i := 0; i < 6; i++ {
+			angle := h.Spin + float64(i)*math.Pi/3
+			tip := c.Add(pixel.V(h.Radius+1, 0).Rotated(angle))
+			imd.Push(c.Add(pixel.V(h.Radius-1, 0).Rotated(angle-0.4)), tip, c.Add(pixel.V(h.Radius-1, 0).Rotated(angle+0.4)))
+			imd.Polygon(0)
+		}
+		imd.Color = colornames.Dimgray
+		imd.Push(c)
+		imd.Circle(1.5, 0)
+	case Spikes:
+		imd.Color = colornames.Lightgray
+		for x := a.Min.X; x+4 <= a.Max.X; x += 4 {
+			imd.Push(pixel.V(x, a.Min.Y), pixel.V(x+4, a.Min.Y), pixel.V(x+2, a.Max.Y))
+			imd.Polygon(0)
+		}
+	case Flame:
+		// a nozzle, and a flickering jet out of it while it burns
+		imd.Color = colornames.Dimgray
+		imd.Push(a.Min, pixel.V(a.Max.X, a.Min.Y+2))
+		imd.Rectangle(0)
+		if !h.burning() {
+			return
+		}
+		flicker := 1 + 0.15*math.Sin(h.Spin*40)
+		for i, col := range []pixel.RGBA{pixel.ToRGBA(colornames.Orangered), pixel.ToRGBA(colornames.Gold)} {
+			in := float64(i) * a.W() / 4
+			imd.Color = col
+			imd.Push(pixel.V(a.Min.X+in, a.Min.Y+2), pixel.V(a.Max.X-in, a.Min.Y+2), pixel.V(a.Center().X, a.Min.Y+(a.H()-2)*flicker/float64(i+1)))
+			imd.Polygon(0)
+		}
+	}
+}
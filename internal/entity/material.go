@@ -0,0 +1,22 @@
+package entity
+
+import "image/color"
+
+// Material is what a platform is made of, it decides how the gopher moves on it
+type Material struct {
+	Name string
+
+	// Friction scales how quickly the gopher speeds up and slows down while standing on it
+	Friction float64
+	// Restitution is the fraction of the fall speed bounced back when landing
+	Restitution float64
+	// Speed scales the top running speed
+	Speed float64
+	// Crumbles is how many seconds it holds once someone stands on it, zero for good
+	Crumbles float64
+
+	// Color overrides the platform's own color, nil keeps it
+	Color color.Color
+	// Weight is how often the generator picks it
+	Weight float64
+}
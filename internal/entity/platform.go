@@ -0,0 +1,122 @@
+// Package entity has the things in the tower the gopher meets: the platforms and what they're
+// made of, the hazards on them and the goals to pick up.
+package entity
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// PlatformID stays the same for the whole life of a platform, unlike its position in a slice, so
+// other systems can hold on to it and look the platform up later
+type PlatformID int
+
+// a platform landed on lights up for LandFlash seconds, platforms narrower than NarrowWidth get
+// marks on their ends
+const (
+	LandFlash   = 0.3
+	NarrowWidth = 30
+)
+
+// Platform is one of the tower's, the gopher lands on its top and runs into its sides
+type Platform struct {
+	ID    PlatformID
+	Rect  pixel.Rect
+	Color color.Color
+	Mat   *Material
+
+	// Slope is how much higher the right end of the top is than the left one, zero for flat
+	Slope float64
+
+	// moving platforms Swing sideways around BaseX
+	Swing      float64
+	SwingSpeed float64
+	SwingTime  float64
+	BaseX      float64
+	// Moved is how far the platform moved by itself in the last update, besides the scroll
+	Moved pixel.Vec
+
+	Hazard *Hazard
+
+	// Flash is how much of the landing highlight is left, the game sets and fades it
+	Flash float64
+	// Crumble is how long a crumbly platform holds on since someone stood on it, zero until then
+	Crumble float64
+}
+
+// Top is the height of the platform's surface at x
+func (p *Platform) Top(x float64) float64 {
+	t := (math.Max(p.Rect.Min.X, math.Min(x, p.Rect.Max.X)) - p.Rect.Min.X) / p.Rect.W()
+	return p.Rect.Max.Y + p.Slope*t
+}
+
+// Normal is the surface normal of the platform's top
+func (p *Platform) Normal() pixel.Vec {
+	return pixel.V(-p.Slope/p.Rect.W(), 1).Unit()
+}
+
+func (p *Platform) Draw(imd *imdraw.IMDraw) {
+	p.DrawFaded(imd, 1)
+}
+
+// DrawFaded draws the platform see-through, alpha 0 is not at all
+func (p *Platform) DrawFaded(imd *imdraw.IMDraw, alpha float64) {
+	c := p.Color
+	if p.Mat != nil && p.Mat.Color != nil {
+		c = p.Mat.Color
+	}
+	// a crumbling one fades as it gives way
+	if p.Crumble > 0 {
+		alpha *= 0.3 + 0.7*p.Crumble/p.Mat.Crumbles
+	}
+	imd.Color = pixel.ToRGBA(c).Scaled(alpha)
+	if p.Slope == 0 {
+		imd.Push(p.Rect.Min, p.Rect.Max)
+		imd.Rectangle(0)
+		return
+	}
+	imd.Push(
+		p.Rect.Min,
+		pixel.V(p.Rect.Max.X, p.Rect.Min.Y+p.Slope),
+		pixel.V(p.Rect.Max.X, p.Rect.Max.Y+p.Slope),
+		pixel.V(p.Rect.Min.X, p.Rect.Max.Y),
+	)
+	imd.Polygon(0)
+}
+
+// DrawMarks draws the highlight of a platform just landed on and, on the narrow ones, a mark at
+// each end so they're easy to read at speed
+func (p *Platform) DrawMarks(imd *imdraw.IMDraw, alpha float64) {
+	if p.Flash > 0 {
+		imd.Color = pixel.Alpha(alpha * p.Flash / LandFlash)
+		imd.Push(pixel.V(p.Rect.Min.X, p.Rect.Max.Y), pixel.V(p.Rect.Max.X, p.Rect.Max.Y+p.Slope))
+		imd.Line(2)
+	}
+	if p.Rect.W() < NarrowWidth {
+		imd.Color = pixel.Alpha(alpha * 0.6)
+		for _, x := range []float64{p.Rect.Min.X, p.Rect.Max.X} {
+			y := p.Top(x)
+			imd.Push(pixel.V(x, y-4), pixel.V(x, y+3))
+			imd.Line(1)
+		}
+	}
+}
+
+func (p *Platform) DrawHazard(imd *imdraw.IMDraw) {
+	if p.Hazard != nil {
+		p.Hazard.draw(imd, p)
+	}
+}
+
+// Clone is a copy of the platform that shares nothing with it
+func (p *Platform) Clone() Platform {
+	c := *p
+	if p.Hazard != nil {
+		h := *p.Hazard
+		c.Hazard = &h
+	}
+	return c
+}
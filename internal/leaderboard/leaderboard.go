@@ -0,0 +1,116 @@
+// Package leaderboard has the score submissions shared by the game and the leaderboard server.
+// Every install of the game has its own ed25519 key and signs what it submits, together with a
+// hash of the run's input, so a score can't be made up with a bare POST or changed on the way.
+package leaderboard
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"GoTower/internal/profile"
+
+	"github.com/pkg/errors"
+)
+
+// Score is one finished run
+type Score struct {
+	Score  int     `json:"score"`
+	Height float64 `json:"height"`
+	// Prestige is how many times the run looped into a harder tier, see the game's README
+	Prestige int `json:"prestige,omitempty"`
+	// Code is the tower code the run was played on
+	Code string `json:"code"`
+	// InputHash is the hash of every step's command during the run, picks and skips included
+	InputHash string    `json:"inputHash"`
+	Time      time.Time `json:"time"`
+	// Mutators flags a run played with changed rules, it has the ids of the mutators
+	Mutators []string `json:"mutators,omitempty"`
+	// Style is the gopher's movement style, from profile.Styles, the runs from before there were
+	// styles don't have one and were classic
+	Style string `json:"style,omitempty"`
+	// Assist flags a run played with the timing assist, with wider jump windows and goals
+	Assist bool `json:"assist,omitempty"`
+	// Adaptive flags a run played with the adaptive difficulty, with a tower that eased off
+	// after deaths
+	Adaptive bool `json:"adaptive,omitempty"`
+	// Name and Avatar are the player's profile, see package profile
+	Name   string `json:"name,omitempty"`
+	Avatar int    `json:"avatar,omitempty"`
+}
+
+// Submission is a score signed by the install that played it
+type Submission struct {
+	Score
+	Key       ed25519.PublicKey `json:"key"`
+	Signature []byte            `json:"signature"`
+}
+
+// message is what gets signed, the score as JSON
+func (s Score) message() []byte {
+	data, err := json.Marshal(s)
+	if err != nil {
+		// nothing in a score can fail to marshal
+		panic(err)
+	}
+	return data
+}
+
+// Sign makes a submission of the score with the install's key
+func Sign(s Score, key ed25519.PrivateKey) Submission {
+	return Submission{
+		Score:     s,
+		Key:       key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, s.message()),
+	}
+}
+
+// Verify checks the submission was signed by its key and is complete
+func (sub *Submission) Verify() error {
+	if len(sub.Key) != ed25519.PublicKeySize {
+		return errors.New("bad key")
+	}
+	if !ed25519.Verify(sub.Key, sub.message(), sub.Signature) {
+		return errors.New("bad signature")
+	}
+	if sub.InputHash == "" || sub.Code == "" {
+		return errors.New("missing the tower code or the input hash")
+	}
+	if sub.Name != "" {
+		if err := profile.CheckName(sub.Name); err != nil {
+			return err
+		}
+	}
+	if sub.Avatar < 0 || sub.Avatar >= len(profile.Avatars) {
+		return errors.New("no such avatar")
+	}
+	if err := profile.CheckStyle(sub.Style); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Player is a short name for the install that submitted, made from its key
+func (sub *Submission) Player() string {
+	sum := sha256.Sum256(sub.Key)
+	return hex.EncodeToString(sum[:4])
+}
+
+// ID is the same for the same run submitted twice by the same install
+func (sub *Submission) ID() string {
+	h := sha256.New()
+	h.Write(sub.Key)
+	h.Write([]byte(sub.Code))
+	h.Write([]byte(sub.InputHash))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// StyleOf is the movement style of the score, classic for the ones from before there were styles
+func (s Score) StyleOf() string {
+	if s.Style == "" {
+		return profile.Styles[0]
+	}
+	return s.Style
+}
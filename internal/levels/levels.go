@@ -0,0 +1,120 @@
+// Package levels has the custom levels shared by the game and the level server. A level is a
+// chunk library like the game's own chunks.json, with a name, an author and a difficulty, and the
+// players rate them. Ratings are signed with the install's key, like the scores, so every install
+// gets one rating per level.
+package levels
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// the difficulties go from MinDifficulty to MaxDifficulty, the stars from 1 to MaxStars
+const (
+	MinDifficulty = 1
+	MaxDifficulty = 5
+	MaxStars      = 5
+)
+
+// the longest name and author, they have to fit in the browser
+const (
+	maxName   = 20
+	maxAuthor = 16
+)
+
+// Level is one custom level
+type Level struct {
+	Name       string `json:"name"`
+	Author     string `json:"author"`
+	Difficulty int    `json:"difficulty"`
+	// Chunks is the chunk library, in the format of chunks.json, the game checks it
+	Chunks json.RawMessage `json:"chunks"`
+}
+
+// Check makes sure the level has everything the browser shows
+func (l *Level) Check() error {
+	var problems []string
+	if name := strings.TrimSpace(l.Name); name == "" || len(name) > maxName {
+		problems = append(problems, "the name has to be 1 to 20 characters")
+	}
+	if author := strings.TrimSpace(l.Author); author == "" || len(author) > maxAuthor {
+		problems = append(problems, "the author has to be 1 to 16 characters")
+	}
+	if l.Difficulty < MinDifficulty || l.Difficulty > MaxDifficulty {
+		problems = append(problems, "the difficulty goes from 1 to 5")
+	}
+	if c := bytes.TrimSpace(l.Chunks); len(c) == 0 || c[0] != '[' {
+		problems = append(problems, "no chunks")
+	}
+	if len(problems) > 0 {
+		return errors.New("bad level: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// ID is the same for the same level uploaded twice
+func (l *Level) ID() string {
+	data, err := json.Marshal(l)
+	if err != nil {
+		// nothing in a checked level can fail to marshal
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// Listing is a level as the browser lists it, without its chunks
+type Listing struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	Author     string `json:"author"`
+	Difficulty int    `json:"difficulty"`
+	// Rating is the average of the stars, zero when there are no Ratings yet
+	Rating  float64 `json:"rating"`
+	Ratings int     `json:"ratings"`
+}
+
+// Rating is an install's stars for a level
+type Rating struct {
+	Level     string            `json:"level"`
+	Stars     int               `json:"stars"`
+	Key       ed25519.PublicKey `json:"key"`
+	Signature []byte            `json:"signature"`
+}
+
+// message is what gets signed
+func (r *Rating) message() []byte {
+	return []byte(r.Level + ":" + string(rune('0'+r.Stars)))
+}
+
+// Rate makes a rating of the level with the install's key
+func Rate(level string, stars int, key ed25519.PrivateKey) Rating {
+	r := Rating{Level: level, Stars: stars, Key: key.Public().(ed25519.PublicKey)}
+	r.Signature = ed25519.Sign(key, r.message())
+	return r
+}
+
+// Verify checks the rating was signed by its key and is in range
+func (r *Rating) Verify() error {
+	if r.Stars < 1 || r.Stars > MaxStars {
+		return errors.New("the stars go from 1 to 5")
+	}
+	if len(r.Key) != ed25519.PublicKeySize {
+		return errors.New("bad key")
+	}
+	if !ed25519.Verify(r.Key, r.message(), r.Signature) {
+		return errors.New("bad signature")
+	}
+	return nil
+}
+
+// Rater is a key for the install that rated, so it can change its mind
+func (r *Rating) Rater() string {
+	return hex.EncodeToString(r.Key)
+}
@@ -0,0 +1,89 @@
+// Package lobby has the ghost race rooms shared by the game and the server. A player makes a
+// room, friends join it with its code, and once everyone says they're ready the race starts at
+// the same moment for all of them, on the same tower with the same mutators. While racing every
+// game posts where its gopher is and gets everyone else back, to draw them as ghosts.
+package lobby
+
+import (
+	"time"
+
+	"GoTower/internal/profile"
+)
+
+// the limits of a room
+const (
+	MaxPlayers = 8
+	MaxName    = profile.MaxName
+	// Countdown is how long after the last player is ready the race starts
+	Countdown = 3 * time.Second
+	// a racer can say something every EmoteGap, it's shown for EmoteShown
+	EmoteGap   = 2 * time.Second
+	EmoteShown = 3 * time.Second
+)
+
+// QuickChat is all that can be said in a race, by number from 1, there's no free text to filter
+var QuickChat = []string{"hi!", "gg", "nice!", "oops", "catch me!", "see you up there"}
+
+// Room is a race, as everyone in it sees it
+type Room struct {
+	Code     string   `json:"code"`
+	Seed     int64    `json:"seed"`
+	Mutators []string `json:"mutators,omitempty"`
+	Players  []Player `json:"players"`
+	// StartAt is when the race starts, zero until everyone is ready
+	StartAt time.Time `json:"startAt,omitempty"`
+	// Now is the server's clock, the games count down by it
+	Now time.Time `json:"now"`
+}
+
+// Player is one racer, ID is their place in the room, Avatar is from profile.Avatars
+type Player struct {
+	ID     int    `json:"id"`
+	Name   string `json:"name"`
+	Avatar int    `json:"avatar,omitempty"`
+	Ready  bool   `json:"ready"`
+	Ghost  Ghost  `json:"ghost"`
+
+	// Emote is what they said last, from QuickChat, at EmoteAt by the server's clock
+	Emote   int       `json:"emote,omitempty"`
+	EmoteAt time.Time `json:"emoteAt,omitempty"`
+}
+
+// Ghost is where a racer's gopher is
+type Ghost struct {
+	Tick uint32 `json:"tick"`
+	// X is across the tower, Height up it from the bottom, like the floors count it
+	X      float64 `json:"x"`
+	Height float64 `json:"height"`
+	Floor  int     `json:"floor"`
+	Deaths int     `json:"deaths"`
+}
+
+// Create makes a room with the player who made it in it
+type Create struct {
+	Name     string   `json:"name"`
+	Avatar   int      `json:"avatar,omitempty"`
+	Seed     int64    `json:"seed"`
+	Mutators []string `json:"mutators,omitempty"`
+}
+
+// Join is a player joining a room
+type Join struct {
+	Name   string `json:"name"`
+	Avatar int    `json:"avatar,omitempty"`
+}
+
+// Joined is the answer to Create and Join, the token is what the player posts with from then on
+type Joined struct {
+	Room  Room   `json:"room"`
+	ID    int    `json:"id"`
+	Token string `json:"token"`
+}
+
+// Update is a player saying they're ready, where their ghost is or something from QuickChat
+type Update struct {
+	Token string `json:"token"`
+	Ready bool   `json:"ready,omitempty"`
+	Ghost *Ghost `json:"ghost,omitempty"`
+	Emote int    `json:"emote,omitempty"`
+}
@@ -0,0 +1,272 @@
+// Package physics moves the gopher through the tower: it runs, jumps, lands on the platforms and
+// stops at the walls. The tower comes in through a World, what happens to the gopher goes out
+// through its Publish, as the events here.
+package physics
+
+import (
+	"math"
+
+	"GoTower/internal/entity"
+
+	"github.com/faiface/pixel"
+)
+
+// minBounce keeps rubber from bouncing forever with tiny hops
+const minBounce = 60
+
+// KillZone is where the gopher falls out of the tower, the bottom of the canvas
+const KillZone = -120
+
+// DangerRange is how far above the kill zone the gopher is in danger, a quarter of the screen
+const DangerRange = 60
+
+// World is what the body needs from the tower around it: how fast it scrolls and how far it has,
+// the material of the platforms that don't say and where to publish what happens to the body
+type World struct {
+	Scroll, Climbed float64
+	Normal          *entity.Material
+	Publish         func(e interface{})
+}
+
+// material is the one p is made of, normal for the plain ones
+func (w World) material(p *entity.Platform) *entity.Material {
+	if p.Mat == nil {
+		return w.Normal
+	}
+	return p.Mat
+}
+
+// Body is the gopher as it moves, the size, speeds and mutators of a run and where it is in it
+type Body struct {
+	Gravity   float64
+	RunSpeed  float64
+	RunAccel  float64
+	AirAccel  float64
+	JumpSpeed float64
+
+	// MaxFall is the terminal falling speed, FastFall the one while holding down in the air
+	MaxFall  float64
+	FastFall float64
+
+	Rect      pixel.Rect
+	Vel       pixel.Vec
+	Ground    bool
+	GroundID  entity.PlatformID
+	GroundMat *entity.Material
+	Normal    pixel.Vec
+	Floor     int
+	// Lives are the extra ones left, from the mutators and the picks
+	Lives int
+
+	// Coyote is how long after running off a platform a jump still works, Buffer how long before
+	// landing one can be pressed. Ledge and Queued are the time left of each.
+	Coyote, Buffer float64
+	Ledge, Queued  float64
+	// Wrap takes the gopher around the edges of the screen instead of into the walls
+	Wrap bool
+	// Stamina is the stamina mutator's bar
+	Stamina Stamina
+}
+
+// Standing is the platform the gopher is on, and whether it's on one at all
+func (gp *Body) Standing() (entity.PlatformID, bool) {
+	return gp.GroundID, gp.Ground
+}
+
+// Carry moves the gopher along with its platform, the walls still stop it
+func (gp *Body) Carry(d pixel.Vec) {
+	gp.Rect = gp.Rect.Moved(d)
+	gp.hitWall()
+}
+
+// Update moves the body dt on, ctrl is the way the player wants it to go, up for a jump
+func (gp *Body) Update(dt float64, ctrl pixel.Vec, platforms []*entity.Platform, w World) {
+	// apply controls, accelerating by however much grip the ground gives
+	mat := w.Normal
+	accel := gp.AirAccel
+	if gp.Ground && gp.GroundMat != nil {
+		mat = gp.GroundMat
+		accel = gp.RunAccel * mat.Friction
+	}
+	// running uphill is slower than downhill, the normal leans against the direction of the climb
+	slope := 1.0
+	if gp.Ground {
+		slope += gp.Normal.X * ctrl.X
+	}
+	gp.Vel.X = approach(gp.Vel.X, ctrl.X*gp.RunSpeed*mat.Speed*slope, accel*dt)
+
+	// apply gravity and velocity, holding down in the air dives faster
+	gravity, maxFall := gp.Gravity, gp.MaxFall
+	if !gp.Ground && ctrl.Y < 0 {
+		gravity, maxFall = 2*gp.Gravity, gp.FastFall
+	}
+	gp.Vel.Y += gravity * dt
+	if maxFall > 0 && gp.Vel.Y < -maxFall {
+		gp.Vel.Y = -maxFall
+	}
+	from := gp.Rect
+	gp.Rect = gp.Rect.Moved(gp.Vel.Scaled(dt))
+	gp.hitWall()
+	// the way the gopher went, not around the screen when it wrapped
+	move := gp.Rect.Min.Sub(from.Min)
+	if math.Abs(move.X) > WrapWidth/2 {
+		move.X -= math.Copysign(WrapWidth, move.X)
+	}
+
+	// stop at the first platform side the feet ran into
+	side := math.Inf(1)
+	for _, p := range platforms {
+		if t, ok := sideHit(gp.Rect, move, p); ok && t < side {
+			side = t
+		}
+	}
+	if side <= 1 {
+		gp.Rect = gp.Rect.Moved(pixel.V(-move.X*(1-side), 0))
+		move.X *= side
+		gp.Vel.X = 0
+	}
+
+	// land on the first platform the gopher's feet came down on, bouncy materials throw it back up
+	wasGround := gp.Ground
+	fall := -gp.Vel.Y
+	touched := false
+	gp.Ground = false
+	gp.Normal = pixel.V(0, 1)
+	if gp.Vel.Y <= 0 {
+		var hit *entity.Platform
+		first := math.Inf(1)
+		for _, p := range platforms {
+			// on a slope the surface moves under the feet, stick to it when walking down
+			stick := 0.0
+			if wasGround && p.Slope != 0 {
+				stick = math.Abs(gp.Vel.X*dt*p.Slope/p.Rect.W()) + 0.5
+			}
+			if t, ok := impact(gp.Rect, move, p, stick); ok && t < first {
+				hit, first = p, t
+			}
+		}
+		if p := hit; p != nil {
+			// the feet are on the top where they came down on it, not where the step ended
+			x := gp.Rect.Center().X - move.X*(1-first)
+			gp.Rect = gp.Rect.Moved(pixel.V(0, p.Top(x)-gp.Rect.Min.Y))
+			touched = true
+			if bounce := fall * w.material(p).Restitution; bounce > minBounce {
+				gp.Vel.Y = bounce
+			} else {
+				gp.Vel.Y = 0
+				gp.Ground = true
+				gp.GroundID = p.ID
+				gp.GroundMat = w.material(p)
+				gp.Normal = p.Normal()
+			}
+		}
+	}
+
+	if touched && !wasGround {
+		w.Publish(Landed{Pos: gp.Rect.Center(), Speed: fall})
+	}
+
+	// jump if on the ground, or just off it, and the player wants to jump, or just did
+	if gp.Ground {
+		gp.Ledge = gp.Coyote
+	}
+	if ctrl.Y > 0 {
+		gp.Queued = gp.Buffer
+	}
+	gp.Stamina.update(dt, gp.Ground)
+	if (gp.Ground || gp.Ledge > 0) && (ctrl.Y > 0 || gp.Queued > 0) && gp.Stamina.CanJump() {
+		gp.Vel.Y = gp.JumpSpeed
+		gp.Ledge, gp.Queued = 0, 0
+		gp.Stamina.jumped()
+		w.Publish(Jumped{Pos: gp.Rect.Center()})
+	}
+	gp.Ledge = math.Max(0, gp.Ledge-dt)
+	gp.Queued = math.Max(0, gp.Queued-dt)
+	gp.Rect.Min.Y -= dt * w.Scroll
+	gp.Rect.Max.Y -= dt * w.Scroll
+
+	// report each new floor once per run
+	if floor := int(gp.Height(w.Climbed) / FloorHeight); floor > gp.Floor {
+		gp.Floor = floor
+		w.Publish(FloorReached{Floor: floor, Height: gp.Height(w.Climbed)})
+	}
+
+	// fell out of the bottom of the tower
+	if gp.Rect.Max.Y < KillZone {
+		gp.Die("fall", w)
+	}
+}
+
+// impact is when in the step the feet of the gopher, at rect after moving by move, came down on
+// the platform's top, from 0 at the start of the step to 1 at its end, and whether they did. The
+// feet are followed all the way instead of only checked where they end up, so a fall lands on the
+// platform it crossed even if it's fast or it went past the platform's end, and not on one it only
+// ends up over. stick is how far over the top still counts as on it. The platforms are only solid
+// from above, the gopher jumps up through them and runs off their ends, only their sides stop the
+// feet, see sideHit.
+func impact(rect pixel.Rect, move pixel.Vec, p *entity.Platform, stick float64) (float64, bool) {
+	// how far over the top the feet were before the move and are after it
+	above := rect.Min.Y - move.Y - p.Top(rect.Center().X-move.X)
+	over := rect.Min.Y - p.Top(rect.Center().X)
+	if above < -stick || over > stick {
+		return 0, false
+	}
+	t := 1.0
+	if above > over {
+		t = math.Max(0, math.Min(1, above/(above-over)))
+	}
+	// where the gopher was when the feet got to the top
+	back := move.X * (1 - t)
+	if rect.Max.X-back <= p.Rect.Min.X || rect.Min.X-back >= p.Rect.Max.X {
+		return 0, false
+	}
+	return t, true
+}
+
+// sideHit is when in the step the feet of the gopher, at rect after moving by move, ran into the
+// side of the platform, below its top, and whether they did. The rest of the gopher goes by the
+// sides, so it still jumps up through the platforms.
+func sideHit(rect pixel.Rect, move pixel.Vec, p *entity.Platform) (float64, bool) {
+	if move.X == 0 {
+		return 0, false
+	}
+	edge, lead := p.Rect.Min.X, rect.Max.X
+	if move.X < 0 {
+		edge, lead = p.Rect.Max.X, rect.Min.X
+	}
+	t := 1 - (lead-edge)/move.X
+	if t < 0 || t >= 1 {
+		return 0, false
+	}
+	feet, top := rect.Min.Y-move.Y*(1-t), p.Top(edge)
+	if feet >= top || feet < top-p.Rect.H() {
+		return 0, false
+	}
+	return t, true
+}
+
+// Die reports the death and starts again from the middle
+func (gp *Body) Die(cause string, w World) {
+	if gp.Lives > 0 {
+		gp.Lives--
+		w.Publish(LifeLost{Cause: cause, Left: gp.Lives})
+	} else {
+		w.Publish(Died{Height: gp.Height(w.Climbed), Cause: cause})
+	}
+	gp.Rect = gp.Rect.Moved(gp.Rect.Center().Scaled(-1))
+	gp.Vel = pixel.ZV
+	gp.Stamina.Left = 1
+}
+
+// approach moves v towards target by at most step
+func approach(v, target, step float64) float64 {
+	if v < target {
+		return math.Min(v+step, target)
+	}
+	return math.Max(v-step, target)
+}
+
+// Height is the body's height above the bottom of the tower, climbed up already
+func (gp *Body) Height(climbed float64) float64 {
+	return climbed + gp.Rect.Min.Y + 120
+}
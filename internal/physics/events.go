@@ -0,0 +1,31 @@
+package physics
+
+import "github.com/faiface/pixel"
+
+// the moments of the body, published through the World
+type (
+	Jumped struct {
+		Pos pixel.Vec
+	}
+	Landed struct {
+		Pos   pixel.Vec
+		Speed float64
+	}
+	Died struct {
+		Height float64
+		// Cause is what killed the gopher: fall, boss, saw, spikes
+		Cause string
+	}
+	// LifeLost is a death an extra life took, the run goes on
+	LifeLost struct {
+		Cause string
+		Left  int
+	}
+	FloorReached struct {
+		Floor  int
+		Height float64
+	}
+)
+
+// FloorHeight is the vertical distance between two floors of the tower
+const FloorHeight = 20
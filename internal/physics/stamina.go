@@ -0,0 +1,37 @@
+package physics
+
+import "math"
+
+// a jump takes StaminaJump of the stamina, 1 is full, so three in a row leave too little for a
+// fourth; standing on a platform fills it back at staminaRefill a second
+const (
+	StaminaJump   = 0.34
+	staminaRefill = 0.6
+)
+
+// Stamina is the bar of the stamina mutator: the jumps drain it and the ground fills it back, so
+// the gopher can't hop all the way up without stopping. The jump asks it first, it lets every
+// jump through when it's off.
+type Stamina struct {
+	On   bool
+	Left float64
+}
+
+// CanJump is whether there's enough left for a jump
+func (st *Stamina) CanJump() bool {
+	return !st.On || st.Left >= StaminaJump
+}
+
+// jumped takes a jump's worth
+func (st *Stamina) jumped() {
+	if st.On {
+		st.Left -= StaminaJump
+	}
+}
+
+// update fills the bar back while the gopher stands
+func (st *Stamina) update(dt float64, ground bool) {
+	if st.On && ground {
+		st.Left = math.Min(1, st.Left+staminaRefill*dt)
+	}
+}
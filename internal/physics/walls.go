@@ -0,0 +1,50 @@
+package physics
+
+import "github.com/faiface/pixel"
+
+// the tower's walls stand WallWidth into the screen on both sides, the gopher runs into their
+// faces at ±WallFace
+const (
+	WallWidth = 4
+	WallFace  = 160 - WallWidth
+)
+
+// with the wrap mutator there are no walls, the gopher goes out one side of the screen and comes
+// back in the other, WrapWidth away
+const WrapWidth = 320
+
+// hitWall stops the gopher at the wall faces, keeping a tiny velocity so it still faces the wall
+// it ran into. Wrapping, it goes to the other side once its middle is past the edge.
+func (gp *Body) hitWall() {
+	if gp.Wrap {
+		switch x := gp.Rect.Center().X; {
+		case x < -WrapWidth/2:
+			gp.Rect = gp.Rect.Moved(pixel.V(WrapWidth, 0))
+		case x > WrapWidth/2:
+			gp.Rect = gp.Rect.Moved(pixel.V(-WrapWidth, 0))
+		}
+		return
+	}
+	switch {
+	case gp.Rect.Min.X < -WallFace:
+		gp.Rect = gp.Rect.Moved(pixel.V(-WallFace-gp.Rect.Min.X, 0))
+		gp.Vel.X = -0.000001
+	case gp.Rect.Max.X > WallFace:
+		gp.Rect = gp.Rect.Moved(pixel.V(WallFace-gp.Rect.Max.X, 0))
+		gp.Vel.X = +0.000001
+	}
+}
+
+// Seam is where the gopher's other half shows while it's across the edge of the screen, wrapping,
+// and whether it is
+func (gp *Body) Seam() (pixel.Vec, bool) {
+	switch {
+	case !gp.Wrap:
+		return pixel.ZV, false
+	case gp.Rect.Min.X < -WrapWidth/2:
+		return pixel.V(WrapWidth, 0), true
+	case gp.Rect.Max.X > WrapWidth/2:
+		return pixel.V(-WrapWidth, 0), true
+	}
+	return pixel.ZV, false
+}
@@ -0,0 +1,82 @@
+// Package profile has the player's display name and avatar, shared by the game, which shows them
+// on the high scores and the ghosts, and the server, which checks them on what it's sent.
+package profile
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MaxName is the longest display name, it has to fit over a ghost
+const MaxName = 12
+
+// nameRunes are what a name can be made of, more would need more than the built-in font
+const nameRunes = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789 -_."
+
+// CheckName checks a display name is 1 to MaxName letters, digits, spaces, dashes, underscores
+// and dots, without spaces at the ends
+func CheckName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("the name is empty")
+	case len(name) > MaxName:
+		return errors.Errorf("the name is longer than %d", MaxName)
+	case strings.TrimSpace(name) != name:
+		return errors.New("the name starts or ends with a space")
+	}
+	for _, r := range name {
+		if !strings.ContainsRune(nameRunes, r) {
+			return errors.Errorf("%q can't be in a name", r)
+		}
+	}
+	return nil
+}
+
+// CanType is whether the rune can go in a name at all
+func CanType(r rune) bool {
+	return strings.ContainsRune(nameRunes, r)
+}
+
+// Avatar is a color the gopher is tinted with
+type Avatar struct {
+	Name  string
+	Color color.RGBA
+}
+
+// Avatars are the avatars by number, the first one is the gopher as drawn
+var Avatars = []Avatar{
+	{"classic", color.RGBA{255, 255, 255, 255}},
+	{"cherry", color.RGBA{255, 120, 140, 255}},
+	{"lime", color.RGBA{170, 255, 120, 255}},
+	{"ocean", color.RGBA{110, 170, 255, 255}},
+	{"sunny", color.RGBA{255, 230, 110, 255}},
+	{"violet", color.RGBA{200, 140, 255, 255}},
+	{"shadow", color.RGBA{110, 110, 130, 255}},
+}
+
+// AvatarAt is avatar i, the classic one when there's no such avatar
+func AvatarAt(i int) Avatar {
+	if i < 0 || i >= len(Avatars) {
+		return Avatars[0]
+	}
+	return Avatars[i]
+}
+
+// Styles are the ways the gopher can move, the game has the physics of each, the first is the
+// default. The leaderboard tells the scores apart by them.
+var Styles = []string{"classic", "floaty", "heavy"}
+
+// CheckStyle checks the style is one of Styles, or empty for the default
+func CheckStyle(style string) error {
+	if style == "" {
+		return nil
+	}
+	for _, s := range Styles {
+		if s == style {
+			return nil
+		}
+	}
+	return errors.Errorf("no such style %q", style)
+}
@@ -0,0 +1,189 @@
+// Package render has the gopher's animations and the camera that picks the part of the tower
+// shown.
+package render
+
+import (
+	"image/color"
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/sprite"
+
+	"github.com/faiface/pixel"
+)
+
+// GopherAnimations are the animations GopherAnim can't do without
+var GopherAnimations = []string{"Front", "FrontBlink", "Run", "Jump"}
+
+type animState int
+
+const (
+	idle animState = iota
+	running
+	jumping
+)
+
+// the gopher's animation speeds: a run frame lasts RunRate at full running speed, longer when
+// slower, down to minRunPlayback of the full speed. Standing, it blinks for blinkTime every
+// blinkEvery.
+const (
+	RunRate        = 1.0 / 10
+	minRunPlayback = 0.25
+	blinkEvery     = 4
+	blinkTime      = 0.1
+)
+
+// tumbleTime is how long the gopher tumbles, one whole turn, after it lost a life
+const tumbleTime = 0.5
+
+// GopherAnim picks the gopher's frame from how its body moves and draws it there
+type GopherAnim struct {
+	anims map[string][]sprite.Frame
+	// RunRate, blinkEvery and blinkTime are the constants of the same name unless changed,
+	// Stretch is the movement style's
+	RunRate               float64
+	blinkEvery, blinkTime float64
+	Stretch               float64
+
+	state   animState
+	counter float64
+	// runPhase is how far into the run cycle the gopher is, in frames
+	runPhase float64
+
+	// Facing is +1 when the gopher looks right and -1 left, rotation turns it counterclockwise, in
+	// radians, on top of the lean into slopes. The sprite is flipped before it's rotated, so a
+	// rotation turns the same way whichever way the gopher faces.
+	Facing   float64
+	rotation float64
+	// tumbling is how long the tumble after a death still goes on
+	tumbling float64
+
+	Frame sprite.Frame
+	// Tint is the avatar's color, nil draws the gopher as it is
+	Tint color.Color
+
+	sprite *pixel.Sprite
+}
+
+func NewGopherAnim(anims map[string][]sprite.Frame) *GopherAnim {
+	return &GopherAnim{
+		anims:      anims,
+		RunRate:    RunRate,
+		blinkEvery: blinkEvery,
+		blinkTime:  blinkTime,
+		Facing:     +1,
+	}
+}
+
+func (ga *GopherAnim) Update(dt float64, phys *physics.Body) {
+	ga.counter += dt
+
+	// determine the new animation state
+	var newState animState
+	switch {
+	case !phys.Ground:
+		newState = jumping
+	case phys.Vel.Len() == 0:
+		newState = idle
+	case phys.Vel.Len() > 0:
+		newState = running
+	}
+
+	// reset the time counter if the state changed
+	if ga.state != newState {
+		ga.state = newState
+		ga.counter, ga.runPhase = 0, 0
+	}
+
+	// determine the correct animation frame
+	switch ga.state {
+	case idle:
+		ga.Frame = ga.anims["Front"][0]
+		if math.Mod(ga.counter, ga.blinkEvery) > ga.blinkEvery-ga.blinkTime {
+			ga.Frame = ga.anims["FrontBlink"][0]
+		}
+	case running:
+		// the legs keep up with the ground, on ice or a conveyor too
+		playback := math.Max(minRunPlayback, math.Abs(phys.Vel.X)/phys.RunSpeed)
+		ga.runPhase += dt * playback / ga.RunRate
+		run := ga.anims["Run"]
+		ga.Frame = run[int(ga.runPhase)%len(run)]
+	case jumping:
+		speed := phys.Vel.Y
+		i := int((-speed/phys.JumpSpeed + 1) / 2 * float64(len(ga.anims["Jump"])))
+		if i < 0 {
+			i = 0
+		}
+		if i >= len(ga.anims["Jump"]) {
+			i = len(ga.anims["Jump"]) - 1
+		}
+		ga.Frame = ga.anims["Jump"][i]
+	}
+
+	// set the facing direction of the gopher
+	ga.face(phys.Vel.X)
+
+	// head over heels backwards, upright again once the tumble is over
+	if ga.tumbling > 0 {
+		ga.tumbling = math.Max(0, ga.tumbling-dt)
+		ga.setRotation(ga.Facing * 2 * math.Pi * ga.tumbling / tumbleTime)
+	}
+}
+
+// OnEvent starts a tumble when the gopher dies, it comes back tumbling
+func (ga *GopherAnim) OnEvent(e interface{}) {
+	switch e.(type) {
+	case physics.LifeLost, physics.Died:
+		ga.tumbling = tumbleTime
+	}
+}
+
+// face turns the gopher right when dir is positive and left when it's negative, zero keeps the
+// way it faces
+func (ga *GopherAnim) face(dir float64) {
+	switch {
+	case dir > 0:
+		ga.Facing = +1
+	case dir < 0:
+		ga.Facing = -1
+	}
+}
+
+// setRotation turns the gopher by angle, like for a tumble, zero stands it up again
+func (ga *GopherAnim) setRotation(angle float64) {
+	ga.rotation = angle
+}
+
+// tilt leans the gopher a little into the slope it's standing on
+func (ga *GopherAnim) tilt(phys *physics.Body) float64 {
+	if !phys.Ground {
+		return 0
+	}
+	return -math.Atan2(phys.Normal.X, phys.Normal.Y) / 2
+}
+
+// matrix places the frame on the gopher: scaled to its size, flipped to face its way, rotated
+// and moved to where it is
+func (ga *GopherAnim) matrix(phys *physics.Body) pixel.Matrix {
+	stretch := 1.0
+	if !phys.Ground && ga.Stretch != 0 {
+		stretch += ga.Stretch * math.Min(math.Abs(phys.Vel.Y)/phys.JumpSpeed, 1)
+	}
+	return pixel.IM.
+		ScaledXY(pixel.ZV, pixel.V(
+			phys.Rect.W()/ga.Frame.Rect.W()/stretch,
+			phys.Rect.H()/ga.Frame.Rect.H()*stretch,
+		)).
+		ScaledXY(pixel.ZV, pixel.V(-ga.Facing, 1)).
+		Rotated(pixel.ZV, ga.rotation+ga.tilt(phys)).
+		Moved(phys.Rect.Center())
+}
+
+func (ga *GopherAnim) Draw(t pixel.Target, phys *physics.Body) {
+	if ga.sprite == nil {
+		ga.sprite = pixel.NewSprite(nil, pixel.Rect{})
+	}
+	// draw the correct frame with the correct position and direction
+	ga.sprite.Set(ga.Frame.Pic, ga.Frame.Rect)
+	ga.sprite.DrawColorMask(t, ga.matrix(phys), ga.Tint)
+}
@@ -0,0 +1,93 @@
+package render
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+)
+
+type CameraProfile int
+
+const (
+	// CameraGameplay stays on the tower, it only dips down for the danger warning
+	CameraGameplay CameraProfile = iota
+	// CameraCinematic is for watching: it frames wider, leads the way the gopher moves, and
+	// eases and drifts a little
+	CameraCinematic
+)
+
+var CameraNames = map[string]CameraProfile{
+	"gameplay":  CameraGameplay,
+	"cinematic": CameraCinematic,
+}
+
+// the cinematic camera shows 1/cinematicZoom as much, follows the gopher by cinematicFollow of the
+// way, cinematicLead seconds ahead of it, catches up to that in about cinematicEase seconds and
+// drifts up to cinematicDrift around it
+const (
+	cinematicZoom   = 0.8
+	cinematicFollow = 0.5
+	cinematicLead   = 0.3
+	cinematicEase   = 0.6
+	cinematicDrift  = 3
+)
+
+// focusZoom is how far the camera zooms in on the gopher at full focus, like during the
+// countdown
+const focusZoom = 0.6
+
+// Camera is what part of the tower is shown, on a screen bounds big
+type Camera struct {
+	profile CameraProfile
+	bounds  pixel.Rect
+	Pos     pixel.Vec
+	Zoom    float64
+	// t is the time the drift goes by
+	t float64
+	// Focus is how far the camera is zoomed in on the gopher, at, from 0 to 1, see focusZoom
+	Focus float64
+	at    pixel.Vec
+}
+
+func NewCamera(profile CameraProfile, bounds pixel.Rect) *Camera {
+	c := &Camera{profile: profile, bounds: bounds, Zoom: 1}
+	if profile == CameraCinematic {
+		c.Zoom = cinematicZoom
+	}
+	return c
+}
+
+// Update moves the camera along with the gopher, pull is how far down the danger warning wants it
+func (c *Camera) Update(dt float64, gp *physics.Body, pull float64) {
+	c.t += dt
+	c.at = gp.Rect.Center()
+	switch c.profile {
+	case CameraGameplay:
+		c.Pos = pixel.V(0, pull)
+	case CameraCinematic:
+		target := gp.Rect.Center().Add(gp.Vel.Scaled(cinematicLead)).Scaled(cinematicFollow)
+		target.Y += pull
+		target = target.Add(pixel.V(math.Sin(c.t*0.7), math.Sin(c.t*0.45+1)).Scaled(cinematicDrift))
+		c.Pos = pixel.Lerp(c.Pos, target, 1-math.Exp(-dt/cinematicEase))
+	}
+}
+
+// lens is where the camera looks and how far it's zoomed, with the focus on the gopher
+func (c *Camera) lens() (pixel.Vec, float64) {
+	f := c.Focus * c.Focus * (3 - 2*c.Focus)
+	return pixel.Lerp(c.Pos, c.at, f), c.Zoom * (1 + focusZoom*f)
+}
+
+// View is the part of the world that's shown
+func (c *Camera) View() pixel.Rect {
+	pos, zoom := c.lens()
+	return c.bounds.Resized(pixel.ZV, c.bounds.Size().Scaled(1/zoom)).Moved(pos)
+}
+
+// Matrix takes the world to the screen, the game takes it the rest of the way to the canvas
+func (c *Camera) Matrix() pixel.Matrix {
+	pos, zoom := c.lens()
+	return pixel.IM.Moved(pos.Scaled(-1)).Scaled(pixel.ZV, zoom)
+}
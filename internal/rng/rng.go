@@ -0,0 +1,56 @@
+// Package rng has the game's two streams of random numbers. Gameplay is for everything the
+// simulation decides, the platforms, the hazards, where the goals go; it's seeded with the tower
+// and replays depend on it drawing the exact same numbers. Cosmetic is for the looks, colors,
+// particles, decorations; it runs free, so drawing more or fewer of them never changes the tower.
+package rng
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	Gameplay = rand.New(&lockedSource{src: rand.NewSource(1).(rand.Source64)})
+	Cosmetic = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+)
+
+// Seed starts the gameplay stream over for the seed
+func Seed(seed int64) {
+	Gameplay.Seed(seed)
+}
+
+// cosmeticSalt keeps CosmeticFor from drawing the same numbers as Gameplay for the same seed
+const cosmeticSalt = 0x5eedc010
+
+// CosmeticFor is a cosmetic stream of the seed's own, for the looks that should be the same every
+// time the tower is played, so screenshots and replays of a shared tower look alike. Drawing from
+// it changes nothing in Gameplay or Cosmetic.
+func CosmeticFor(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed ^ cosmeticSalt))
+}
+
+// lockedSource is a source that's safe to share between goroutines, like the one behind the
+// math/rand functions
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Uint64() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Uint64()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
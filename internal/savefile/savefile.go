@@ -0,0 +1,82 @@
+// Package savefile versions the JSON files the games save, so an old file is upgraded when it's
+// loaded instead of being thrown away or failing to parse. Every kind of file has its list of
+// migrations, each one takes a file from one version to the next, working on the JSON object as
+// a generic map. A file without a version is version 0, from before the versions.
+package savefile
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// VersionKey is the field of the JSON object the version goes in
+const VersionKey = "version"
+
+// Migration upgrades a file by one version, in place
+type Migration func(doc map[string]interface{}) error
+
+// Kind is a kind of save file and its migrations
+type Kind struct {
+	name       string
+	migrations []Migration
+}
+
+// NewKind registers the migrations of a kind of file, the first one upgrades version 0 to 1, the
+// second 1 to 2 and so on. A new migration goes on the end whenever the format changes.
+func NewKind(name string, migrations ...Migration) *Kind {
+	return &Kind{name: name, migrations: migrations}
+}
+
+// Version is the current version of the kind, the one files are saved with
+func (k *Kind) Version() int {
+	return len(k.migrations)
+}
+
+// Upgrade reads the file, runs the migrations it's missing and gives it back at the current
+// version, upgraded is whether anything had to be done
+func (k *Kind) Upgrade(data []byte) (current []byte, upgraded bool, err error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, false, errors.Wrapf(err, "error reading the %s", k.name)
+	}
+	version := 0
+	if v, ok := doc[VersionKey]; ok {
+		f, ok := v.(float64)
+		if !ok || f < 0 || f != float64(int(f)) {
+			return nil, false, errors.Errorf("error reading the %s: bad version %v", k.name, v)
+		}
+		version = int(f)
+	}
+	if version > k.Version() {
+		return nil, false, errors.Errorf("the %s is from a newer version of the game (version %d, this one knows up to %d)", k.name, version, k.Version())
+	}
+	if version == k.Version() {
+		return data, false, nil
+	}
+	for v := version; v < k.Version(); v++ {
+		if err := k.migrations[v](doc); err != nil {
+			return nil, false, errors.Wrapf(err, "error upgrading the %s from version %d", k.name, v)
+		}
+	}
+	doc[VersionKey] = k.Version()
+	current, err = json.MarshalIndent(doc, "", "\t")
+	return current, true, errors.Wrapf(err, "error upgrading the %s", k.name)
+}
+
+// Baseline is the first migration of a kind, from the files from before the versions, which
+// only need the version added
+func Baseline(doc map[string]interface{}) error {
+	return nil
+}
+
+// Rename is a migration that renames a field, when it's there
+func Rename(from, to string) Migration {
+	return func(doc map[string]interface{}) error {
+		if v, ok := doc[from]; ok {
+			doc[to] = v
+			delete(doc, from)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,43 @@
+package savefile
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestUpgrade(t *testing.T) {
+	k := NewKind("test file", Baseline, Rename("old", "new"))
+
+	cur, upgraded, err := k.Upgrade([]byte(`{"old": 5, "other": "x"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !upgraded {
+		t.Error("a file without a version wasn't upgraded")
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(cur, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["new"] != 5.0 || doc["old"] != nil || doc["other"] != "x" || doc[VersionKey] != 2.0 {
+		t.Errorf("upgraded to %s", cur)
+	}
+
+	// a file at the current version is left alone
+	same := []byte(`{"version": 2, "old": 1}`)
+	if cur, upgraded, err := k.Upgrade(same); err != nil || upgraded || string(cur) != string(same) {
+		t.Errorf("current file changed: %s %v %v", cur, upgraded, err)
+	}
+
+	// only the missing migrations run
+	cur, _, err = k.Upgrade([]byte(`{"version": 1, "old": 1}`))
+	if err != nil || json.Unmarshal(cur, &doc) != nil || doc["new"] != 1.0 {
+		t.Errorf("upgraded version 1 to %s: %v", cur, err)
+	}
+
+	for _, bad := range []string{`{"version": 3}`, `{"version": "2"}`, `{"version": -1}`, `[]`} {
+		if _, _, err := k.Upgrade([]byte(bad)); err == nil {
+			t.Errorf("upgraded %s", bad)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+// Package sprite loads the spritesheets of the characters: the pictures, the frames cut out of
+// them and the animations the descriptor names. It knows nothing about the tower, any game with a
+// sheet and a descriptor can use it.
+package sprite
+
+import (
+	"encoding/csv"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	_ "image/png"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// Frame is a single frame of an animation, a character's animations may be spread over several
+// sheets (e.g. the body on one, effects on another)
+type Frame struct {
+	// Sheet is the id of the sheet the frame is on, the base sheet has the empty id
+	Sheet string
+	Pic   pixel.Picture
+	Rect  pixel.Rect
+}
+
+// LoadPicture decodes the image at path
+func LoadPicture(path string) (pixel.Picture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, err
+	}
+	return pixel.PictureDataFromImage(img), nil
+}
+
+// SliceFrames cuts the sheet into a row of equally wide frames
+func SliceFrames(sheet pixel.Picture, frameWidth float64) []pixel.Rect {
+	var frames []pixel.Rect
+	for x := 0.0; x+frameWidth <= sheet.Bounds().Max.X; x += frameWidth {
+		frames = append(frames, pixel.R(
+			x,
+			0,
+			x+frameWidth,
+			sheet.Bounds().H(),
+		))
+	}
+	return frames
+}
+
+// descProblems collects everything wrong with a descriptor, so all of it can be fixed in one go
+type descProblems struct {
+	path     string
+	problems []string
+}
+
+func (dp *descProblems) add(row int, format string, args ...interface{}) {
+	dp.problems = append(dp.problems, fmt.Sprintf("%s:%d: %s", dp.path, row, fmt.Sprintf(format, args...)))
+}
+
+func (dp *descProblems) addFile(format string, args ...interface{}) {
+	dp.problems = append(dp.problems, fmt.Sprintf("%s: %s", dp.path, fmt.Sprintf(format, args...)))
+}
+
+func (dp *descProblems) err() error {
+	if len(dp.problems) == 0 {
+		return nil
+	}
+	return errors.New(strings.Join(dp.problems, "\n"))
+}
+
+// LoadSheet loads the base spritesheet and its descriptor. Each descriptor row is
+// "name,start,end" for frames of the base sheet, or "name,start,end,sheet" for frames of an extra
+// sheet declared with a "sheet,id,path,frameWidth" row (path relative to the descriptor). The base
+// sheet has the empty id. The descriptor is checked up front, along with the required animations,
+// instead of blowing up somewhere in the middle of a run.
+func LoadSheet(sheetPath, descPath string, frameWidth float64, required ...string) (sheets map[string]pixel.Picture, anims map[string][]Frame, err error) {
+	// total hack, nicely format the error at the end, so I don't have to type it every time
+	defer func() {
+		if err != nil {
+			err = errors.Wrap(err, "error loading animation sheet")
+		}
+	}()
+
+	// open and load the spritesheet
+	sheet, err := LoadPicture(sheetPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	sheets = map[string]pixel.Picture{"": sheet}
+
+	// create a slice of frames inside each spritesheet
+	frames := map[string][]pixel.Rect{"": SliceFrames(sheet, frameWidth)}
+
+	descFile, err := os.Open(descPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer descFile.Close()
+
+	// rows have a varying number of fields, sheet declarations go first
+	desc := csv.NewReader(descFile)
+	desc.FieldsPerRecord = -1
+	rows, err := desc.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	dp := &descProblems{path: descPath}
+	for i, row := range rows {
+		if row[0] != "sheet" {
+			continue
+		}
+		if len(row) != 4 {
+			dp.add(i+1, "sheet needs an id, path and frame width, got %d fields", len(row)-1)
+			continue
+		}
+		id, path := row[1], filepath.Join(filepath.Dir(descPath), row[2])
+		if _, ok := sheets[id]; ok {
+			dp.add(i+1, "duplicate sheet %q", id)
+			continue
+		}
+		width, err := strconv.ParseFloat(row[3], 64)
+		if err != nil || width <= 0 {
+			dp.add(i+1, "bad frame width %q", row[3])
+			continue
+		}
+		pic, err := LoadPicture(path)
+		if err != nil {
+			dp.add(i+1, "%v", err)
+			continue
+		}
+		sheets[id] = pic
+		frames[id] = SliceFrames(pic, width)
+	}
+
+	anims = make(map[string][]Frame)
+	seen := make(map[string]int)
+
+	// load the animation information, name and interval inside the spritesheet
+	for i, anim := range rows {
+		if anim[0] == "sheet" {
+			continue
+		}
+		if len(anim) != 3 && len(anim) != 4 {
+			dp.add(i+1, "expected name,start,end[,sheet], got %d fields", len(anim))
+			continue
+		}
+		name := anim[0]
+		if prev, ok := seen[name]; ok {
+			dp.add(i+1, "duplicate animation %q, first defined on row %d", name, prev)
+			continue
+		}
+		seen[name] = i + 1
+		start, err := strconv.Atoi(anim[1])
+		if err != nil {
+			dp.add(i+1, "bad start frame %q", anim[1])
+			continue
+		}
+		end, err := strconv.Atoi(anim[2])
+		if err != nil {
+			dp.add(i+1, "bad end frame %q", anim[2])
+			continue
+		}
+		id := ""
+		if len(anim) > 3 {
+			id = anim[3]
+		}
+		sheetFrames, ok := frames[id]
+		if !ok {
+			dp.add(i+1, "unknown sheet %q", id)
+			continue
+		}
+		if start < 0 || end < start || end >= len(sheetFrames) {
+			dp.add(i+1, "frames %d-%d out of range, the sheet has %d frames", start, end, len(sheetFrames))
+			continue
+		}
+
+		for _, f := range sheetFrames[start : end+1] {
+			anims[name] = append(anims[name], Frame{Sheet: id, Pic: sheets[id], Rect: f})
+		}
+	}
+
+	for _, name := range required {
+		if _, ok := anims[name]; !ok {
+			dp.addFile("missing required animation %q", name)
+		}
+	}
+	if err := dp.err(); err != nil {
+		return nil, nil, err
+	}
+
+	return sheets, anims, nil
+}
@@ -0,0 +1,177 @@
+// Package storage is where the games keep their files: saves, settings, runs, logs and mods.
+// They all go in one data directory, in the usual place for the OS
+// ($XDG_DATA_HOME on Linux, %APPDATA% on Windows, ~/Library/Application Support on macOS) unless
+// SetDir moves it, for portable installs.
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// the files and directories in the data directory
+const (
+	Stats      = "stats.json"
+	Settings   = "settings.json"
+	InstallKey = "install.key"
+	Runs       = "runs"
+	Logs       = "logs"
+	Mods       = "mods"
+	Challenges = "challenges.json"
+
+	// ChallengeLog has the first run of every challenge played
+	ChallengeLog = "challengelog.json"
+	// Profile has the player's name and avatar
+	Profile = "profile.json"
+	// Outbox has the scores that couldn't be submitted yet
+	Outbox = "outbox.json"
+)
+
+const appName = "GoTower"
+
+var (
+	mu       sync.Mutex
+	override string
+	resolved string
+)
+
+// SetDir makes dir the data directory instead of the OS one, it has to be called before anything
+// is read or written
+func SetDir(dir string) {
+	mu.Lock()
+	defer mu.Unlock()
+	override, resolved = dir, ""
+}
+
+// Dir is the data directory, it's created the first time
+func Dir() (string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if resolved != "" {
+		return resolved, nil
+	}
+
+	dir := override
+	if dir == "" {
+		var err error
+		if dir, err = osDir(); err != nil {
+			return "", errors.Wrap(err, "error finding the data directory")
+		}
+		migrate(dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", errors.Wrap(err, "error creating the data directory")
+	}
+	resolved = dir
+	return dir, nil
+}
+
+func osDir() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, appName), nil
+		}
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support", appName), nil
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return filepath.Join(dir, appName), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share", appName), nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, appName), nil
+}
+
+// migrate moves the files from where older versions kept them (the user config directory) to
+// dir, if dir doesn't exist yet
+func migrate(dir string) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return
+	}
+	old := filepath.Join(config, appName)
+	if old == dir {
+		return
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return
+	}
+	if _, err := os.Stat(old); err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return
+	}
+	os.Rename(old, dir)
+}
+
+// Path is where name goes in the data directory, the directory it's in is created
+func Path(name ...string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(append([]string{dir}, name...)...)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", errors.Wrapf(err, "error creating the directory for %s", filepath.Join(name...))
+	}
+	return path, nil
+}
+
+// ReadFile reads a file from the data directory, a missing file is an os.IsNotExist error
+func ReadFile(name ...string) ([]byte, error) {
+	path, err := Path(name...)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(path)
+}
+
+// WriteFile writes a file to the data directory, through a temporary file, so a crash halfway
+// never leaves a broken one behind
+func WriteFile(data []byte, perm os.FileMode, name ...string) error {
+	path, err := Path(name...)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package tower
+
+import "GoTower/internal/physics"
+
+// the adaptive difficulty keeps a level, 1 for the normal tower, between adaptEasiest and
+// adaptHardest. A death takes adaptDeath off it and a near miss, dropping into the bottom of the
+// screen and climbing back out, adaptNearMiss; every floor climbed puts adaptFloor back. The gaps
+// between the platforms and the chance of a hazard go with the level.
+const (
+	adaptEasiest  = 0.75
+	adaptHardest  = 1.15
+	adaptDeath    = 0.08
+	adaptNearMiss = 0.03
+	adaptFloor    = 0.002
+)
+
+// adaptive is the adaptive difficulty, for players after a tower that's neither too easy nor too
+// hard. The runs with it are flagged on the scores.
+type adaptive struct {
+	on    bool
+	Level float64
+	// low is whether the gopher is down in the danger range, a near miss if it gets back out
+	low bool
+}
+
+// SetAdaptive turns the adaptive difficulty on or off, off puts the tower back to normal
+func (s *Sim) SetAdaptive(on bool) {
+	s.Adapt = adaptive{on: on, Level: 1}
+	s.Platforms.Spawner.margin, s.Platforms.Spawner.hazards = spawnMargin, 1
+}
+
+// adjust moves the difficulty by d and hands it to the spawner, only the platforms still to come
+// change
+func (s *Sim) adjust(d float64) {
+	a := &s.Adapt
+	if !a.on {
+		return
+	}
+	a.Level += d
+	if a.Level < adaptEasiest {
+		a.Level = adaptEasiest
+	}
+	if a.Level > adaptHardest {
+		a.Level = adaptHardest
+	}
+	// a smaller margin only counts the closer platforms as reachable
+	s.Platforms.Spawner.margin = spawnMargin * a.Level
+	s.Platforms.Spawner.hazards = a.Level
+}
+
+// watch looks for near misses after the step
+func (s *Sim) watch() {
+	a := &s.Adapt
+	if !a.on {
+		return
+	}
+	switch {
+	case s.Phys.Rect.Min.Y < physics.KillZone+physics.DangerRange:
+		a.low = true
+	case a.low && s.Phys.Ground:
+		a.low = false
+		s.adjust(-adaptNearMiss)
+	}
+}
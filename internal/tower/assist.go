@@ -0,0 +1,22 @@
+package tower
+
+// a jump still works coyoteTime after running off a platform and jumpBuffer before landing. The
+// timing assist widens them to assistCoyote and assistBuffer, and makes the goals assistReach
+// times as easy to touch.
+const (
+	coyoteTime   = 0.07
+	jumpBuffer   = 0.1
+	assistCoyote = 0.18
+	assistBuffer = 0.25
+	assistReach  = 1.3
+)
+
+// SetAssist turns the timing assist on or off, for players who find the exact timing hard. The
+// runs with it are flagged on the scores.
+func (s *Sim) SetAssist(on bool) {
+	s.assist = on
+	s.Phys.Coyote, s.Phys.Buffer, s.Goals.reach = coyoteTime, jumpBuffer, 1
+	if on {
+		s.Phys.Coyote, s.Phys.Buffer, s.Goals.reach = assistCoyote, assistBuffer, assistReach
+	}
+}
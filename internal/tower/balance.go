@@ -0,0 +1,86 @@
+package tower
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"GoTower/internal/physics"
+)
+
+// balanceConfig is one difficulty setup the balance harness tries
+type balanceConfig struct {
+	name string
+	// speed is the scroll speed t seconds into the run
+	speed func(t float64) float64
+	// hazards scales the chance of hazards on random platforms
+	hazards float64
+}
+
+var balanceConfigs = []balanceConfig{
+	{"the game's", scrollSpeed, 1},
+	{"flat 20", func(t float64) float64 { return 20 }, 1},
+	{"flat 30", func(t float64) float64 { return 30 }, 1},
+	{"ramp 20+t/4", func(t float64) float64 { return 20 + t/4 }, 1},
+	{"ramp 20+t/4, 2x hazards", func(t float64) float64 { return 20 + t/4 }, 2},
+	{"flat 20, no hazards", func(t float64) float64 { return 20 }, 0},
+}
+
+// Balance plays runs games with the bot for every config, each run until the first death or
+// maxTime seconds, and writes the survival time distributions to w. Run i of every config uses
+// seed+i, so the configs are compared on the same towers as far as they go.
+func Balance(w io.Writer, chunks []*Chunk, runs int, seed int64, maxTime float64) {
+	base := hazardChance
+	defer func() { hazardChance = base }()
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "config\truns\tmean\tp10\tp25\tp50\tp75\tp90\tsurvived\tfloors\t")
+	for _, cfg := range balanceConfigs {
+		for i := range hazardChance {
+			hazardChance[i] = base[i] * cfg.hazards
+		}
+
+		times := make([]float64, runs)
+		survived, floors := 0, 0
+		for i := 0; i < runs; i++ {
+			t, floor := balanceRun(cfg, chunks, seed+int64(i), maxTime)
+			times[i] = t
+			floors += floor
+			if t >= maxTime {
+				survived++
+			}
+		}
+		sort.Float64s(times)
+		mean := 0.0
+		for _, t := range times {
+			mean += t
+		}
+		mean /= float64(runs)
+		pct := func(p float64) float64 { return times[int(p*float64(runs-1))] }
+		fmt.Fprintf(tw, "%s\t%d\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.1f\t%.0f%%\t%.0f\t\n",
+			cfg.name, runs, mean, pct(0.1), pct(0.25), pct(0.5), pct(0.75), pct(0.9),
+			100*float64(survived)/float64(runs), float64(floors)/float64(runs))
+	}
+	tw.Flush()
+}
+
+// balanceRun plays one headless game, returning how long the bot survived and the highest floor
+func balanceRun(cfg balanceConfig, chunks []*Chunk, seed int64, maxTime float64) (float64, int) {
+	ResetWorld(seed)
+
+	dead := false
+	Bus.Subscribe(func(e Event) {
+		if _, ok := e.(physics.Died); ok {
+			dead = true
+		}
+	})
+	s := NewSim(chunks)
+	s.speed = cfg.speed
+	b := &Bot{}
+	t := 0.0
+	for ; t < maxTime && !dead; t += SimStep {
+		s.Update(SimStep, b.Control(s))
+	}
+	return t, s.Phys.Floor
+}
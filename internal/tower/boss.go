@@ -0,0 +1,159 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"golang.org/x/image/colornames"
+)
+
+// a boss shows up every bossFloors floors, surviving it is worth bossBonus points
+const (
+	bossFloors = 200
+	bossBonus  = 20
+)
+
+// BossSurvived is published when the boss gives up
+type BossSurvived struct {
+	Bonus int
+}
+
+// BossWarned is published when the boss is about to sweep in, dir is the way it flies, so it
+// comes from the left when dir is 1
+type BossWarned struct {
+	Dir float64
+}
+
+type bossState int
+
+const (
+	bossAway bossState = iota
+	bossWarning
+	bossSweeping
+	bossResting
+)
+
+// boss is a giant bird sweeping across the tower, it eats the platforms in its way and knocks
+// the gopher out of the tower. It's announced by a warning at the edge it comes from.
+type boss struct {
+	State  bossState
+	Timer  float64
+	sweeps int
+
+	Pos  pixel.Vec
+	dir  float64
+	size pixel.Vec
+
+	speed    float64
+	warning  float64
+	rest     float64
+	maxSweep int
+}
+
+func newBoss() *boss {
+	return &boss{
+		size:     pixel.V(40, 16),
+		speed:    220,
+		warning:  1,
+		rest:     0.6,
+		maxSweep: 5,
+	}
+}
+
+func (b *boss) active() bool {
+	return b.State != bossAway
+}
+
+func (b *boss) onEvent(e Event) {
+	if e, ok := e.(physics.FloorReached); ok && e.Floor%bossFloors == 0 && !b.active() {
+		b.State = bossResting
+		b.Timer = 0
+		b.sweeps = 0
+	}
+}
+
+func (b *boss) rect() pixel.Rect {
+	return pixel.Rect{Min: b.Pos.Sub(b.size.Scaled(0.5)), Max: b.Pos.Add(b.size.Scaled(0.5))}
+}
+
+func (b *boss) update(dt float64, phys *physics.Body, platforms *platformManager) {
+	if !b.active() {
+		return
+	}
+	b.Timer += dt
+
+	switch b.State {
+	case bossResting:
+		if b.Timer < b.rest {
+			return
+		}
+		if b.sweeps == b.maxSweep {
+			b.State = bossAway
+			Bus.Publish(BossSurvived{Bonus: bossBonus})
+			return
+		}
+		// aim around the gopher, from a random side
+		b.dir = 1
+		if rng.Gameplay.Intn(2) == 0 {
+			b.dir = -1
+		}
+		y := phys.Rect.Center().Y + float64(rng.Gameplay.Intn(40)-10)
+		b.Pos = pixel.V(-b.dir*(160+b.size.X), math.Max(-100, math.Min(y, 100)))
+		b.State, b.Timer = bossWarning, 0
+		Bus.Publish(BossWarned{Dir: b.dir})
+
+	case bossWarning:
+		if b.Timer >= b.warning {
+			b.State, b.Timer = bossSweeping, 0
+		}
+
+	case bossSweeping:
+		b.Pos.X += b.dir * b.speed * dt
+		r := b.rect()
+		for _, p := range platforms.All() {
+			if r.Intersects(p.Rect) {
+				platforms.remove(p.ID)
+			}
+		}
+		if r.Intersects(phys.Rect) {
+			phys.Die("boss", PhysicsWorld())
+		}
+		if b.dir*b.Pos.X > 160+b.size.X {
+			b.sweeps++
+			b.State, b.Timer = bossResting, 0
+		}
+	}
+}
+
+func (b *boss) draw(imd *imdraw.IMDraw) {
+	switch b.State {
+	case bossWarning:
+		// blinking arrow on the side it's coming from
+		if int(b.Timer*8)%2 == 0 {
+			x := -b.dir * 150
+			imd.Color = colornames.Red
+			imd.Push(pixel.V(x, b.Pos.Y+6), pixel.V(x, b.Pos.Y-6), pixel.V(x+b.dir*10, b.Pos.Y))
+			imd.Polygon(0)
+		}
+
+	case bossSweeping:
+		flap := math.Sin(b.Timer*20) * 8
+		imd.Color = colornames.Darkslateblue
+		imd.Push(b.Pos)
+		imd.Ellipse(b.size.Scaled(0.5), 0)
+		imd.Push(b.Pos.Add(pixel.V(-8, 0)), b.Pos.Add(pixel.V(8, 0)), b.Pos.Add(pixel.V(0, 14+flap)))
+		imd.Polygon(0)
+		// beak and eye, looking where it's going
+		imd.Color = colornames.Orange
+		front := b.Pos.Add(pixel.V(b.dir*b.size.X/2, 0))
+		imd.Push(front.Add(pixel.V(0, 3)), front.Add(pixel.V(0, -3)), front.Add(pixel.V(b.dir*8, 0)))
+		imd.Polygon(0)
+		imd.Color = colornames.White
+		imd.Push(b.Pos.Add(pixel.V(b.dir*12, 3)))
+		imd.Circle(2, 0)
+	}
+}
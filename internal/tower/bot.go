@@ -0,0 +1,113 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+)
+
+// Bot plays the tower by looking at the sim, like a player would look at the screen: from the
+// ground it picks the platform that leads the highest in a few jumps the spawner considers
+// possible, runs under it and jumps, dropping down when there's no way up. It hops over saws and
+// keeps off spikes, but doesn't try to collect goals.
+type Bot struct {
+	target entity.PlatformID
+}
+
+// botLookahead is how many jumps ahead the bot plans
+const botLookahead = 3
+
+// Control is the command for the next step of s
+func (b *Bot) Control(s *Sim) Command {
+	return Command{Tick: s.Tick, Actions: packInput(b.steer(s))}
+}
+
+// steer is the direction the bot wants to go in
+func (b *Bot) steer(s *Sim) pixel.Vec {
+	gp := s.Phys
+	pos := gp.Rect.Center()
+	sp := s.Platforms.Spawner
+	ctrl := pixel.ZV
+
+	cur := s.Platforms.get(gp.GroundID)
+	if gp.Ground && cur != nil {
+		b.target = 0
+		bestHeight, bestCost := math.Inf(-1), math.Inf(1)
+		for _, p := range s.Platforms.All() {
+			dh := p.Rect.Max.Y - cur.Rect.Max.Y
+			gap := math.Max(p.Rect.Min.X-cur.Rect.Max.X, cur.Rect.Min.X-p.Rect.Max.X)
+			up := dh > 0 && sp.reachable(cur, p)
+			down := dh <= 0 && dh > -2*physics.FloorHeight && gap > 0
+			if p == cur || !up && !down {
+				continue
+			}
+			// the higher it leads the better, then the lowest step and the closest one
+			h := b.height(s, p, botLookahead)
+			cost := math.Abs(dh)*10 + math.Abs(p.Rect.Center().X-pos.X)
+			if h > bestHeight || h == bestHeight && cost < bestCost {
+				bestHeight, bestCost = h, cost
+				b.target = p.ID
+			}
+		}
+
+		// hop over a saw coming along the platform
+		if h := cur.Hazard; h != nil && h.Kind == entity.Saw {
+			if h.Area(cur).Moved(pixel.V(-h.Speed/4, 0)).Intersects(gp.Rect.Resized(pos, pixel.V(24, gp.Rect.H()))) {
+				ctrl.Y = 1
+			}
+		}
+	}
+
+	target := s.Platforms.get(b.target)
+	if target == nil {
+		return ctrl
+	}
+
+	// aim for the middle of the safe part of the target
+	lo, hi := target.Rect.Min.X+gp.Rect.W(), target.Rect.Max.X-gp.Rect.W()
+	if h := target.Hazard; h != nil && h.Kind == entity.Spikes {
+		a := h.Area(target)
+		if a.Min.X-target.Rect.Min.X > target.Rect.Max.X-a.Max.X {
+			hi = a.Min.X - gp.Rect.W()
+		} else {
+			lo = a.Max.X + gp.Rect.W()
+		}
+	}
+	x := (lo + hi) / 2
+	if lo > hi {
+		x = target.Rect.Center().X
+	}
+	switch {
+	case x < pos.X-2:
+		ctrl.X = -1
+	case x > pos.X+2:
+		ctrl.X = +1
+	}
+
+	// jump once the target is close enough sideways to make it, or at the latest from the edge
+	if gp.Ground && cur != nil && cur != target {
+		gap := math.Max(0, math.Max(target.Rect.Min.X-gp.Rect.Max.X, gp.Rect.Min.X-target.Rect.Max.X))
+		edge := pos.X+ctrl.X*gp.Rect.W() < cur.Rect.Min.X || pos.X+ctrl.X*gp.Rect.W() > cur.Rect.Max.X
+		if edge || gap <= 0.75*sp.reach(target.Rect.Max.Y-gp.Rect.Min.Y) {
+			ctrl.Y = 1
+		}
+	}
+	return ctrl
+}
+
+// height is the highest top the bot can get to from p in jumps jumps
+func (b *Bot) height(s *Sim, p *entity.Platform, jumps int) float64 {
+	h := p.Rect.Max.Y
+	if jumps == 0 {
+		return h
+	}
+	for _, q := range s.Platforms.All() {
+		if q.Rect.Max.Y > p.Rect.Max.Y && s.Platforms.Spawner.reachable(p, q) {
+			h = math.Max(h, b.height(s, q, jumps-1))
+		}
+	}
+	return h
+}
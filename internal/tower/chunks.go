@@ -0,0 +1,250 @@
+package tower
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// the generator gets harder in tiers, one every TierFloors floors
+const (
+	tiers      = 3
+	TierFloors = 100
+)
+
+// chunkPlatform is one platform of an authored chunk, positions are relative to the bottom of
+// the chunk, x is the left edge
+type chunkPlatform struct {
+	X        float64 `json:"x"`
+	Y        float64 `json:"y"`
+	W        float64 `json:"w"`
+	Slope    float64 `json:"slope,omitempty"`
+	Material string  `json:"material,omitempty"`
+
+	// Swing makes the platform move sideways this far, SwingSpeed in radians per second
+	Swing      float64 `json:"swing,omitempty"`
+	SwingSpeed float64 `json:"swingSpeed,omitempty"`
+
+	// Hazard is "saw" or "spikes"
+	Hazard string `json:"hazard,omitempty"`
+}
+
+// chunkDecoration is a decoration of an authored chunk, placed like its platforms, Kind is
+// "torch", "flag", "cloud" or "bricks"
+type chunkDecoration struct {
+	Kind string  `json:"kind"`
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+}
+
+// chunkSpot is a point in a chunk, placed like its platforms
+type chunkSpot struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Chunk is a small authored piece of the tower (a zigzag, a spring tower...), the generator
+// stitches them together with its own random platforms
+type Chunk struct {
+	Name string `json:"name"`
+	// Weights is how likely the chunk is picked in each difficulty tier, zero never
+	Weights   []float64       `json:"weights"`
+	Platforms []chunkPlatform `json:"platforms"`
+	// Decorations don't count for the height of the chunk, nothing stands on them
+	Decorations []chunkDecoration `json:"decorations,omitempty"`
+
+	// Opening chunks are only ever the bottom of the tower, one of them is picked for every
+	// seed. They start at the bottom of the screen, have a platform under the gopher's start
+	// and the first Goal.
+	Opening bool       `json:"opening,omitempty"`
+	Goal    *chunkSpot `json:"goal,omitempty"`
+}
+
+// height is how much of the tower the chunk takes up
+func (c *Chunk) height() float64 {
+	h := 0.0
+	for _, p := range c.Platforms {
+		if p.Y > h {
+			h = p.Y
+		}
+	}
+	return h + physics.FloorHeight
+}
+
+func (c *Chunk) weight(tier int) float64 {
+	if c.Opening || tier >= len(c.Weights) {
+		return 0
+	}
+	return c.Weights[tier]
+}
+
+// platform makes the i-th platform of the chunk with the bottom of the chunk at y, mirrored
+// swaps left and right
+func (c *Chunk) platform(i int, y float64, mirror bool) entity.Platform {
+	cp := c.Platforms[i]
+	if mirror {
+		cp.X = -cp.X - cp.W
+		cp.Slope = -cp.Slope
+	}
+	pf := entity.Platform{
+		Rect:       pixel.R(cp.X, y+cp.Y, cp.X+cp.W, y+cp.Y+2),
+		Color:      RandomNiceColor(),
+		Mat:        MaterialByName(cp.Material),
+		Slope:      cp.Slope,
+		Swing:      cp.Swing,
+		SwingSpeed: cp.SwingSpeed,
+		BaseX:      cp.X,
+	}
+	if kind, ok := entity.HazardNames[cp.Hazard]; ok {
+		pf.Hazard = entity.NewHazard(kind, &pf)
+	}
+	return pf
+}
+
+// decorate puts the chunk's decorations on dl with the bottom of the chunk at y
+func (c *Chunk) decorate(dl *decorLayer, y float64, mirror bool) {
+	for _, d := range c.Decorations {
+		x := d.X
+		if mirror {
+			x = -x
+		}
+		dl.add(decorNames[d.Kind], pixel.V(x, y+d.Y))
+	}
+}
+
+func MaterialByName(name string) *entity.Material {
+	for _, m := range materials {
+		if m.Name == name {
+			return m
+		}
+	}
+	return normal
+}
+
+// LoadChunks reads the chunk library and checks it makes sense
+func LoadChunks(path string) ([]*Chunk, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading chunks")
+	}
+	return ParseChunks(data, path)
+}
+
+// ParseChunks reads a chunk library from data, path names it in the errors
+func ParseChunks(data []byte, path string) ([]*Chunk, error) {
+	var chunks []*Chunk
+	if err := json.Unmarshal(data, &chunks); err != nil {
+		return nil, errors.Wrapf(err, "error loading chunks from %s", path)
+	}
+
+	var problems []string
+	for i, c := range chunks {
+		where := fmt.Sprintf("%s: chunk %d (%s)", path, i, c.Name)
+		if len(c.Platforms) == 0 {
+			problems = append(problems, where+": no platforms")
+		}
+		if len(c.Weights) > tiers {
+			problems = append(problems, fmt.Sprintf("%s: %d weights, there are only %d tiers", where, len(c.Weights), tiers))
+		}
+		for j, p := range c.Platforms {
+			if p.W <= 0 {
+				problems = append(problems, fmt.Sprintf("%s: platform %d has no width", where, j))
+			}
+			if p.X-p.Swing < -160 || p.X+p.W+p.Swing > 160 {
+				problems = append(problems, fmt.Sprintf("%s: platform %d sticks out of the tower", where, j))
+			}
+			if p.Material != "" && MaterialByName(p.Material).Name != p.Material {
+				problems = append(problems, fmt.Sprintf("%s: platform %d has unknown material %q", where, j, p.Material))
+			}
+			if _, ok := entity.HazardNames[p.Hazard]; p.Hazard != "" && !ok {
+				problems = append(problems, fmt.Sprintf("%s: platform %d has unknown hazard %q", where, j, p.Hazard))
+			}
+		}
+		if c.Opening {
+			problems = append(problems, c.checkOpening(where)...)
+		}
+		for j, d := range c.Decorations {
+			if _, ok := decorNames[d.Kind]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: decoration %d has unknown kind %q", where, j, d.Kind))
+			}
+			if d.X < -160 || d.X > 160 {
+				problems = append(problems, fmt.Sprintf("%s: decoration %d is outside the tower", where, j))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return chunks, nil
+}
+
+// the gopher starts with its feet startY above the bottom of an opening
+const startY = 160
+
+// checkOpening is what's wrong with the chunk as an opening, where says which one it is
+func (c *Chunk) checkOpening(where string) []string {
+	var problems []string
+	if c.Goal == nil {
+		problems = append(problems, where+": an opening needs a goal")
+	}
+	under := false
+	for _, p := range c.Platforms {
+		if p.X <= 0 && p.X+p.W >= 0 && p.Y <= startY && p.Swing == 0 {
+			under = true
+		}
+	}
+	if !under {
+		problems = append(problems, where+": an opening needs a still platform under the gopher's start")
+	}
+	return problems
+}
+
+// plainOpening is the bottom of the tower for chunk libraries without openings, like the older
+// community levels
+var plainOpening = &Chunk{
+	Name:      "plain",
+	Opening:   true,
+	Platforms: []chunkPlatform{{X: -80, Y: startY - physics.FloorHeight, W: 160}},
+	Goal:      &chunkSpot{X: 50, Y: startY},
+}
+
+// pickOpening chooses the bottom of the tower among the openings, nil if there's none
+func pickOpening(chunks []*Chunk) *Chunk {
+	var openings []*Chunk
+	for _, c := range chunks {
+		if c.Opening {
+			openings = append(openings, c)
+		}
+	}
+	if len(openings) == 0 {
+		return nil
+	}
+	return openings[rng.Gameplay.Intn(len(openings))]
+}
+
+// pickChunk chooses a chunk for the tier, nil if none of them fit
+func pickChunk(chunks []*Chunk, tier int) *Chunk {
+	total := 0.0
+	for _, c := range chunks {
+		total += c.weight(tier)
+	}
+	if total == 0 {
+		return nil
+	}
+	r := rng.Gameplay.Float64() * total
+	for _, c := range chunks {
+		if r < c.weight(tier) {
+			return c
+		}
+		r -= c.weight(tier)
+	}
+	return nil
+}
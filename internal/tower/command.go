@@ -0,0 +1,70 @@
+package tower
+
+import "github.com/faiface/pixel"
+
+// Command is the input for one step of the sim, and the only input it takes. The keyboard,
+// replays and the bot all make commands, so they can't drift apart: Tick is the step it's for,
+// counted from the start of the run, and Actions are the InputLeft, InputRight... bits of what's
+// held.
+type Command struct {
+	Tick    uint32
+	Actions byte
+}
+
+// the actions of a command
+const (
+	InputLeft = 1 << iota
+	InputRight
+	InputDown
+	InputJump
+	// inputPick and the bit after it are the pick made at a milestone, its index plus one
+	inputPick
+	// InputSkip skips the countdown at the start of a run
+	InputSkip = inputPick << 2
+)
+
+// WithPick is the actions with the i-th pick on offer made
+func WithPick(actions byte, i int) byte {
+	return actions | byte(i+1)*inputPick
+}
+
+// pick is the index of the pick the command makes, -1 for none
+func (c Command) pick() int {
+	return int(c.Actions/inputPick&3) - 1
+}
+
+// packInput is the actions of a direction, the way the bot thinks about its input
+func packInput(ctrl pixel.Vec) byte {
+	var b byte
+	if ctrl.X < 0 {
+		b |= InputLeft
+	}
+	if ctrl.X > 0 {
+		b |= InputRight
+	}
+	if ctrl.Y < 0 {
+		b |= InputDown
+	}
+	if ctrl.Y > 0 {
+		b |= InputJump
+	}
+	return b
+}
+
+// ctrl is the command as a direction for the physics, jumping beats diving
+func (c Command) ctrl() pixel.Vec {
+	var ctrl pixel.Vec
+	if c.Actions&InputLeft != 0 {
+		ctrl.X--
+	}
+	if c.Actions&InputRight != 0 {
+		ctrl.X++
+	}
+	if c.Actions&InputDown != 0 {
+		ctrl.Y = -1
+	}
+	if c.Actions&InputJump != 0 {
+		ctrl.Y = 1
+	}
+	return ctrl
+}
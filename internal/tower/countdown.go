@@ -0,0 +1,44 @@
+package tower
+
+import "math"
+
+// every run starts with a countdown of countdownTime seconds, the gopher can move around but the
+// tower waits
+const countdownTime = 3
+
+// CountdownTick is published when the countdown gets to the next number, left is zero for go
+type CountdownTick struct {
+	Left int
+}
+
+// SetCountdown turns the countdown before every run on or off, on starts one right away
+func (s *Sim) SetCountdown(on bool) {
+	s.ceremony, s.Hold = on, 0
+	if on {
+		s.startCountdown()
+	}
+}
+
+func (s *Sim) startCountdown() {
+	s.Hold = countdownTime
+	Bus.Publish(CountdownTick{Left: countdownTime})
+}
+
+// holding runs the countdown down by dt and is whether the tower still waits, skipping it ends it
+// right away
+func (s *Sim) holding(dt float64, cmd Command) bool {
+	if s.Hold <= 0 {
+		return false
+	}
+	before := math.Ceil(s.Hold)
+	s.Hold -= dt
+	if cmd.Actions&InputSkip != 0 || s.Hold <= 0 {
+		s.Hold = 0
+		Bus.Publish(CountdownTick{Left: 0})
+		return false
+	}
+	if left := math.Ceil(s.Hold); left < before {
+		Bus.Publish(CountdownTick{Left: int(left)})
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"golang.org/x/image/colornames"
+)
+
+type decorKind int
+
+const (
+	// torches flicker on the wall
+	decorTorch decorKind = iota
+	// flags wave in the wind
+	decorFlag
+	// clouds drift slowly sideways
+	decorCloud
+	// bricks are darker patches of the wall
+	decorBricks
+)
+
+var decorNames = map[string]decorKind{
+	"torch":  decorTorch,
+	"flag":   decorFlag,
+	"cloud":  decorCloud,
+	"bricks": decorBricks,
+}
+
+// the generator puts a random decoration every decorGap or so of tower
+const decorGap = 45
+
+// decoration is a prop on the wall of the tower, it scrolls with the platforms but the gopher
+// goes right through it
+type decoration struct {
+	kind decorKind
+	pos  pixel.Vec
+	// phase is how far along its flicker or wave it is, so they don't all move together
+	phase float64
+	color pixel.RGBA
+}
+
+// decorLayer keeps the tower's decorations: the ones the chunks place and random ones sprinkled
+// in between. They only make the tower look varied, so they're placed with the cosmetic random
+// numbers.
+type decorLayer struct {
+	decor []decoration
+	// top is where the next random decoration goes, it scrolls down with the tower
+	top float64
+
+	imd *imdraw.IMDraw
+}
+
+func newDecorLayer() *decorLayer {
+	dl := &decorLayer{top: -120, imd: imdraw.New(nil)}
+	dl.imd.Precision = 16
+	dl.update(0)
+	return dl
+}
+
+// add puts a decoration of the kind at pos
+func (dl *decorLayer) add(kind decorKind, pos pixel.Vec) {
+	d := decoration{kind: kind, pos: pos, phase: rng.Cosmetic.Float64() * 2 * math.Pi}
+	if kind == decorFlag {
+		d.color = RandomNiceColor()
+	}
+	dl.decor = append(dl.decor, d)
+}
+
+// update scrolls the decorations down, drops the ones that fell out of the tower and sprinkles
+// new ones at the top
+func (dl *decorLayer) update(dt float64) {
+	kept := dl.decor[:0]
+	for _, d := range dl.decor {
+		d.pos.Y -= dt * Scroll
+		d.phase += dt
+		if d.kind == decorCloud {
+			d.pos.X += dt * 4
+			if d.pos.X > 190 {
+				d.pos.X -= 380
+			}
+		}
+		if d.pos.Y > -150 {
+			kept = append(kept, d)
+		}
+	}
+	dl.decor = kept
+
+	dl.top -= dt * Scroll
+	for dl.top <= 140 {
+		kind := decorKind(rng.Cosmetic.Intn(len(decorNames)))
+		dl.add(kind, pixel.V(-150+rng.Cosmetic.Float64()*300, dl.top))
+		dl.top += decorGap * (0.5 + rng.Cosmetic.Float64())
+	}
+}
+
+// Draw draws the decorations into their own batch, under everything else
+func (dl *decorLayer) Draw(t pixel.Target) {
+	imd := dl.imd
+	imd.Clear()
+	for _, d := range dl.decor {
+		d.draw(imd)
+	}
+	imd.Draw(t)
+}
+
+func (d *decoration) draw(imd *imdraw.IMDraw) {
+	p := d.pos
+	switch d.kind {
+	case decorTorch:
+		imd.Color = colornames.Saddlebrown
+		imd.Push(p, p.Add(pixel.V(0, 8)))
+		imd.Line(2)
+		flicker := 0.75 + 0.25*math.Sin(d.phase*17)*math.Sin(d.phase*5)
+		imd.Color = pixel.ToRGBA(colornames.Orange).Mul(pixel.Alpha(0.3))
+		imd.Push(p.Add(pixel.V(0, 11)))
+		imd.Circle(7*flicker, 0)
+		imd.Color = colornames.Yellow
+		imd.Push(p.Add(pixel.V(0, 10)))
+		imd.Circle(2.5*flicker, 0)
+	case decorFlag:
+		imd.Color = colornames.Gray
+		imd.Push(p, p.Add(pixel.V(0, 16)))
+		imd.Line(1)
+		wave := 2 * math.Sin(d.phase*4)
+		imd.Color = d.color
+		imd.Push(p.Add(pixel.V(0, 16)), p.Add(pixel.V(12, 13+wave)), p.Add(pixel.V(0, 10)))
+		imd.Polygon(0)
+	case decorCloud:
+		imd.Color = pixel.Alpha(0.12)
+		for _, c := range []pixel.Vec{{X: -8, Y: 0}, {X: 0, Y: 3}, {X: 8, Y: 0}} {
+			imd.Push(p.Add(c))
+			imd.Circle(7, 0)
+		}
+	case decorBricks:
+		imd.Color = pixel.RGB(0.12, 0.1, 0.1)
+		for row := 0; row < 3; row++ {
+			x := float64(row%2) * 5
+			for col := 0; col < 3; col++ {
+				min := p.Add(pixel.V(x+float64(col)*11, float64(row)*6))
+				imd.Push(min, min.Add(pixel.V(10, 5)))
+				imd.Rectangle(0)
+			}
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package tower
+
+import "github.com/faiface/pixel"
+
+// GoalCollected is published when the gopher picks up a goal worth Value
+type GoalCollected struct {
+	Pos   pixel.Vec
+	Value int
+}
+
+// Event is anything published on the bus, the subscribers pick the ones they want by type
+type Event = interface{}
+
+// EventBus hands the events of the tower to whoever subscribed to them
+type EventBus struct {
+	subs []func(Event)
+}
+
+// Subscribe registers fn to receive every published event, use a type switch to pick the
+// interesting ones
+func (b *EventBus) Subscribe(fn func(Event)) {
+	b.subs = append(b.subs, fn)
+}
+
+// Mark is how many subscribers there are, Drop goes back to that many, for the ones that only
+// last as long as a run
+func (b *EventBus) Mark() int {
+	return len(b.subs)
+}
+
+func (b *EventBus) Drop(mark int) {
+	b.subs = b.subs[:mark]
+}
+
+func (b *EventBus) Publish(e Event) {
+	for _, fn := range b.subs {
+		fn(e)
+	}
+}
+
+// Bus is the tower's events, the game's too
+var Bus = &EventBus{}
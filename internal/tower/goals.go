@@ -0,0 +1,199 @@
+package tower
+
+import (
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+	"golang.org/x/image/colornames"
+)
+
+// a risky goal is worth riskyValue times a safe one. Goals go on the platforms at most goalReach
+// below the top of the tower, a safe one on a platform at least safeWidth wide that doesn't move
+// or hurt.
+const (
+	riskyValue = 3
+	goalReach  = 60
+	safeWidth  = 50
+	// riskyHeight is how high over a platform a risky goal hangs when there's no hard platform
+	riskyHeight = 36
+)
+
+// goalManager keeps the goals of the tower: there's always a safe one, on an easy platform, and a
+// risky one, on a narrow, moving or dangerous one and worth more, besides the bonus ones like
+// the boss's reward. A goal that's collected or scrolls away is replaced at the top of the tower.
+type goalManager struct {
+	goals []*entity.Goal
+	// value multiplies what the goals are worth, from the mutators, reach how far from the
+	// gopher they're touched
+	value int
+	reach float64
+	// palette is the colors the goals of this tower cycle through, see entity.GoalPalette
+	palette []pixel.RGBA
+}
+
+func newGoalManager(first entity.Goal) *goalManager {
+	return &goalManager{goals: []*entity.Goal{&first}, value: 1, reach: 1}
+}
+
+// All returns the goals there are right now
+func (gm *goalManager) All() []*entity.Goal {
+	return gm.goals
+}
+
+// add puts a goal in the tower, next to the others
+func (gm *goalManager) add(g entity.Goal) {
+	g.Palette = gm.palette
+	gm.goals = append(gm.goals, &g)
+}
+
+// SetPalette gives the goals, the ones there are and the ones to come, the colors to cycle through
+func (gm *goalManager) SetPalette(palette []pixel.RGBA) {
+	gm.palette = palette
+	for _, g := range gm.goals {
+		g.Palette = palette
+	}
+}
+
+// update moves the goals, collects the ones the gopher touches and drops the ones that scrolled
+// away, then fills in the safe and the risky one if they're missing. The collected ones are worth
+// mult times their value.
+func (gm *goalManager) update(dt float64, pm *platformManager, gp *physics.Body, magnet float64, mult int) {
+	kept := gm.goals[:0]
+	for _, g := range gm.goals {
+		g.Update(dt, Scroll)
+		if g.On != 0 {
+			if p := pm.get(g.On); p != nil {
+				g.Pos.X = p.Rect.Min.X + g.Offset
+			}
+		}
+		g.Attract(dt, gp.Rect.Center(), magnet)
+		if g.Vel != pixel.ZV {
+			// flying at the gopher, it doesn't ride its platform anymore
+			g.On = 0
+		}
+		if g.Pos.Y+g.Radius < -120 {
+			continue
+		}
+		r := g.Radius * gm.reach
+		if g.Pos.X < gp.Rect.Max.X+r && g.Pos.X > gp.Rect.Min.X-r && g.Pos.Y < gp.Rect.Max.Y+r && g.Pos.Y > gp.Rect.Min.Y-r {
+			Bus.Publish(GoalCollected{Pos: g.Pos, Value: g.Value * mult})
+			continue
+		}
+		kept = append(kept, g)
+	}
+	gm.goals = kept
+
+	for _, risky := range []bool{false, true} {
+		if !gm.has(risky) {
+			gm.place(pm, risky)
+		}
+	}
+}
+
+// has is whether there's a normal goal of the kind, the bonus ones don't count
+func (gm *goalManager) has(risky bool) bool {
+	for _, g := range gm.goals {
+		if !g.Bonus && g.Risky == risky {
+			return true
+		}
+	}
+	return false
+}
+
+// taken is whether a goal sits on the platform already
+func (gm *goalManager) taken(p *entity.Platform) bool {
+	for _, g := range gm.goals {
+		if g.On == p.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// place puts a safe or a risky goal on a random platform near the top of the tower that suits it.
+// Without a hard platform around, the risky goal hangs high over the very end of one instead, none
+// goes in if there isn't a platform at all yet.
+func (gm *goalManager) place(pm *platformManager, risky bool) {
+	top := pm.newest()
+	if top == nil {
+		return
+	}
+	var fits, near []*entity.Platform
+	for _, p := range pm.All() {
+		if p.Rect.Min.Y < top.Rect.Min.Y-goalReach || gm.taken(p) {
+			continue
+		}
+		near = append(near, p)
+		hard := p.Rect.W() < entity.NarrowWidth || p.Swing != 0 || p.Hazard != nil
+		safe := !hard && p.Rect.W() >= safeWidth
+		if (risky && hard) || (!risky && safe) {
+			fits = append(fits, p)
+		}
+	}
+	x, high := 0.0, 10.0
+	var p *entity.Platform
+	switch {
+	case len(fits) > 0:
+		p = fits[rng.Gameplay.Intn(len(fits))]
+		x = p.Rect.Center().X
+	case risky && len(near) > 0:
+		p = near[rng.Gameplay.Intn(len(near))]
+		x, high = p.Rect.Min.X+2, riskyHeight
+		if rng.Gameplay.Intn(2) == 0 {
+			x = p.Rect.Max.X - 2
+		}
+	default:
+		return
+	}
+	g := entity.Goal{
+		Pos:    pixel.V(x, p.Top(x)+high),
+		Radius: 5,
+		Step:   1.0 / 7,
+		Value:  gm.value,
+		On:     p.ID,
+		Offset: x - p.Rect.Min.X,
+	}
+	if risky {
+		g.Risky = true
+		g.Radius = 4
+		g.Value = riskyValue * gm.value
+	}
+	gm.add(g)
+}
+
+// draw draws the goals with the theme's renderer r
+func (gm *goalManager) draw(imd *imdraw.IMDraw, r GoalRenderer) {
+	for _, g := range gm.goals {
+		r.Draw(imd, g)
+		if g.Risky {
+			// a gold ring tells the risky ones apart
+			imd.Color = colornames.Gold
+			imd.Push(g.Pos)
+			imd.Circle(g.Radius+2, 1)
+		}
+	}
+}
+
+// clone is a copy of the goals that shares nothing with them
+func (gm *goalManager) clone() goalManager {
+	c := goalManager{value: gm.value, reach: gm.reach, palette: gm.palette}
+	for _, g := range gm.goals {
+		c.add(*g)
+	}
+	return c
+}
+
+func RandomNiceColor() pixel.RGBA {
+	return entity.NiceColor(rng.Cosmetic)
+}
+
+// GoalRenderer draws the goals. draw puts a goal in imd or a batch of its own, flush draws the
+// batch over imd once it's drawn.
+type GoalRenderer interface {
+	Update(dt float64)
+	Draw(imd *imdraw.IMDraw, g *entity.Goal)
+	Flush(t pixel.Target)
+}
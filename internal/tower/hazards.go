@@ -0,0 +1,22 @@
+package tower
+
+import (
+	"GoTower/internal/entity"
+	"GoTower/internal/rng"
+)
+
+// hazardChance is how likely a random platform gets a hazard in each difficulty tier
+var hazardChance = [tiers]float64{0.05, 0.12, 0.2}
+
+// randomHazard rolls for a hazard on a freshly generated platform, the prestige tiers add to the
+// chance and bring in the flames
+func randomHazard(p *entity.Platform, tier, prestige int, rate float64) *entity.Hazard {
+	if rng.Gameplay.Float64() >= (hazardChance[tier]+prestigeHazard*float64(prestige))*rate {
+		return nil
+	}
+	kinds := 2
+	if prestige > 0 {
+		kinds = 3
+	}
+	return entity.NewHazard(entity.HazardKind(rng.Gameplay.Intn(kinds)), p)
+}
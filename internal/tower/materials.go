@@ -0,0 +1,41 @@
+package tower
+
+import (
+	"GoTower/internal/entity"
+	"GoTower/internal/rng"
+
+	"golang.org/x/image/colornames"
+)
+
+var materials = []*entity.Material{
+	{Name: "normal", Friction: 1, Speed: 1, Weight: 70},
+	{Name: "ice", Friction: 0.15, Speed: 1.2, Color: colornames.Lightcyan, Weight: 10},
+	{Name: "rubber", Friction: 1, Restitution: 0.7, Speed: 1, Color: colornames.Hotpink, Weight: 10},
+	{Name: "mud", Friction: 2, Speed: 0.5, Color: colornames.Saddlebrown, Weight: 10},
+	{Name: "crumbly", Friction: 1, Speed: 1, Crumbles: 0.6, Color: colornames.Tan, Weight: 6},
+}
+
+var normal = materials[0]
+
+// materialOf is what the platform is made of, normal for the platforms that don't say
+func materialOf(p *entity.Platform) *entity.Material {
+	if p.Mat == nil {
+		return normal
+	}
+	return p.Mat
+}
+
+func randomMaterial() *entity.Material {
+	total := 0.0
+	for _, m := range materials {
+		total += m.Weight
+	}
+	r := rng.Gameplay.Float64() * total
+	for _, m := range materials {
+		if r < m.Weight {
+			return m
+		}
+		r -= m.Weight
+	}
+	return normal
+}
@@ -0,0 +1,179 @@
+package tower
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// MaxMutators is how many mutators there can be, one per key from F1 to F8
+const MaxMutators = 8
+
+// Mutator changes the rules of a run, they're picked on the title screen and any number of them
+// can be on at once. They're data, in mutators.json: the physics are multiplied by the ones that
+// aren't zero.
+type Mutator struct {
+	// ID goes in the run log and the score, it's what flags the run as modified
+	ID   string `json:"id"`
+	Name string `json:"name"`
+
+	Gravity   float64 `json:"gravity,omitempty"`
+	RunSpeed  float64 `json:"runSpeed,omitempty"`
+	JumpSpeed float64 `json:"jumpSpeed,omitempty"`
+	// GoalValue multiplies what every goal is worth
+	GoalValue int `json:"goalValue,omitempty"`
+	// Magnet multiplies how close the goals have to be to fly at the gopher
+	Magnet float64 `json:"magnet,omitempty"`
+	// Scroll multiplies the scroll speed
+	Scroll float64 `json:"scroll,omitempty"`
+	// Lives are extra lives, a death with one left only takes it
+	Lives int `json:"lives,omitempty"`
+	// Mirror swaps left and right
+	Mirror bool `json:"mirror,omitempty"`
+	// Hidden platforms only fade in near the gopher
+	Hidden bool `json:"hidden,omitempty"`
+	// Wrap takes out the walls, the gopher wraps around the edges of the screen
+	Wrap bool `json:"wrap,omitempty"`
+	// Stamina makes the jumps drain a bar that only fills back on the ground
+	Stamina bool `json:"stamina,omitempty"`
+}
+
+// the hidden platforms are fully there within hiddenNear of the gopher and gone hiddenFade further
+const (
+	hiddenNear = 40
+	hiddenFade = 30
+)
+
+// LoadMutators reads the mutators and checks they make sense, there can be max of them
+func LoadMutators(path string, max int) ([]*Mutator, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading mutators")
+	}
+	var muts []*Mutator
+	if err := json.Unmarshal(data, &muts); err != nil {
+		return nil, errors.Wrapf(err, "error loading mutators from %s", path)
+	}
+
+	var problems []string
+	if len(muts) > max {
+		problems = append(problems, fmt.Sprintf("%s: %d mutators, there can only be %d", path, len(muts), max))
+	}
+	seen := map[string]bool{}
+	for i, m := range muts {
+		where := fmt.Sprintf("%s: mutator %d (%s)", path, i, m.ID)
+		if m.ID == "" || m.Name == "" {
+			problems = append(problems, where+": needs an id and a name")
+		}
+		if seen[m.ID] {
+			problems = append(problems, where+": duplicate id")
+		}
+		seen[m.ID] = true
+		if m.Gravity < 0 || m.RunSpeed < 0 || m.JumpSpeed < 0 || m.GoalValue < 0 || m.Magnet < 0 || m.Scroll < 0 || m.Lives < 0 {
+			problems = append(problems, where+": negative multiplier")
+		}
+	}
+	if len(problems) > 0 {
+		return nil, errors.New(strings.Join(problems, "\n"))
+	}
+	return muts, nil
+}
+
+// combine is the mutators all at once, mirroring twice is no mirroring
+func combine(muts []*Mutator) Mutator {
+	c := Mutator{Gravity: 1, RunSpeed: 1, JumpSpeed: 1, GoalValue: 1, Magnet: 1, Scroll: 1}
+	var ids []string
+	for _, m := range muts {
+		mul := func(v *float64, by float64) {
+			if by != 0 {
+				*v *= by
+			}
+		}
+		mul(&c.Gravity, m.Gravity)
+		mul(&c.RunSpeed, m.RunSpeed)
+		mul(&c.JumpSpeed, m.JumpSpeed)
+		mul(&c.Magnet, m.Magnet)
+		mul(&c.Scroll, m.Scroll)
+		c.Lives += m.Lives
+		if m.GoalValue != 0 {
+			c.GoalValue *= m.GoalValue
+		}
+		c.Mirror = c.Mirror != m.Mirror
+		c.Hidden = c.Hidden || m.Hidden
+		c.Wrap = c.Wrap || m.Wrap
+		c.Stamina = c.Stamina || m.Stamina
+		ids = append(ids, m.ID)
+	}
+	c.ID = strings.Join(ids, ",")
+	return c
+}
+
+// MutatorIDs are the ids of the mutators, for the run log and the score
+func MutatorIDs(muts []*Mutator) []string {
+	var ids []string
+	for _, m := range muts {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+// MutatorsByID are the mutators with the ids, the ones this version of the game doesn't have are
+// left out
+func MutatorsByID(all []*Mutator, ids []string) []*Mutator {
+	var muts []*Mutator
+	for _, id := range ids {
+		for _, m := range all {
+			if m.ID == id {
+				muts = append(muts, m)
+			}
+		}
+	}
+	return muts
+}
+
+// Mutate changes the rules of the sim, right after it's made, the extra lives are the first run's
+func (s *Sim) Mutate(muts []*Mutator) {
+	s.muts = muts
+	s.applyRules()
+	s.Phys.Lives = s.rules.Lives
+	for _, g := range s.Goals.All() {
+		g.Value *= s.rules.GoalValue
+	}
+}
+
+// applyRules works out the rules from the mutators and the picks of the run, the physics are the
+// starting ones changed by them, and the spawner's idea of how far the gopher jumps changes too
+func (s *Sim) applyRules() {
+	s.rules = combine(append(append([]*Mutator(nil), s.muts...), s.picks...))
+	s.Phys.Gravity = s.base.Gravity * s.rules.Gravity
+	s.Phys.RunSpeed = s.base.RunSpeed * s.rules.RunSpeed
+	s.Phys.JumpSpeed = s.base.JumpSpeed * s.rules.JumpSpeed
+	s.Phys.Wrap = s.rules.Wrap
+	if s.Phys.Stamina.On != s.rules.Stamina {
+		s.Phys.Stamina = physics.Stamina{On: s.rules.Stamina, Left: 1}
+	}
+	sp := s.Platforms.Spawner
+	sp.Gravity, sp.runSpeed, sp.jumpSpeed = s.Phys.Gravity, s.Phys.RunSpeed, s.Phys.JumpSpeed
+	s.Goals.value = s.rules.GoalValue
+}
+
+// visibility is how much of the platform shows, everything does unless the platforms are hidden
+func (s *Sim) visibility(p *entity.Platform) float64 {
+	if !s.rules.Hidden {
+		return 1
+	}
+	c := s.Phys.Rect.Center()
+	near := pixel.V(
+		math.Max(p.Rect.Min.X, math.Min(c.X, p.Rect.Max.X)),
+		math.Max(p.Rect.Min.Y, math.Min(c.Y, p.Rect.Max.Y+math.Max(p.Slope, 0))),
+	)
+	return math.Max(0, math.Min(1, 1-(c.To(near).Len()-hiddenNear)/hiddenFade))
+}
@@ -0,0 +1,39 @@
+package tower
+
+import "GoTower/internal/rng"
+
+// every pickFloors floors the run offers pickChoices picks from a pool of up to MaxPicks
+const (
+	pickFloors  = 100
+	pickChoices = 3
+	MaxPicks    = 16
+)
+
+// PickTaken is published when the run takes a pick, it's what the run log counts as power-ups
+type PickTaken struct {
+	ID string
+}
+
+// offerPicks puts a few different picks from the pool on offer, they're drawn from the gameplay
+// numbers, and which one is taken comes in with a command, so replays offer and pick the same
+func (s *Sim) offerPicks() {
+	if len(s.Pool) == 0 {
+		return
+	}
+	left := append([]*Mutator(nil), s.Pool...)
+	s.Offer = nil
+	for len(s.Offer) < pickChoices && len(left) > 0 {
+		i := rng.Gameplay.Intn(len(left))
+		s.Offer = append(s.Offer, left[i])
+		left = append(left[:i], left[i+1:]...)
+	}
+}
+
+// pick takes one of the picks on offer for the rest of the run
+func (s *Sim) pick(m *Mutator) {
+	s.Offer = nil
+	s.picks = append(s.picks, m)
+	s.applyRules()
+	s.Phys.Lives += m.Lives
+	Bus.Publish(PickTaken{m.ID})
+}
@@ -0,0 +1,187 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+)
+
+// platformManager owns the platforms of the tower. Adding and removing is deferred until flush,
+// so nothing changes under the feet of whoever is iterating over them.
+type platformManager struct {
+	nextID    entity.PlatformID
+	byID      map[entity.PlatformID]*entity.Platform
+	platforms []*entity.Platform
+
+	added   []*entity.Platform
+	removed map[entity.PlatformID]bool
+
+	// riders are everything that stands on platforms, aboard who's on which one, worked out at
+	// the start of every move
+	riders []rider
+	aboard map[entity.PlatformID][]rider
+
+	Spawner *spawner
+}
+
+// rider is anything that stands on the platforms, the platform it's on carries it along when it
+// moves
+type rider interface {
+	// Standing is the platform the rider is on, and whether it's on one at all
+	Standing() (entity.PlatformID, bool)
+	// Carry moves the rider by its platform's move
+	Carry(d pixel.Vec)
+}
+
+func newPlatformManager(sp *spawner) *platformManager {
+	return &platformManager{
+		byID:    make(map[entity.PlatformID]*entity.Platform),
+		removed: make(map[entity.PlatformID]bool),
+		aboard:  make(map[entity.PlatformID][]rider),
+		Spawner: sp,
+	}
+}
+
+// board adds a rider, for good
+func (pm *platformManager) board(r rider) {
+	pm.riders = append(pm.riders, r)
+}
+
+// aboardOf is who's standing on the platform, as of the last move
+func (pm *platformManager) aboardOf(id entity.PlatformID) []rider {
+	return pm.aboard[id]
+}
+
+// Add schedules a new platform and returns the ID it will have
+func (pm *platformManager) Add(p entity.Platform) entity.PlatformID {
+	pm.nextID++
+	p.ID = pm.nextID
+	pm.added = append(pm.added, &p)
+	return p.ID
+}
+
+// remove schedules the platform to be removed
+func (pm *platformManager) remove(id entity.PlatformID) {
+	pm.removed[id] = true
+}
+
+// get returns the platform with the ID, or nil if it's gone
+func (pm *platformManager) get(id entity.PlatformID) *entity.Platform {
+	return pm.byID[id]
+}
+
+// All returns the live platforms, oldest first
+func (pm *platformManager) All() []*entity.Platform {
+	return pm.platforms
+}
+
+// newest is the most recently spawned platform, the one at the top of the tower
+func (pm *platformManager) newest() *entity.Platform {
+	if len(pm.platforms) == 0 {
+		return nil
+	}
+	return pm.platforms[len(pm.platforms)-1]
+}
+
+// highlight lights up the platform, when the gopher lands on it
+func (pm *platformManager) highlight(id entity.PlatformID) {
+	if p := pm.get(id); p != nil {
+		p.Flash = entity.LandFlash
+	}
+}
+
+// flush applies the scheduled additions and removals
+func (pm *platformManager) flush() {
+	if len(pm.removed) > 0 {
+		kept := pm.platforms[:0]
+		for _, p := range pm.platforms {
+			if pm.removed[p.ID] {
+				delete(pm.byID, p.ID)
+				continue
+			}
+			kept = append(kept, p)
+		}
+		pm.platforms = kept
+		pm.removed = make(map[entity.PlatformID]bool)
+	}
+	for _, p := range pm.added {
+		if pm.removed[p.ID] {
+			continue
+		}
+		pm.byID[p.ID] = p
+		pm.platforms = append(pm.platforms, p)
+	}
+	pm.added = nil
+}
+
+// move scrolls the platforms down and swings the moving ones, with their riders along, it comes
+// before the riders move by themselves so they start the step where their platform took them
+func (pm *platformManager) move(dt float64) {
+	for id := range pm.aboard {
+		delete(pm.aboard, id)
+	}
+	for _, r := range pm.riders {
+		if id, ok := r.Standing(); ok {
+			pm.aboard[id] = append(pm.aboard[id], r)
+		}
+	}
+	for _, p := range pm.platforms {
+		p.Rect = p.Rect.Moved(pixel.V(0, -dt*Scroll))
+		p.Moved = pixel.ZV
+		if p.Swing != 0 {
+			p.SwingTime += dt
+			x := p.BaseX + p.Swing*math.Sin(p.SwingTime*p.SwingSpeed)
+			p.Moved = pixel.V(x-p.Rect.Min.X, 0)
+			p.Rect = p.Rect.Moved(p.Moved)
+		}
+		// the scroll moves everything alike, the riders only need the platform's own move
+		if p.Moved != pixel.ZV {
+			for _, r := range pm.aboard[p.ID] {
+				r.Carry(p.Moved)
+			}
+		}
+	}
+}
+
+// update runs the hazards, crumbles the crumbly platforms stood on for long enough, drops the
+// ones that fell out of the tower and lets the spawner add new ones at the top
+func (pm *platformManager) update(dt float64) {
+	for _, p := range pm.platforms {
+		if p.Hazard != nil {
+			p.Hazard.Update(dt, p)
+		}
+		p.Flash = math.Max(0, p.Flash-dt)
+		if crumbles := materialOf(p).Crumbles; crumbles > 0 {
+			if p.Crumble == 0 && len(pm.aboardOf(p.ID)) > 0 {
+				p.Crumble = crumbles
+			} else if p.Crumble > 0 {
+				p.Crumble -= dt
+				if p.Crumble <= 0 {
+					pm.remove(p.ID)
+				}
+			}
+		}
+		if p.Rect.Max.Y < -128 {
+			pm.remove(p.ID)
+		}
+	}
+	pm.Spawner.update(dt, pm)
+	pm.flush()
+}
+
+// randomPlatform makes a new platform at height y
+func randomPlatform(y float64) entity.Platform {
+	r := float64(rng.Gameplay.Int63n(240))
+	pf := entity.Platform{Rect: pixel.R(-160+r, y, -80+r, y+2), Color: RandomNiceColor(), Mat: randomMaterial()}
+	// every now and then a ramp, going either way
+	if rng.Gameplay.Float64() < 0.15 {
+		pf.Slope = float64(8 + rng.Gameplay.Intn(9))
+		if rng.Gameplay.Intn(2) == 0 {
+			pf.Slope = -pf.Slope
+		}
+	}
+	return pf
+}
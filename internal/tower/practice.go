@@ -0,0 +1,133 @@
+package tower
+
+import (
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+)
+
+// SimSnapshot is a copy of everything in a sim and the world around it, for practice save states
+// and, spelled out as a simState, for Snapshot
+type SimSnapshot struct {
+	phys      physics.Body
+	platforms []entity.Platform
+	nextID    entity.PlatformID
+	spawner   spawner
+	goals     goalManager
+	boss      boss
+	decor     []decoration
+	decorTop  float64
+	rules     Mutator
+	muts      []*Mutator
+	style     string
+	assist    bool
+	adapt     adaptive
+	ceremony  bool
+	hold      float64
+	picks     []*Mutator
+	offer     []*Mutator
+	prestige  prestige
+
+	climbed, spe float64
+	score        int
+	tick         uint32
+	elapsed      float64
+	// seed reseeds the gameplay numbers at the snapshot and at every restore, so the tower grows
+	// back the same
+	seed int64
+}
+
+// Save copies the sim, it's only taken between updates, when nothing is waiting to be flushed
+func (s *Sim) Save() *SimSnapshot {
+	ss := &SimSnapshot{
+		phys:     *s.Phys,
+		nextID:   s.Platforms.nextID,
+		spawner:  *s.Platforms.Spawner,
+		goals:    s.Goals.clone(),
+		boss:     *s.Boss,
+		climbed:  Climbed,
+		spe:      Scroll,
+		score:    Score,
+		tick:     s.Tick,
+		elapsed:  s.Elapsed,
+		rules:    s.rules,
+		muts:     append([]*Mutator(nil), s.muts...),
+		style:    s.style,
+		assist:   s.assist,
+		adapt:    s.Adapt,
+		ceremony: s.ceremony,
+		hold:     s.Hold,
+		picks:    append([]*Mutator(nil), s.picks...),
+		offer:    append([]*Mutator(nil), s.Offer...),
+		prestige: s.Prestige,
+		seed:     rng.Gameplay.Int63(),
+	}
+	for _, p := range s.Platforms.All() {
+		ss.platforms = append(ss.platforms, p.Clone())
+	}
+	ss.decor, ss.decorTop = append(ss.decor, s.Decor.decor...), s.Decor.top
+	rng.Seed(ss.seed)
+	return ss
+}
+
+// Load puts the sim back to the snapshot, in place, since the event bus and the animation
+// hold on to its parts
+func (s *Sim) Load(ss *SimSnapshot) {
+	*s.Phys = ss.phys
+	// the goals keep the tower's colors, a snapshot read back from its state has none
+	palette := s.Goals.palette
+	*s.Goals = ss.goals.clone()
+	s.Goals.SetPalette(palette)
+	*s.Boss = ss.boss
+	*s.Platforms.Spawner = ss.spawner
+
+	pm := s.Platforms
+	pm.nextID = ss.nextID
+	pm.byID = make(map[entity.PlatformID]*entity.Platform)
+	pm.platforms = nil
+	pm.added = nil
+	pm.removed = make(map[entity.PlatformID]bool)
+	for _, p := range ss.platforms {
+		p := p.Clone()
+		pm.byID[p.ID] = &p
+		pm.platforms = append(pm.platforms, &p)
+	}
+
+	s.Decor.decor, s.Decor.top = append(s.Decor.decor[:0], ss.decor...), ss.decorTop
+
+	Climbed, Scroll, Score = ss.climbed, ss.spe, ss.score
+	s.Tick, s.Elapsed = ss.tick, ss.elapsed
+	s.rules, s.muts = ss.rules, append([]*Mutator(nil), ss.muts...)
+	s.style, s.assist, s.Adapt = ss.style, ss.assist, ss.adapt
+	s.ceremony, s.Hold = ss.ceremony, ss.hold
+	StyleByName(ss.style).apply(&s.base)
+	s.picks = append([]*Mutator(nil), ss.picks...)
+	s.Offer = append([]*Mutator(nil), ss.offer...)
+	s.Prestige = ss.prestige
+	rng.Seed(ss.seed)
+}
+
+// Reroll throws away the platforms above y and lets the spawner make new ones in their place
+func (s *Sim) Reroll(y float64) {
+	pm := s.Platforms
+	top := pm.Spawner.top
+	for _, p := range pm.All() {
+		if p.Rect.Min.Y > y {
+			pm.remove(p.ID)
+			if p.Rect.Min.Y < top {
+				top = p.Rect.Min.Y
+			}
+		}
+	}
+	pm.flush()
+	pm.Spawner.top = top
+	pm.Spawner.update(0, pm)
+	pm.flush()
+}
+
+// StartAt moves the start of the run up to the floor, the tower is generated for that height from
+// there on, with its tier's chunks and hazards and the bosses still to come above it
+func (s *Sim) StartAt(floor int) {
+	Climbed = float64(floor * physics.FloorHeight)
+	s.Phys.Floor = floor
+}
@@ -0,0 +1,77 @@
+package tower
+
+import (
+	"fmt"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/text"
+	"golang.org/x/image/colornames"
+)
+
+// once the scroll speed is within prestigeCap of MaxSpeed and stays there for prestigeFloors, the
+// run loops into the next prestige tier: the speed's clock goes back to prestigeClock seconds, a
+// bit slower, every tier makes a hazard prestigeHazard more likely and brings in the flames, and
+// the goals are worth one more time their value per tier
+const (
+	prestigeCap    = 0.97
+	prestigeFloors = 60
+	prestigeClock  = 100
+	prestigeHazard = 0.04
+)
+
+// PrestigeReached is published when the run loops into the next prestige tier
+type PrestigeReached struct {
+	Tier int
+}
+
+// prestige is how many times the run has looped
+type prestige struct {
+	Tier int
+	// Since is the floor the speed reached the cap at, zero while it's below
+	Since int
+	// Rewind is how far the speed's clock was turned back
+	Rewind float64
+}
+
+// mult is what the goals are worth times their value in the tier
+func (p *prestige) mult() int {
+	return p.Tier + 1
+}
+
+// clock is the time the scroll speed goes by, the run's time less the loops' rewinds
+func (s *Sim) clock() float64 {
+	return s.Elapsed - s.Prestige.Rewind
+}
+
+// climb counts the floors climbed at the capped speed, and loops the run when there are enough
+func (s *Sim) climb(floor int) {
+	p := &s.Prestige
+	if s.speed(s.clock()) < prestigeCap*MaxSpeed {
+		p.Since = 0
+		return
+	}
+	if p.Since == 0 {
+		p.Since = floor
+		return
+	}
+	if floor-p.Since < prestigeFloors {
+		return
+	}
+	p.Tier++
+	p.Since = 0
+	p.Rewind = s.Elapsed - prestigeClock
+	Bus.Publish(PrestigeReached{Tier: p.Tier})
+}
+
+// Draw shows the tier and its multiplier in the top right corner of view, nothing before the
+// first loop
+func (p *prestige) Draw(txt *text.Text, t pixel.Target, view pixel.Rect) {
+	if p.Tier == 0 {
+		return
+	}
+	txt.Clear()
+	txt.Color = colornames.Gold
+	fmt.Fprintf(txt, "prestige %d  x%d", p.Tier, p.mult())
+	b := txt.Bounds()
+	txt.Draw(t, pixel.IM.Moved(pixel.V(view.Max.X-b.W()-4, view.Max.Y-b.H()-2)))
+}
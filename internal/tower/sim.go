@@ -0,0 +1,219 @@
+// Package tower is Gopher Up without a window: the simulation of a run, with its platforms, goals,
+// boss, mutators and picks, the chunks the tower is built from and the bot that plays it. The game
+// draws it and feeds it the keyboard, tools like gotower-balance run it headless.
+package tower
+
+import (
+	"fmt"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// Sim is the tower without a window: the gopher's physics, the platforms, the goal and the boss.
+// The game screen draws it, tools like the balance harness run it headless.
+type Sim struct {
+	Phys      *physics.Body
+	Platforms *platformManager
+	Goals     *goalManager
+	Boss      *boss
+	// Decor is the decorations on the wall, they don't take part in anything
+	Decor *decorLayer
+	// rules are the run's mutators and picks together, see mutate and applyRules, the physics
+	// are changed from base, which is the style's, see Restyle
+	rules Mutator
+	muts  []*Mutator
+	base  physics.Body
+	style string
+	// assist is the timing assist, see SetAssist, adapt the adaptive difficulty, see SetAdaptive
+	assist bool
+	Adapt  adaptive
+	// Pool is what's picked from at the milestones, Offer what's on offer right now, nil when
+	// nothing is, and picks what was picked for the run so far
+	Pool  []*Mutator
+	Offer []*Mutator
+	picks []*Mutator
+	// Tick is the step the next command is for
+	Tick uint32
+	// Elapsed is the simulated time of the run, speed gives the scroll speed for it, see clock
+	Elapsed  float64
+	speed    func(t float64) float64
+	Prestige prestige
+	// ceremony is whether every run starts with a countdown, hold what's left of it, see
+	// SetCountdown
+	ceremony bool
+	Hold     float64
+}
+
+// ResetWorld puts the global state back to the start of a run with the seed, for tools that play
+// many runs in one go
+func ResetWorld(seed int64) {
+	rng.Seed(seed)
+	Bus = &EventBus{}
+	Climbed, Score, Scroll = 0, 0, StartSpeed
+}
+
+// PhysicsWorld is the tower around the gopher as its physics see it
+func PhysicsWorld() physics.World {
+	return physics.World{Scroll: Scroll, Climbed: Climbed, Normal: normal, Publish: Bus.Publish}
+}
+
+func NewSim(chunks []*Chunk) *Sim {
+	s := &Sim{rules: combine(nil), speed: scrollSpeed, style: MoveStyles[0].Name}
+	s.Phys = &physics.Body{Rect: pixel.R(-6, -120+startY, 6, -120+startY+14)}
+	MoveStyles[0].apply(s.Phys)
+	s.base = *s.Phys
+
+	// the bottom of the tower is one of the openings, the spawner goes on right above it
+	opening := pickOpening(chunks)
+	if opening == nil {
+		opening = plainOpening
+	}
+	s.Decor = newDecorLayer()
+	sp := newSpawner(s.Phys, chunks)
+	sp.decor = s.Decor
+	sp.top = -120 + opening.height()
+	s.Platforms = newPlatformManager(sp)
+	s.Platforms.board(s.Phys)
+	for i := range opening.Platforms {
+		s.Platforms.Add(opening.platform(i, -120, false))
+	}
+	s.Platforms.flush()
+	opening.decorate(s.Decor, -120, false)
+
+	s.Goals = newGoalManager(entity.Goal{
+		Pos:    pixel.V(opening.Goal.X, -120+opening.Goal.Y),
+		Radius: 5,
+		Step:   1.0 / 7,
+		Value:  1,
+	})
+
+	s.SetAssist(false)
+	s.SetAdaptive(false)
+
+	s.Boss = newBoss()
+	Bus.Subscribe(s.Boss.onEvent)
+	Bus.Subscribe(s.onEvent)
+	return s
+}
+
+// Update advances the tower by dt with the gopher controlled by the command, which has to be
+// for the current tick, anything else is a bug in whatever made it
+func (s *Sim) Update(dt float64, cmd Command) {
+	if cmd.Tick != s.Tick {
+		panic(fmt.Sprintf("command for tick %d at tick %d", cmd.Tick, s.Tick))
+	}
+	s.Tick++
+	if i := cmd.pick(); i >= 0 && i < len(s.Offer) {
+		s.pick(s.Offer[i])
+	}
+	// the tower waits for the countdown, its time doesn't count
+	hold := s.holding(dt, cmd)
+	if !hold {
+		s.Elapsed += dt
+	}
+	Scroll = s.speed(s.clock()) * s.rules.Scroll
+	if hold {
+		Scroll = 0
+	}
+	ctrl := cmd.ctrl()
+	if s.rules.Mirror {
+		ctrl.X = -ctrl.X
+	}
+	// the platforms move first, the gopher moves from where its platform took it
+	s.Platforms.move(dt)
+	s.Phys.Update(dt, ctrl, s.Platforms.All(), PhysicsWorld())
+	Climbed += dt * Scroll
+
+	// update the platforms, the boss keeps the spawner to plain arena platforms
+	s.Platforms.Spawner.arena = s.Boss.active()
+	s.Platforms.Spawner.prestige = s.Prestige.Tier
+	s.Boss.update(dt, s.Phys, s.Platforms)
+	s.Platforms.update(dt)
+	s.Decor.update(dt)
+	for _, p := range s.Platforms.All() {
+		if p.Hazard != nil && p.Hazard.Hits(s.Phys.Rect, p) {
+			s.Phys.Die(p.Hazard.Kind.String(), PhysicsWorld())
+			break
+		}
+	}
+	s.watch()
+	s.Goals.update(dt, s.Platforms, s.Phys, s.rules.Magnet, s.Prestige.mult())
+}
+
+// DrawTower adds the platforms, their hazards, the goals, the boss and the walls, if there are any,
+// to imd. With tiles, the
+// platforms themselves go in their batch instead, to be drawn under imd; nil draws flat rects.
+// The goals go wherever goals draws them.
+func (s *Sim) DrawTower(imd *imdraw.IMDraw, tiles *TileSet, goals GoalRenderer) {
+	if tiles != nil {
+		tiles.clear()
+	}
+	for _, p := range s.Platforms.All() {
+		if a := s.visibility(p); a > 0 {
+			if tiles != nil {
+				tiles.add(p, a)
+			} else {
+				p.DrawFaded(imd, a)
+			}
+			p.DrawMarks(imd, a)
+		}
+	}
+	for _, p := range s.Platforms.All() {
+		p.DrawHazard(imd)
+	}
+	s.Goals.draw(imd, goals)
+	s.Boss.draw(imd)
+	if !s.rules.Wrap {
+		drawWalls(imd)
+	}
+}
+
+func (s *Sim) onEvent(e Event) {
+	switch e := e.(type) {
+	case physics.Landed:
+		s.Platforms.highlight(s.Phys.GroundID)
+	case physics.FloorReached:
+		if e.Floor%pickFloors == 0 {
+			s.offerPicks()
+		}
+		s.climb(e.Floor)
+		s.adjust(adaptFloor)
+	case physics.Died:
+		s.Adapt.low = false
+		s.adjust(-adaptDeath)
+		// the picks are for the run, and it's over, the next one starts with the mutators' lives
+		if len(s.picks) > 0 {
+			s.picks = nil
+			s.applyRules()
+		}
+		s.Phys.Lives = s.rules.Lives
+		s.Offer = nil
+		s.Prestige = prestige{}
+		if s.ceremony {
+			s.startCountdown()
+		}
+	}
+	// the reward for surviving the boss, a big goal on top of the tower
+	if _, ok := e.(BossSurvived); ok {
+		pf := s.Platforms.newest()
+		x := pf.Rect.Center().X
+		s.Goals.add(entity.Goal{
+			Pos:    pixel.V(x, pf.Top(x)+14),
+			Radius: 9,
+			Step:   1.0 / 14,
+			Value:  5 * s.rules.GoalValue,
+			On:     pf.ID,
+			Offset: x - pf.Rect.Min.X,
+			Bonus:  true,
+		})
+	}
+}
+
+// SimStep is the length of a step of the tower while playing, it's simulated in steps of the same
+// length whatever the frame rate, so the jumps and the collisions don't change with it
+const SimStep = 1.0 / 120
@@ -0,0 +1,430 @@
+package tower
+
+import (
+	"encoding/json"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/savefile"
+
+	"github.com/faiface/pixel"
+	"github.com/pkg/errors"
+)
+
+// simFile is the version of the simState, see savefile, a migration for a change to it goes on
+// the end of its list
+var simFile = savefile.NewKind("simulation", savefile.Baseline)
+
+// simState is a SimSnapshot with everything spelled out, to go to bytes: for saving a run to
+// resume it, rewinding, or sending it to someone spectating. The platforms' materials go by
+// name, the gameplay numbers by the seed they're reseeded with. None of the numbers are left out
+// when they're zero, a -0 has to come back as one for the replays to hash the same.
+type simState struct {
+	Version int `json:"version"`
+
+	Phys      physState       `json:"phys"`
+	Platforms []platformState `json:"platforms"`
+	NextID    int             `json:"nextID"`
+	Spawner   spawnerState    `json:"spawner"`
+	Goals     []goalState     `json:"goals"`
+	GoalValue int             `json:"goalValue"`
+	GoalReach float64         `json:"goalReach"`
+	Boss      bossSave        `json:"boss"`
+	Decor     []decorState    `json:"decor"`
+	DecorTop  float64         `json:"decorTop"`
+	Rules     Mutator         `json:"rules"`
+	Mutators  []Mutator       `json:"mutators"`
+	Style     string          `json:"style"`
+	Assist    bool            `json:"assist"`
+	Adaptive  adaptiveState   `json:"adaptive"`
+	Ceremony  bool            `json:"ceremony"`
+	Hold      float64         `json:"hold"`
+	Picks     []Mutator       `json:"picks"`
+	Offer     []Mutator       `json:"offer"`
+	Prestige  prestigeState   `json:"prestige"`
+
+	Climbed float64 `json:"climbed"`
+	Speed   float64 `json:"speed"`
+	Score   int     `json:"score"`
+	Tick    uint32  `json:"tick"`
+	Elapsed float64 `json:"elapsed"`
+	Seed    int64   `json:"seed"`
+}
+
+type physState struct {
+	Gravity   float64    `json:"gravity"`
+	RunSpeed  float64    `json:"runSpeed"`
+	RunAccel  float64    `json:"runAccel"`
+	AirAccel  float64    `json:"airAccel"`
+	JumpSpeed float64    `json:"jumpSpeed"`
+	MaxFall   float64    `json:"maxFall"`
+	FastFall  float64    `json:"fastFall"`
+	Rect      pixel.Rect `json:"rect"`
+	Vel       pixel.Vec  `json:"vel"`
+	Ground    bool       `json:"ground"`
+	GroundID  int        `json:"groundID"`
+	GroundMat string     `json:"groundMat,omitempty"`
+	Normal    pixel.Vec  `json:"normal"`
+	Floor     int        `json:"floor"`
+	Lives     int        `json:"lives"`
+	Coyote    float64    `json:"coyote"`
+	Buffer    float64    `json:"buffer"`
+	Ledge     float64    `json:"ledge"`
+	Queued    float64    `json:"queued"`
+	Wrap      bool       `json:"wrap"`
+	// Stamina is whether the stamina mutator is on, StaminaLeft what's left of the bar
+	Stamina     bool    `json:"stamina,omitempty"`
+	StaminaLeft float64 `json:"staminaLeft,omitempty"`
+}
+
+type platformState struct {
+	ID         int          `json:"id"`
+	Rect       pixel.Rect   `json:"rect"`
+	Color      pixel.RGBA   `json:"color"`
+	Material   string       `json:"material,omitempty"`
+	Slope      float64      `json:"slope"`
+	Swing      float64      `json:"swing"`
+	SwingSpeed float64      `json:"swingSpeed"`
+	SwingTime  float64      `json:"swingTime"`
+	BaseX      float64      `json:"baseX"`
+	Hazard     *hazardState `json:"hazard,omitempty"`
+	Flash      float64      `json:"flash"`
+	Crumble    float64      `json:"crumble"`
+}
+
+type hazardState struct {
+	Kind   string  `json:"kind"`
+	Offset float64 `json:"offset"`
+	Width  float64 `json:"width"`
+	Speed  float64 `json:"speed"`
+	Radius float64 `json:"radius"`
+	Spin   float64 `json:"spin"`
+}
+
+type spawnerState struct {
+	Gravity     float64 `json:"gravity"`
+	JumpSpeed   float64 `json:"jumpSpeed"`
+	RunSpeed    float64 `json:"runSpeed"`
+	Margin      float64 `json:"margin"`
+	Hazards     float64 `json:"hazards"`
+	Tries       int     `json:"tries"`
+	ChunkChance float64 `json:"chunkChance"`
+	Arena       bool    `json:"arena"`
+	Prestige    int     `json:"prestige"`
+	Top         float64 `json:"top"`
+}
+
+type prestigeState struct {
+	Tier   int     `json:"tier"`
+	Since  int     `json:"since"`
+	Rewind float64 `json:"rewind"`
+}
+
+type adaptiveState struct {
+	On    bool    `json:"on"`
+	Level float64 `json:"level"`
+	Low   bool    `json:"low"`
+}
+
+type goalState struct {
+	Pos     pixel.Vec     `json:"pos"`
+	Radius  float64       `json:"radius"`
+	Step    float64       `json:"step"`
+	Value   int           `json:"value"`
+	Counter float64       `json:"counter"`
+	Cols    [5]pixel.RGBA `json:"cols"`
+	Shade   int           `json:"shade,omitempty"`
+	Vel     pixel.Vec     `json:"vel"`
+	On      int           `json:"on,omitempty"`
+	Offset  float64       `json:"offset"`
+	Risky   bool          `json:"risky,omitempty"`
+	Bonus   bool          `json:"bonus,omitempty"`
+}
+
+// bossSave is the boss's state, bossState is taken by what it's doing
+type bossSave struct {
+	State    int       `json:"state"`
+	Timer    float64   `json:"timer"`
+	Sweeps   int       `json:"sweeps"`
+	Pos      pixel.Vec `json:"pos"`
+	Dir      float64   `json:"dir"`
+	Size     pixel.Vec `json:"size"`
+	Speed    float64   `json:"speed"`
+	Warning  float64   `json:"warning"`
+	Rest     float64   `json:"rest"`
+	MaxSweep int       `json:"maxSweep"`
+}
+
+type decorState struct {
+	Kind  int        `json:"kind"`
+	Pos   pixel.Vec  `json:"pos"`
+	Phase float64    `json:"phase"`
+	Color pixel.RGBA `json:"color"`
+}
+
+// Snapshot is the whole state of the sim as bytes, Restore puts it back. Like snapshot, it's
+// only taken between updates, and reseeds the gameplay numbers.
+func (s *Sim) Snapshot() ([]byte, error) {
+	st := s.Save().state()
+	data, err := json.Marshal(st)
+	return data, errors.Wrap(err, "error saving the simulation")
+}
+
+// Restore puts the sim back to the state from Snapshot, the chunks stay the sim's own
+func (s *Sim) Restore(data []byte) error {
+	data, _, err := simFile.Upgrade(data)
+	if err != nil {
+		return err
+	}
+	var st simState
+	if err := json.Unmarshal(data, &st); err != nil {
+		return errors.Wrap(err, "error loading the simulation")
+	}
+	ss := st.snapshot()
+	ss.spawner.chunks, ss.spawner.decor = s.Platforms.Spawner.chunks, s.Decor
+	s.Load(ss)
+	return nil
+}
+
+// state spells the snapshot out
+func (ss *SimSnapshot) state() simState {
+	gp := &ss.phys
+	st := simState{
+		Version: simFile.Version(),
+		Phys: physState{
+			Gravity:     gp.Gravity,
+			RunSpeed:    gp.RunSpeed,
+			RunAccel:    gp.RunAccel,
+			AirAccel:    gp.AirAccel,
+			JumpSpeed:   gp.JumpSpeed,
+			MaxFall:     gp.MaxFall,
+			FastFall:    gp.FastFall,
+			Rect:        gp.Rect,
+			Vel:         gp.Vel,
+			Ground:      gp.Ground,
+			GroundID:    int(gp.GroundID),
+			Normal:      gp.Normal,
+			Floor:       gp.Floor,
+			Lives:       gp.Lives,
+			Coyote:      gp.Coyote,
+			Buffer:      gp.Buffer,
+			Ledge:       gp.Ledge,
+			Queued:      gp.Queued,
+			Wrap:        gp.Wrap,
+			Stamina:     gp.Stamina.On,
+			StaminaLeft: gp.Stamina.Left,
+		},
+		NextID: int(ss.nextID),
+		Spawner: spawnerState{
+			Gravity:     ss.spawner.Gravity,
+			JumpSpeed:   ss.spawner.jumpSpeed,
+			RunSpeed:    ss.spawner.runSpeed,
+			Margin:      ss.spawner.margin,
+			Hazards:     ss.spawner.hazards,
+			Tries:       ss.spawner.tries,
+			ChunkChance: ss.spawner.chunkChance,
+			Arena:       ss.spawner.arena,
+			Prestige:    ss.spawner.prestige,
+			Top:         ss.spawner.top,
+		},
+		GoalValue: ss.goals.value,
+		GoalReach: ss.goals.reach,
+		Boss: bossSave{
+			State:    int(ss.boss.State),
+			Timer:    ss.boss.Timer,
+			Sweeps:   ss.boss.sweeps,
+			Pos:      ss.boss.Pos,
+			Dir:      ss.boss.dir,
+			Size:     ss.boss.size,
+			Speed:    ss.boss.speed,
+			Warning:  ss.boss.warning,
+			Rest:     ss.boss.rest,
+			MaxSweep: ss.boss.maxSweep,
+		},
+		DecorTop: ss.decorTop,
+		Climbed:  ss.climbed,
+		Speed:    ss.spe,
+		Score:    ss.score,
+		Tick:     ss.tick,
+		Elapsed:  ss.elapsed,
+		Rules:    ss.rules,
+		Style:    ss.style,
+		Assist:   ss.assist,
+		Adaptive: adaptiveState{On: ss.adapt.on, Level: ss.adapt.Level, Low: ss.adapt.low},
+		Ceremony: ss.ceremony,
+		Hold:     ss.hold,
+		Prestige: prestigeState{Tier: ss.prestige.Tier, Since: ss.prestige.Since, Rewind: ss.prestige.Rewind},
+		Seed:     ss.seed,
+	}
+	if gp.GroundMat != nil {
+		st.Phys.GroundMat = gp.GroundMat.Name
+	}
+	for _, p := range ss.platforms {
+		ps := platformState{
+			ID:         int(p.ID),
+			Rect:       p.Rect,
+			Color:      pixel.ToRGBA(p.Color),
+			Material:   materialOf(&p).Name,
+			Slope:      p.Slope,
+			Swing:      p.Swing,
+			SwingSpeed: p.SwingSpeed,
+			SwingTime:  p.SwingTime,
+			BaseX:      p.BaseX,
+			Flash:      p.Flash,
+			Crumble:    p.Crumble,
+		}
+		if h := p.Hazard; h != nil {
+			ps.Hazard = &hazardState{Kind: h.Kind.String(), Offset: h.Offset, Width: h.Width, Speed: h.Speed, Radius: h.Radius, Spin: h.Spin}
+		}
+		st.Platforms = append(st.Platforms, ps)
+	}
+	for _, g := range ss.goals.All() {
+		st.Goals = append(st.Goals, goalState{
+			Pos:     g.Pos,
+			Radius:  g.Radius,
+			Step:    g.Step,
+			Value:   g.Value,
+			Counter: g.Counter,
+			Cols:    g.Cols,
+			Shade:   g.Shade,
+			Vel:     g.Vel,
+			On:      int(g.On),
+			Offset:  g.Offset,
+			Risky:   g.Risky,
+			Bonus:   g.Bonus,
+		})
+	}
+	for _, m := range ss.muts {
+		st.Mutators = append(st.Mutators, *m)
+	}
+	for _, m := range ss.picks {
+		st.Picks = append(st.Picks, *m)
+	}
+	for _, m := range ss.offer {
+		st.Offer = append(st.Offer, *m)
+	}
+	for _, d := range ss.decor {
+		st.Decor = append(st.Decor, decorState{Kind: int(d.kind), Pos: d.pos, Phase: d.phase, Color: d.color})
+	}
+	return st
+}
+
+// snapshot is the snapshot the state spells out, without the spawner's chunks and decorations
+func (st *simState) snapshot() *SimSnapshot {
+	p := st.Phys
+	ss := &SimSnapshot{
+		phys: physics.Body{
+			Gravity:   p.Gravity,
+			RunSpeed:  p.RunSpeed,
+			RunAccel:  p.RunAccel,
+			AirAccel:  p.AirAccel,
+			JumpSpeed: p.JumpSpeed,
+			MaxFall:   p.MaxFall,
+			FastFall:  p.FastFall,
+			Rect:      p.Rect,
+			Vel:       p.Vel,
+			Ground:    p.Ground,
+			GroundID:  entity.PlatformID(p.GroundID),
+			Normal:    p.Normal,
+			Floor:     p.Floor,
+			Lives:     p.Lives,
+			Coyote:    p.Coyote,
+			Buffer:    p.Buffer,
+			Ledge:     p.Ledge,
+			Queued:    p.Queued,
+			Wrap:      p.Wrap,
+			Stamina:   physics.Stamina{On: p.Stamina, Left: p.StaminaLeft},
+		},
+		nextID: entity.PlatformID(st.NextID),
+		spawner: spawner{
+			Gravity:     st.Spawner.Gravity,
+			jumpSpeed:   st.Spawner.JumpSpeed,
+			runSpeed:    st.Spawner.RunSpeed,
+			margin:      st.Spawner.Margin,
+			hazards:     st.Spawner.Hazards,
+			tries:       st.Spawner.Tries,
+			chunkChance: st.Spawner.ChunkChance,
+			arena:       st.Spawner.Arena,
+			prestige:    st.Spawner.Prestige,
+			top:         st.Spawner.Top,
+		},
+		goals: goalManager{value: st.GoalValue, reach: st.GoalReach},
+		boss: boss{
+			State:    bossState(st.Boss.State),
+			Timer:    st.Boss.Timer,
+			sweeps:   st.Boss.Sweeps,
+			Pos:      st.Boss.Pos,
+			dir:      st.Boss.Dir,
+			size:     st.Boss.Size,
+			speed:    st.Boss.Speed,
+			warning:  st.Boss.Warning,
+			rest:     st.Boss.Rest,
+			maxSweep: st.Boss.MaxSweep,
+		},
+		decorTop: st.DecorTop,
+		climbed:  st.Climbed,
+		spe:      st.Speed,
+		score:    st.Score,
+		tick:     st.Tick,
+		elapsed:  st.Elapsed,
+		rules:    st.Rules,
+		style:    st.Style,
+		assist:   st.Assist,
+		adapt:    adaptive{on: st.Adaptive.On, Level: st.Adaptive.Level, low: st.Adaptive.Low},
+		ceremony: st.Ceremony,
+		hold:     st.Hold,
+		prestige: prestige{Tier: st.Prestige.Tier, Since: st.Prestige.Since, Rewind: st.Prestige.Rewind},
+		seed:     st.Seed,
+	}
+	if p.GroundMat != "" {
+		ss.phys.GroundMat = MaterialByName(p.GroundMat)
+	}
+	for _, ps := range st.Platforms {
+		pf := entity.Platform{
+			ID:         entity.PlatformID(ps.ID),
+			Rect:       ps.Rect,
+			Color:      ps.Color,
+			Mat:        MaterialByName(ps.Material),
+			Slope:      ps.Slope,
+			Swing:      ps.Swing,
+			SwingSpeed: ps.SwingSpeed,
+			SwingTime:  ps.SwingTime,
+			BaseX:      ps.BaseX,
+			Flash:      ps.Flash,
+			Crumble:    ps.Crumble,
+		}
+		if h := ps.Hazard; h != nil {
+			pf.Hazard = &entity.Hazard{Kind: entity.HazardNames[h.Kind], Offset: h.Offset, Width: h.Width, Speed: h.Speed, Radius: h.Radius, Spin: h.Spin}
+		}
+		ss.platforms = append(ss.platforms, pf)
+	}
+	for _, g := range st.Goals {
+		ss.goals.add(entity.Goal{
+			Pos:     g.Pos,
+			Radius:  g.Radius,
+			Step:    g.Step,
+			Value:   g.Value,
+			Counter: g.Counter,
+			Cols:    g.Cols,
+			Shade:   g.Shade,
+			Vel:     g.Vel,
+			On:      entity.PlatformID(g.On),
+			Offset:  g.Offset,
+			Risky:   g.Risky,
+			Bonus:   g.Bonus,
+		})
+	}
+	for i := range st.Mutators {
+		ss.muts = append(ss.muts, &st.Mutators[i])
+	}
+	for i := range st.Picks {
+		ss.picks = append(ss.picks, &st.Picks[i])
+	}
+	for i := range st.Offer {
+		ss.offer = append(ss.offer, &st.Offer[i])
+	}
+	for _, d := range st.Decor {
+		ss.decor = append(ss.decor, decoration{kind: decorKind(d.Kind), pos: d.Pos, phase: d.Phase, color: d.Color})
+	}
+	return ss
+}
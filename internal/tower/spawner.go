@@ -0,0 +1,197 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+	"GoTower/internal/rng"
+
+	"github.com/faiface/pixel"
+)
+
+// spawnMargin is the spawner's normal margin, see adjust
+const spawnMargin = 0.8
+
+// spawner fills the top of the tower with platforms, either authored chunks or single random
+// ones. It works out how far the gopher can jump from its physics and makes sure every new
+// platform (or the start of every chunk) can be reached from one below it.
+type spawner struct {
+	Gravity   float64
+	jumpSpeed float64
+	runSpeed  float64
+
+	// margin shrinks the theoretical reach, so nothing needs a pixel perfect jump
+	margin float64
+	// hazards multiplies the chance of a hazard on a random platform
+	hazards float64
+	// tries is how many random spots are attempted before giving up on randomness
+	tries int
+
+	chunks      []*Chunk
+	chunkChance float64
+
+	// arena stops the normal generation for wide, plain platforms (while the boss is around)
+	arena bool
+	// prestige is the run's prestige tier, more and new hazards come with it
+	prestige int
+
+	// top is where the next platform or chunk goes, it scrolls down with the tower, it starts
+	// right above the opening
+	top float64
+
+	// decor gets the decorations of the chunks, nil for none
+	decor *decorLayer
+}
+
+func newSpawner(phys *physics.Body, chunks []*Chunk) *spawner {
+	return &spawner{
+		Gravity:     phys.Gravity,
+		jumpSpeed:   phys.JumpSpeed,
+		runSpeed:    phys.RunSpeed,
+		margin:      spawnMargin,
+		hazards:     1,
+		tries:       20,
+		chunks:      chunks,
+		chunkChance: 0.25,
+	}
+}
+
+// tier is the current difficulty tier, from how far the tower has climbed
+func (s *spawner) tier() int {
+	t := int(Climbed/physics.FloorHeight) / TierFloors
+	if t >= tiers {
+		t = tiers - 1
+	}
+	return t
+}
+
+// update keeps the tower stocked up to just above the top of the screen
+func (s *spawner) update(dt float64, pm *platformManager) {
+	s.top -= dt * Scroll
+
+	placed := append([]*entity.Platform(nil), pm.All()...)
+	add := func(pf entity.Platform) {
+		pm.Add(pf)
+		placed = append(placed, &pf)
+	}
+	for s.top <= 130 {
+		if s.arena {
+			pf := randomPlatform(s.top)
+			pf.Rect.Max.X = math.Min(pf.Rect.Min.X+120, 160)
+			pf.Mat, pf.Slope = normal, 0
+			add(pf)
+			s.top += physics.FloorHeight
+			continue
+		}
+		if c := s.chunk(placed); c != nil {
+			for _, pf := range c {
+				add(pf)
+			}
+			continue
+		}
+		pf := s.next(placed, s.top)
+		pf.Hazard = randomHazard(&pf, s.tier(), s.prestige, s.hazards)
+		add(pf)
+		s.top += physics.FloorHeight
+	}
+}
+
+// chunk picks an authored chunk for the current tier, mirrored if that's what it takes to reach
+// it, nil if it's a random platform's turn
+func (s *spawner) chunk(below []*entity.Platform) []entity.Platform {
+	if rng.Gameplay.Float64() >= s.chunkChance {
+		return nil
+	}
+	c := pickChunk(s.chunks, s.tier())
+	if c == nil {
+		return nil
+	}
+	for _, mirror := range []bool{rng.Gameplay.Intn(2) == 0, true, false} {
+		pfs := make([]entity.Platform, len(c.Platforms))
+		lowest := 0
+		for i := range c.Platforms {
+			pfs[i] = c.platform(i, s.top, mirror)
+			if pfs[i].Rect.Min.Y < pfs[lowest].Rect.Min.Y {
+				lowest = i
+			}
+		}
+		for _, p := range below {
+			if s.reachable(p, &pfs[lowest]) {
+				if s.decor != nil {
+					c.decorate(s.decor, s.top, mirror)
+				}
+				s.top += c.height()
+				return pfs
+			}
+		}
+	}
+	return nil
+}
+
+// maxHeight is the highest the gopher can get above the platform it jumps from
+func (s *spawner) maxHeight() float64 {
+	return s.margin * s.jumpSpeed * s.jumpSpeed / (-2 * s.Gravity)
+}
+
+// reach is how far sideways the gopher can get while jumping onto something dh higher, negative
+// when it can't get that high at all
+func (s *spawner) reach(dh float64) float64 {
+	if dh > s.maxHeight() {
+		return -1
+	}
+	g := -s.Gravity
+	// time until the jump comes back down to dh
+	t := (s.jumpSpeed + math.Sqrt(s.jumpSpeed*s.jumpSpeed-2*g*dh)) / g
+	return s.margin * s.runSpeed * t
+}
+
+// reachable checks the worst case: from the lowest end of one top to the highest end of the other
+func (s *spawner) reachable(from, to *entity.Platform) bool {
+	dh := to.Rect.Max.Y + math.Max(to.Slope, 0) - (from.Rect.Max.Y + math.Min(from.Slope, 0))
+	if dh > s.maxHeight() {
+		return false
+	}
+	gap := math.Max(to.Rect.Min.X-from.Rect.Max.X, from.Rect.Min.X-to.Rect.Max.X)
+	return gap <= s.reach(dh)
+}
+
+// next makes a new platform at height y that can be reached from one of the others
+func (s *spawner) next(others []*entity.Platform, y float64) entity.Platform {
+	// only the platforms close enough below are any good to jump from
+	var from []*entity.Platform
+	for _, p := range others {
+		if p.Rect.Max.Y < y && y-p.Rect.Max.Y <= s.maxHeight() {
+			from = append(from, p)
+		}
+	}
+	for i := 0; i < s.tries && len(from) > 0; i++ {
+		pf := randomPlatform(y)
+		for _, p := range from {
+			if s.reachable(p, &pf) {
+				return pf
+			}
+		}
+	}
+
+	// no luck, put it right above the highest one below, brought down to where a jump from it
+	// still gets there when the gap is too tall
+	var highest *entity.Platform
+	for _, p := range others {
+		if p.Rect.Max.Y < y && (highest == nil || p.Rect.Max.Y > highest.Rect.Max.Y) {
+			highest = p
+		}
+	}
+	pf := randomPlatform(y)
+	if highest == nil {
+		return pf
+	}
+	pf.Slope = 0
+	x := highest.Rect.Center().X - pf.Rect.W()/2
+	x = math.Max(-160, math.Min(x, 160-pf.Rect.W()))
+	pf.Rect = pf.Rect.Moved(pixel.V(x-pf.Rect.Min.X, 0))
+	if top := highest.Rect.Max.Y + math.Min(highest.Slope, 0) + s.maxHeight(); pf.Rect.Max.Y > top {
+		pf.Rect = pf.Rect.Moved(pixel.V(0, top-pf.Rect.Max.Y))
+	}
+	return pf
+}
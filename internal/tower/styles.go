@@ -0,0 +1,66 @@
+package tower
+
+import (
+	"GoTower/internal/physics"
+	"GoTower/internal/render"
+)
+
+// moveStyle is a way for the gopher to move, picked on the profile screen: a set of its physics,
+// and the animation to go with them. The names are profile.Styles, the scores go by them.
+type moveStyle struct {
+	Name string
+
+	gravity   float64
+	runSpeed  float64
+	runAccel  float64
+	airAccel  float64
+	jumpSpeed float64
+	maxFall   float64
+	fastFall  float64
+
+	// runRate is how long a run frame lasts at full speed, stretch how much longer the gopher
+	// gets the faster it goes up or down in the air
+	runRate float64
+	stretch float64
+}
+
+// MoveStyles are the styles, the first is the one the game was made with. They all jump about
+// as high, so every tower can be climbed with any of them.
+var MoveStyles = []moveStyle{
+	{Name: "classic", gravity: -512, runSpeed: 64, runAccel: 1024, airAccel: 512, jumpSpeed: 240, maxFall: 300, fastFall: 480, runRate: render.RunRate},
+	// floaty hangs in the air and steers well there, it's slow to fall and to get going
+	{Name: "floaty", gravity: -360, runSpeed: 60, runAccel: 800, airAccel: 640, jumpSpeed: 205, maxFall: 200, fastFall: 420, runRate: 1.0 / 8, stretch: 0.06},
+	// heavy jumps hard and drops like a stone, it's quick on its feet but hard to steer midair
+	{Name: "heavy", gravity: -700, runSpeed: 70, runAccel: 1400, airAccel: 380, jumpSpeed: 280, maxFall: 380, fastFall: 600, runRate: 1.0 / 12, stretch: 0.15},
+}
+
+// StyleByName is the style with the name, classic when there's none
+func StyleByName(name string) *moveStyle {
+	for i := range MoveStyles {
+		if MoveStyles[i].Name == name {
+			return &MoveStyles[i]
+		}
+	}
+	return &MoveStyles[0]
+}
+
+// apply sets the style's physics on the gopher
+func (ms *moveStyle) apply(gp *physics.Body) {
+	gp.Gravity, gp.RunSpeed, gp.RunAccel, gp.AirAccel = ms.gravity, ms.runSpeed, ms.runAccel, ms.airAccel
+	gp.JumpSpeed, gp.MaxFall, gp.FastFall = ms.jumpSpeed, ms.maxFall, ms.fastFall
+}
+
+// Animate sets the style's animation on the gopher
+func (ms *moveStyle) Animate(ga *render.GopherAnim) {
+	ga.RunRate, ga.Stretch = ms.runRate, ms.stretch
+}
+
+// Restyle changes how the gopher moves, right after the sim is made, the mutators and picks go
+// on top of the style
+func (s *Sim) Restyle(name string) {
+	ms := StyleByName(name)
+	s.style = ms.Name
+	ms.apply(s.Phys)
+	ms.apply(&s.base)
+	s.applyRules()
+}
@@ -0,0 +1,115 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/entity"
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+)
+
+// the platforms are drawn from tiles.png: a row per biome, from the top grass, icy and metal,
+// each with a left end, a middle and a right end. The tiles are drawn tileW by tileH, hanging
+// down from the platform's top, whatever size the sheet's are.
+const (
+	tileW = 8
+	tileH = 6
+)
+
+// the platform styles in the settings: tiles from the biome's sheet, or the classic flat colored
+// rects, also what's drawn when tiles.png can't be loaded
+var PlatformStyles = []string{"tiles", "classic"}
+
+func KnownPlatformStyle(style string) bool {
+	for _, ps := range PlatformStyles {
+		if ps == style {
+			return true
+		}
+	}
+	return false
+}
+
+// biome is the look of the platforms in a stretch of the tower, one per difficulty tier
+type biome int
+
+const (
+	biomeGrass biome = iota
+	biomeIce
+	biomeMetal
+	biomes
+)
+
+// biomeAt is the biome at height y on the screen, it goes with the tier the floor is in
+func biomeAt(y float64) biome {
+	t := int((Climbed+y+120)/physics.FloorHeight) / TierFloors
+	if t < 0 {
+		t = 0
+	}
+	if t >= int(biomes) {
+		t = int(biomes) - 1
+	}
+	return biome(t)
+}
+
+// TileSet draws the platforms from the tile sheet, all of them in one batch
+type TileSet struct {
+	// tiles are the left end, middle and right end of every biome
+	tiles [biomes][3]*pixel.Sprite
+	batch *pixel.Batch
+}
+
+func NewTileSet(sheet pixel.Picture) *TileSet {
+	ts := &TileSet{batch: pixel.NewBatch(&pixel.TrianglesData{}, sheet)}
+	b := sheet.Bounds()
+	w, h := b.W()/3, b.H()/float64(biomes)
+	for i := range ts.tiles {
+		// the picture goes up from the bottom, the rows down from the top
+		y := b.Max.Y - float64(i+1)*h
+		for j := range ts.tiles[i] {
+			x := b.Min.X + float64(j)*w
+			ts.tiles[i][j] = pixel.NewSprite(sheet, pixel.R(x, y, x+w, y+h))
+		}
+	}
+	return ts
+}
+
+func (ts *TileSet) clear() {
+	ts.batch.Clear()
+}
+
+// add puts the platform into the batch, its ends capped and the middle tiled in between, all of
+// it tilted along the slope. The material's color tints it.
+func (ts *TileSet) add(p *entity.Platform, alpha float64) {
+	row := &ts.tiles[biomeAt(p.Rect.Max.Y)]
+	mask := pixel.Alpha(alpha)
+	if mc := materialOf(p).Color; mc != nil {
+		mask = pixel.ToRGBA(mc).Scaled(alpha)
+	}
+
+	w := p.Rect.W()
+	angle := math.Atan2(p.Slope, w)
+	// too narrow for both ends, they're squeezed to meet in the middle
+	sx := math.Min(1, w/(2*tileW))
+	// stretched along the slope, so the tiles still meet end to end
+	stretch := 1 / math.Cos(angle)
+	put := func(tile *pixel.Sprite, x, width float64) {
+		center := pixel.V(x+width/2, p.Top(x+width/2)-tileH/2)
+		m := pixel.IM.
+			ScaledXY(pixel.ZV, pixel.V(width*stretch/tile.Frame().W(), tileH/tile.Frame().H())).
+			Rotated(pixel.ZV, angle).
+			Moved(center)
+		tile.DrawColorMask(ts.batch, m, mask)
+	}
+
+	// the middle first, the last one can run under the right end
+	for x := p.Rect.Min.X + tileW; x < p.Rect.Max.X-tileW; x += tileW {
+		put(row[1], x, tileW)
+	}
+	put(row[0], p.Rect.Min.X, tileW*sx)
+	put(row[2], p.Rect.Max.X-tileW*sx, tileW*sx)
+}
+
+func (ts *TileSet) Draw(t pixel.Target) {
+	ts.batch.Draw(t)
+}
@@ -0,0 +1,61 @@
+package tower
+
+import (
+	"math"
+
+	"GoTower/internal/physics"
+
+	"github.com/faiface/pixel"
+	"github.com/faiface/pixel/imdraw"
+)
+
+// the tower's walls stand physics.WallWidth into the screen on both sides, the gopher runs into
+// their faces at ±physics.WallFace. They're built of brickW by brickH bricks, every other row
+// shifted by half a brick, and go out to ±wallOuter so the wider cameras don't see past them.
+const (
+	wallOuter = 320
+	brickW    = 12
+	brickH    = 6
+)
+
+// wallColors are the bricks in each biome, the mortar is the same darker
+var wallColors = [biomes]pixel.RGBA{
+	biomeGrass: pixel.RGB(0.45, 0.4, 0.36),
+	biomeIce:   pixel.RGB(0.5, 0.6, 0.7),
+	biomeMetal: pixel.RGB(0.35, 0.37, 0.42),
+}
+
+// drawWalls adds both walls to imd, the bricks scroll down with the tower. They cover the
+// screen and a bit more, for the cameras that pull down or frame wider.
+func drawWalls(imd *imdraw.IMDraw) {
+	const reach = 200
+	for _, side := range []float64{-1, 1} {
+		// the mortar shows between the bricks
+		imd.Color = pixel.RGB(0.15, 0.13, 0.12)
+		imd.Push(pixel.V(side*physics.WallFace, -reach), pixel.V(side*wallOuter, reach))
+		imd.Rectangle(0)
+
+		// the rows are counted from the bottom of the tower, so they keep their shift as they
+		// scroll
+		for row := math.Floor((Climbed - reach) / brickH); row*brickH-Climbed < reach; row++ {
+			y := row*brickH - Climbed
+			imd.Color = wallColors[biomeAt(y)]
+			shift := 0.0
+			if int(row)%2 != 0 {
+				shift = brickW / 2
+			}
+			for x := physics.WallFace - shift; x < wallOuter; x += brickW {
+				imd.Push(
+					pixel.V(side*math.Max(x+0.5, physics.WallFace), y+0.5),
+					pixel.V(side*(x+brickW-0.5), y+brickH-0.5),
+				)
+				imd.Rectangle(0)
+			}
+		}
+
+		// a lit edge on the face
+		imd.Color = pixel.RGB(0.8, 0.75, 0.7)
+		imd.Push(pixel.V(side*physics.WallFace, -reach), pixel.V(side*physics.WallFace, reach))
+		imd.Line(1)
+	}
+}
@@ -0,0 +1,26 @@
+package tower
+
+import "math"
+
+// the scroll speed starts at StartSpeed and eases towards MaxSpeed, most of the way there after
+// speedRamp seconds
+const (
+	StartSpeed = 20
+	MaxSpeed   = 45
+	speedRamp  = 240
+)
+
+// Scroll is how fast the tower scrolls down right now
+var Scroll float64 = StartSpeed
+
+// scrollSpeed is the speed t seconds into a run, the sim sets Scroll from it every step, so it
+// only depends on the simulated time and not on the frame rate
+func scrollSpeed(t float64) float64 {
+	return MaxSpeed - (MaxSpeed-StartSpeed)*math.Exp(-t/(speedRamp/3))
+}
+
+// Climbed is how far the tower has scrolled since the start of the run
+var Climbed float64
+
+// Score is the run's score, the goals and the bosses add to it
+var Score int = 0